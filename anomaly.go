@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	AnomalyZScore = "zscore"
+	AnomalyMad    = "mad"
+
+	DefaultAnomalyBaselineDays = 30
+	DefaultAnomalyMinSamples   = 7
+	MaxAnomalyBaselineDays     = 365
+)
+
+var (
+	AnomaliesRgx *regexp.Regexp
+
+	// DefaultAnomalyThresholds are commonly used cut offs for each method
+	DefaultAnomalyThresholds = map[string]float64{
+		AnomalyZScore: 3.0,
+		AnomalyMad:    3.5,
+	}
+
+	DefaultAnomalyDetector = AnomalyDetector{
+		Method:       AnomalyMad,
+		Threshold:    DefaultAnomalyThresholds[AnomalyMad],
+		BaselineDays: DefaultAnomalyBaselineDays,
+		MinSamples:   DefaultAnomalyMinSamples,
+	}
+)
+
+type AnomalyHandler struct{}
+
+// AnomalyDetector compares each day's value of a metric field with a personal
+// baseline made of the BaselineDays before it. Days whose score is at least
+// Threshold away from the baseline are flagged. The zscore method scores
+// against the baseline mean and standard deviation; the mad method scores
+// against the median and median absolute deviation, which is less affected
+// by earlier outliers in the baseline.
+type AnomalyDetector struct {
+	Method       string
+	Threshold    float64
+	BaselineDays int
+	MinSamples   int
+}
+
+type Anomaly struct {
+	Date      string  `json:"date"`
+	Metric    string  `json:"metric"`
+	Field     string  `json:"field"`
+	Value     float64 `json:"value"`
+	Baseline  float64 `json:"baseline"`
+	Spread    float64 `json:"spread"`
+	Score     float64 `json:"score"`
+	Direction string  `json:"direction"`
+}
+
+type Anomalies struct {
+	Start        string    `json:"start"`
+	End          string    `json:"end"`
+	Method       string    `json:"method"`
+	Threshold    float64   `json:"threshold"`
+	BaselineDays int       `json:"baseline_days"`
+	Data         []Anomaly `json:"data"`
+}
+
+func init() {
+	AnomaliesRgx = regexp.MustCompile(`^/anomalies$`)
+}
+
+// Detect returns the anomalies in every numeric field of metric between
+// start and end inclusive.
+func (d AnomalyDetector) Detect(ctx context.Context, metric Metric, start time.Time, end time.Time) ([]Anomaly, error) {
+	params := DateRangeParams{
+		StartDate: start.AddDate(0, 0, -d.BaselineDays),
+		EndDate:   end,
+	}
+
+	dailyValues, err := metric.Values(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := []Anomaly{}
+	for _, field := range metric.Fields {
+		values := dailyValues[field]
+
+		for i, dailyValue := range values {
+			if dailyValue.Date.Before(start) {
+				continue
+			}
+
+			baselineStart := dailyValue.Date.AddDate(0, 0, -d.BaselineDays)
+
+			var baseline []float64
+			for j := i - 1; j >= 0 && !values[j].Date.Before(baselineStart); j-- {
+				baseline = append(baseline, values[j].Value)
+			}
+
+			if len(baseline) < d.MinSamples {
+				continue
+			}
+
+			center, spread, score := d.score(dailyValue.Value, baseline)
+			if math.Abs(score) < d.Threshold {
+				continue
+			}
+
+			direction := "high"
+			if score < 0 {
+				direction = "low"
+			}
+
+			anomalies = append(anomalies, Anomaly{
+				Date:      dailyValue.Date.Format("2006-01-02"),
+				Metric:    metric.Name,
+				Field:     field,
+				Value:     dailyValue.Value,
+				Baseline:  center,
+				Spread:    spread,
+				Score:     score,
+				Direction: direction,
+			})
+		}
+	}
+
+	return anomalies, nil
+}
+
+// score returns the baseline's center and spread and how far value is from
+// the center. A score of zero is returned when the baseline has no spread.
+func (d AnomalyDetector) score(value float64, baseline []float64) (float64, float64, float64) {
+	var center, spread, score float64
+
+	switch d.Method {
+	case AnomalyMad:
+		center = median(baseline)
+
+		deviations := make([]float64, len(baseline))
+		for i, v := range baseline {
+			deviations[i] = math.Abs(v - center)
+		}
+		spread = median(deviations)
+
+		// 0.6745 scales the MAD so the score is comparable to a z-score. When
+		// more than half the baseline sits on the median the MAD is zero, so
+		// fall back to the mean absolute deviation scaled the same way.
+		if spread > 0 {
+			score = 0.6745 * (value - center) / spread
+		} else {
+			var sum float64
+			for _, deviation := range deviations {
+				sum += deviation
+			}
+			spread = sum / float64(len(deviations))
+
+			if spread > 0 {
+				score = (value - center) / (1.253314 * spread)
+			}
+		}
+	default:
+		var sum float64
+		for _, v := range baseline {
+			sum += v
+		}
+		center = sum / float64(len(baseline))
+
+		var squares float64
+		for _, v := range baseline {
+			squares += (v - center) * (v - center)
+		}
+		spread = math.Sqrt(squares / float64(len(baseline)-1))
+
+		if spread > 0 {
+			score = (value - center) / spread
+		}
+	}
+
+	return center, spread, score
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// annotateAnomalies runs the default detector over a single day of one
+// metric for the anomaly annotation on /date responses.
+func annotateAnomalies(metric Metric, date time.Time) ([]Anomaly, error) {
+	return DefaultAnomalyDetector.Detect(DatabaseContext, metric, date, date)
+}
+
+func (h *AnomalyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && AnomaliesRgx.MatchString(r.URL.Path):
+		h.listAnomalies(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get anomalies across all metrics
+// @Security ApiKeyAuth
+// @Description Retrieves days between start and end (inclusive) where a numeric field of any metric
+// @Description deviates from a personal baseline built from the preceding baseline_days days.
+// @Description The zscore method compares against the baseline mean and standard deviation, the mad
+// @Description method against the median and median absolute deviation. A field needs at least
+// @Description 7 baseline days before it is checked. Results are ordered by date, metric and field.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags anomalies
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param method query string false "Scoring method" Enums(zscore, mad) default(mad)
+// @Param threshold query number false "Score at or beyond which a day is flagged, 3 for zscore and 3.5 for mad by default"
+// @Param baseline_days query int false "Number of days before each day that make up its baseline" default(30)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Anomalies
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /anomalies [get]
+func (h *AnomalyHandler) listAnomalies(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(values.Get("start"), values.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	detector := DefaultAnomalyDetector
+
+	if values.Has("method") {
+		detector.Method = values.Get("method")
+
+		threshold, found := DefaultAnomalyThresholds[detector.Method]
+		if !found {
+			ErrorLog.Printf("invalid anomaly method '%s'", detector.Method)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid method '%s', expected zscore or mad", detector.Method))
+			return
+		}
+		detector.Threshold = threshold
+	}
+
+	if values.Has("threshold") {
+		detector.Threshold, err = strconv.ParseFloat(values.Get("threshold"), 64)
+		if err != nil || detector.Threshold <= 0 {
+			ErrorLog.Printf("invalid anomaly threshold '%s'", values.Get("threshold"))
+			handleError(w, http.StatusBadRequest, "Invalid threshold, expected a number greater than 0")
+			return
+		}
+	}
+
+	if values.Has("baseline_days") {
+		detector.BaselineDays, err = strconv.Atoi(values.Get("baseline_days"))
+		if err != nil || detector.BaselineDays < detector.MinSamples || detector.BaselineDays > MaxAnomalyBaselineDays {
+			ErrorLog.Printf("invalid anomaly baseline days '%s'", values.Get("baseline_days"))
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid baseline_days, expected a whole number from %d to %d", detector.MinSamples, MaxAnomalyBaselineDays))
+			return
+		}
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	results := []Anomaly{}
+	for _, metric := range Metrics {
+		metricAnomalies, err := detector.Detect(DatabaseContext, metric, startDate, endDate)
+		if err != nil {
+			ErrorLog.Printf("error detecting %s anomalies between '%s' and '%s': %v", metric.Name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		results = append(results, metricAnomalies...)
+	}
+
+	// Metrics and fields are already in a fixed order so a stable sort by date is enough
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Date < results[j].Date
+	})
+
+	anomalies := Anomalies{
+		Start:        startDate.Format("2006-01-02"),
+		End:          endDate.Format("2006-01-02"),
+		Method:       detector.Method,
+		Threshold:    detector.Threshold,
+		BaselineDays: detector.BaselineDays,
+		Data:         results,
+	}
+
+	jsonBytes, err := json.Marshal(anomalies)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log"
@@ -14,16 +14,20 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 var (
 	ListeningPort      string
 	ListRowLimit       int32
+	CursorSecretKey    []byte
 	InfoLog            *log.Logger
 	ErrorLog           *log.Logger
 	DatabaseContext    context.Context
-	DatabaseConnection *pgx.Conn
+	DatabaseConnection *pgxpool.Pool
 	ApiDatabase        *austinapi_db.Queries
+	ApiQueries         *Queries
+	OuraSync           *SyncWorker
 )
 
 func init() {
@@ -37,22 +41,50 @@ func init() {
 
 	ListRowLimit = GetInt32("LIST_ROW_LIMIT")
 
+	CursorSecretKey = []byte(GetString("CURSOR_SECRET_KEY"))
+	if len(CursorSecretKey) == 0 {
+		log.Fatalf("CURSOR_SECRET_KEY must be set to sign list cursors")
+	}
+
+	CalendarWakeTime, err = time.Parse("15:04", GetStringDefault("CALENDAR_WAKE_TIME", DefaultCalendarWakeTime))
+	if err != nil {
+		log.Fatalf("CALENDAR_WAKE_TIME must be a time like 07:00")
+	}
+
 	InfoLog = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
 	ErrorLog = log.New(os.Stdout, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
 
 	connStr := getDatabaseConnectionString()
 	DatabaseContext = context.Background()
 
-	DatabaseConnection, err = pgx.Connect(DatabaseContext, connStr)
+	DatabaseConnection, err = pgxpool.New(DatabaseContext, connStr)
 	if err != nil {
 		log.Fatalf("DB Connection error: %v", err)
 	}
 
 	ApiDatabase = austinapi_db.New(DatabaseConnection)
+	ApiQueries = NewQueries(DatabaseConnection)
+
+	// The Oura sync only runs when a personal access token is configured
+	ouraAccessToken := GetString("OURA_ACCESS_TOKEN")
+	if ouraAccessToken != "" {
+		OuraSync = NewSyncWorker(
+			NewOuraClient(GetStringDefault("OURA_BASE_URL", DefaultOuraBaseUrl), ouraAccessToken),
+			GetDurationDefault("OURA_SYNC_INTERVAL", DefaultOuraSyncInterval),
+			GetIntDefault("OURA_SYNC_LOOKBACK_DAYS", DefaultOuraSyncLookbackDays),
+		)
+	}
 }
 
 func main() {
 
+	// Subcommands such as imports run instead of the server
+	if len(os.Args) > 1 {
+		exitCode := runCommand(os.Args[1:])
+		DatabaseConnection.Close()
+		os.Exit(exitCode)
+	}
+
 	mux := http.NewServeMux()
 
 	// Serve Swagger UI files
@@ -69,25 +101,70 @@ func main() {
 		http.ServeFile(w, r, "./docs/swagger.yaml")
 	})
 
+	// Chart, heatmap and badge images also accept URLs signed by /sign
+	signingSecret := GetString("SIGNED_URL_SECRET")
+
 	// OURA RING DATA
 	mux.Handle("/sleep", authenticator(&SleepHandler{}))
-	mux.Handle("/sleep/", authenticator(&SleepHandler{}))
+	mux.Handle("/sleep/", signedAuthenticator(signingSecret, &SleepHandler{}))
 
 	mux.Handle("/readyscore", authenticator(&ReadyScoreHandler{}))
-	mux.Handle("/readyscore/", authenticator(&ReadyScoreHandler{}))
+	mux.Handle("/readyscore/", signedAuthenticator(signingSecret, &ReadyScoreHandler{}))
 
 	mux.Handle("/heartrate", authenticator(&HeartRateHandler{}))
-	mux.Handle("/heartrate/", authenticator(&HeartRateHandler{}))
+	mux.Handle("/heartrate/", signedAuthenticator(signingSecret, &HeartRateHandler{}))
 
 	mux.Handle("/stress", authenticator(&StressHandler{}))
-	mux.Handle("/stress/", authenticator(&StressHandler{}))
+	mux.Handle("/stress/", signedAuthenticator(signingSecret, &StressHandler{}))
 
 	mux.Handle("/spo2", authenticator(&Spo2Handler{}))
-	mux.Handle("/spo2/", authenticator(&Spo2Handler{}))
+	mux.Handle("/spo2/", signedAuthenticator(signingSecret, &Spo2Handler{}))
+
+	// COMBINED DATA
+	mux.Handle("/day/", authenticator(&DayHandler{}))
+
+	// ANALYTICS
+	mux.Handle("/anomalies", authenticator(&AnomalyHandler{}))
+	mux.Handle("/insights/", authenticator(&InsightsHandler{}))
+
+	// STANDARDS BASED
+	mux.Handle("/fhir/", authenticator(&FhirHandler{}))
+	mux.Handle("/omh/", authenticator(&OmhHandler{}))
+
+	// FEEDS, which also accept FEED_TOKEN as a token query parameter
+	feedToken := GetString("FEED_TOKEN")
+	mux.Handle("/calendar.ics", feedAuthenticator(feedToken, &CalendarHandler{}))
+	mux.Handle("/feed.atom", feedAuthenticator(feedToken, &FeedHandler{}))
+
+	// IMAGES
+	mux.Handle("/badge/", signedAuthenticator(signingSecret, &BadgeHandler{}))
+	mux.Handle("/sign", authenticator(&SigningHandler{Secret: signingSecret}))
+
+	// DASHBOARD, signed in with a cookie holding the JWT
+	mux.Handle("/ui/", cookieAuthenticator(&UiHandler{}))
+	mux.Handle("/ui/login", &UiSessionHandler{})
+	mux.Handle("/ui/logout", &UiSessionHandler{})
+	mux.Handle("/ui/static/", uiStaticHandler())
+
+	// EXPORTS
+	mux.Handle("/export/", authenticator(&ExportHandler{}))
+
+	// IMPORTS
+	mux.Handle("/import/", authenticator(&ImportHandler{}))
+
+	// WEBHOOKS, verified by their own signatures rather than a JWT
+	mux.Handle("/webhooks/oura", &OuraWebhookHandler{
+		Secret:            GetString("OURA_WEBHOOK_SECRET"),
+		VerificationToken: GetString("OURA_WEBHOOK_VERIFICATION_TOKEN"),
+	})
+
+	if OuraSync != nil {
+		go OuraSync.Run(DatabaseContext)
+	}
 
 	http.ListenAndServe(ListeningPort, mux)
 
-	defer DatabaseConnection.Close(DatabaseContext)
+	defer DatabaseConnection.Close()
 
 }
 
@@ -106,7 +183,7 @@ func handleError(w http.ResponseWriter, statusCode int, message string) {
 }
 
 func getIdFromUrl(regex *regexp.Regexp, url *url.URL) (int64, error) {
-	matches := regex.FindStringSubmatch(url.String())
+	matches := regex.FindStringSubmatch(url.Path)
 
 	if len(matches) < 2 {
 		return -1, fmt.Errorf("no ID found in URL path '%s'", url.String())
@@ -143,3 +220,17 @@ func getDatabaseConnectionString() string {
 		sslMode,
 	)
 }
+
+// requestBaseUrl is the scheme and host the request was made to, for the
+// absolute links that FHIR bundles and feeds expect.
+func requestBaseUrl(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	AppleHealthSource = "apple_health"
+
+	AppleHealthTimeFormat = "2006-01-02 15:04:05 -0700"
+
+	AppleHealthHeartRate        = "HKQuantityTypeIdentifierHeartRate"
+	AppleHealthOxygenSaturation = "HKQuantityTypeIdentifierOxygenSaturation"
+	AppleHealthSleepAnalysis    = "HKCategoryTypeIdentifierSleepAnalysis"
+
+	AppleHealthSleepUnspecified = "HKCategoryValueSleepAnalysisAsleepUnspecified"
+	AppleHealthSleepAsleep      = "HKCategoryValueSleepAnalysisAsleep"
+	AppleHealthSleepCore        = "HKCategoryValueSleepAnalysisAsleepCore"
+	AppleHealthSleepDeep        = "HKCategoryValueSleepAnalysisAsleepDeep"
+	AppleHealthSleepRem         = "HKCategoryValueSleepAnalysisAsleepREM"
+	AppleHealthSleepAwake       = "HKCategoryValueSleepAnalysisAwake"
+	AppleHealthSleepInBed       = "HKCategoryValueSleepAnalysisInBed"
+)
+
+// appleHealthDays accumulates records into one entry per day, so memory
+// grows with the number of days in an export rather than its size.
+type appleHealthDays struct {
+	heartRates map[string]*heartRateRollup
+	spo2s      map[string]*spo2Rollup
+	// sleeps is keyed by day and then by the source that recorded it, since
+	// a watch and a phone both logging the same night would double count
+	sleeps map[string]map[string]*austinapi_db.SaveSleepParams
+}
+
+// heartRateRollup and spo2Rollup accumulate one day of samples for imports
+// that read individual readings
+type heartRateRollup struct {
+	low   float64
+	high  float64
+	sum   float64
+	count int
+}
+
+type spo2Rollup struct {
+	sum   float64
+	count int
+}
+
+// ImportAppleHealth reads an Apple Health export.xml as a stream of tokens and
+// upserts a heart rate, spo2 and sleep row for each day on or after since.
+func ImportAppleHealth(ctx context.Context, reader io.Reader, since time.Time) (ImportReport, error) {
+	report := NewImportReport(AppleHealthSource)
+
+	days := appleHealthDays{
+		heartRates: map[string]*heartRateRollup{},
+		spo2s:      map[string]*spo2Rollup{},
+		sleeps:     map[string]map[string]*austinapi_db.SaveSleepParams{},
+	}
+
+	decoder := xml.NewDecoder(reader)
+	// Some iOS versions write export.xml with markup that is not quite well
+	// formed, so parse leniently rather than fail part way through
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("%w: error reading xml: %v", ErrImportFormat, err)
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "Record" {
+			continue
+		}
+
+		report.Records++
+		if !days.add(element.Attr, since.Format("2006-01-02")) {
+			report.Skipped++
+		}
+
+		if report.Records%100000 == 0 && ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+	}
+
+	err := days.save(ctx, &report)
+	return report, err
+}
+
+// add folds one Record into its day, returning false when the record is not
+// one that is imported.
+func (d appleHealthDays) add(attributes []xml.Attr, since string) bool {
+	record := map[string]string{}
+	for _, attribute := range attributes {
+		record[attribute.Name.Local] = attribute.Value
+	}
+
+	switch record["type"] {
+	case AppleHealthHeartRate:
+		day, value, ok := appleHealthQuantity(record)
+		if !ok || day < since {
+			return false
+		}
+
+		heartRate, found := d.heartRates[day]
+		if !found {
+			heartRate = &heartRateRollup{low: value, high: value}
+			d.heartRates[day] = heartRate
+		}
+
+		heartRate.low = math.Min(heartRate.low, value)
+		heartRate.high = math.Max(heartRate.high, value)
+		heartRate.sum += value
+		heartRate.count++
+
+	case AppleHealthOxygenSaturation:
+		day, value, ok := appleHealthQuantity(record)
+		if !ok || day < since {
+			return false
+		}
+
+		// Saturation is exported as a fraction even though its unit is %
+		if value <= 1 {
+			value *= 100
+		}
+
+		spo2, found := d.spo2s[day]
+		if !found {
+			spo2 = &spo2Rollup{}
+			d.spo2s[day] = spo2
+		}
+
+		spo2.sum += value
+		spo2.count++
+
+	case AppleHealthSleepAnalysis:
+		start, startErr := time.Parse(AppleHealthTimeFormat, record["startDate"])
+		end, endErr := time.Parse(AppleHealthTimeFormat, record["endDate"])
+		if startErr != nil || endErr != nil || end.Before(start) {
+			return false
+		}
+
+		day := end.Format("2006-01-02")
+		if day < since {
+			return false
+		}
+
+		if d.sleeps[day] == nil {
+			d.sleeps[day] = map[string]*austinapi_db.SaveSleepParams{}
+		}
+
+		sleep, found := d.sleeps[day][record["sourceName"]]
+		if !found {
+			sleep = &austinapi_db.SaveSleepParams{}
+			d.sleeps[day][record["sourceName"]] = sleep
+		}
+
+		seconds := int(end.Sub(start).Seconds())
+
+		switch record["value"] {
+		case AppleHealthSleepCore:
+			sleep.LightSleep += seconds
+			sleep.TotalSleep += seconds
+		case AppleHealthSleepDeep:
+			sleep.DeepSleep += seconds
+			sleep.TotalSleep += seconds
+		case AppleHealthSleepRem:
+			sleep.RemSleep += seconds
+			sleep.TotalSleep += seconds
+		case AppleHealthSleepUnspecified, AppleHealthSleepAsleep:
+			sleep.TotalSleep += seconds
+		case AppleHealthSleepAwake, AppleHealthSleepInBed:
+			// Time awake or just in bed is not sleep
+		default:
+			return false
+		}
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// appleHealthQuantity returns the day a quantity sample started on, in the
+// time zone it was recorded in, and its value.
+func appleHealthQuantity(record map[string]string) (string, float64, bool) {
+	start, err := time.Parse(AppleHealthTimeFormat, record["startDate"])
+	if err != nil {
+		return "", 0, false
+	}
+
+	value, err := strconv.ParseFloat(record["value"], 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return start.Format("2006-01-02"), value, true
+}
+
+func (d appleHealthDays) save(ctx context.Context, report *ImportReport) error {
+	for _, day := range sortedKeys(d.heartRates) {
+		heartRate := d.heartRates[day]
+		date, _ := time.Parse("2006-01-02", day)
+
+		_, _, err := ApiQueries.UpsertHeartRate(ctx, austinapi_db.SaveHeartRateParams{
+			Date:    date,
+			Low:     int(math.Round(heartRate.low)),
+			High:    int(math.Round(heartRate.high)),
+			Average: int(math.Round(heartRate.sum / float64(heartRate.count))),
+		})
+		if err != nil {
+			return fmt.Errorf("error saving heart rate with date '%s': %w", day, err)
+		}
+		report.Saved[HeartRateMetric.Name]++
+	}
+
+	for _, day := range sortedKeys(d.spo2s) {
+		spo2 := d.spo2s[day]
+		date, _ := time.Parse("2006-01-02", day)
+
+		_, _, err := ApiQueries.UpsertSpo2(ctx, austinapi_db.SaveSpo2Params{
+			Date:        date,
+			AverageSpo2: spo2.sum / float64(spo2.count),
+		})
+		if err != nil {
+			return fmt.Errorf("error saving spo2 with date '%s': %w", day, err)
+		}
+		report.Saved[Spo2Metric.Name]++
+	}
+
+	for _, day := range sortedKeys(d.sleeps) {
+		var sleep *austinapi_db.SaveSleepParams
+		for _, source := range sortedKeys(d.sleeps[day]) {
+			if sleep == nil || d.sleeps[day][source].TotalSleep > sleep.TotalSleep {
+				sleep = d.sleeps[day][source]
+			}
+		}
+
+		if sleep.TotalSleep == 0 {
+			continue
+		}
+
+		sleep.Date, _ = time.Parse("2006-01-02", day)
+
+		_, _, err := ApiQueries.UpsertSleepDurations(ctx, *sleep)
+		if err != nil {
+			return fmt.Errorf("error saving sleep with date '%s': %w", day, err)
+		}
+		report.Saved[SleepMetric.Name]++
+	}
+
+	return nil
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"crypto/subtle"
 	"github.com/cristalhq/jwt/v5"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -78,3 +80,70 @@ func authenticator(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// feedAuthenticator protects feeds read by apps that cannot send an
+// Authorization header, such as calendar and feed readers. A token query
+// parameter matching feedToken is accepted, otherwise the request needs a
+// bearer token as usual. An empty feedToken accepts only bearer tokens.
+func feedAuthenticator(feedToken string, next http.Handler) http.Handler {
+	bearer := authenticator(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" || feedToken == "" {
+			bearer.ServeHTTP(w, r)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(feedToken)) != 1 {
+			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// signedAuthenticator lets embeddable images through with a signed URL in
+// place of a bearer token. A signature query parameter on a path matching
+// SignableRgx must verify against signingSecret; every other request needs
+// a bearer token as usual. An empty signingSecret accepts only bearer tokens.
+func signedAuthenticator(signingSecret string, next http.Handler) http.Handler {
+	bearer := authenticator(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("signature") || signingSecret == "" || !SignableRgx.MatchString(r.URL.Path) {
+			bearer.ServeHTTP(w, r)
+			return
+		}
+
+		err := verifySignedUrl(signingSecret, r.URL, time.Now())
+		if err != nil {
+			log.Printf("rejected signed url '%s': %v", r.URL.Path, err)
+			http.Error(w, "Unauthorized: Invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cookieAuthenticator protects the HTML dashboard, which browsers open
+// without an Authorization header. The session cookie holds the same JWT a
+// bearer token would and is checked with VerifyToken. Without a valid one the
+// browser is sent to sign in and then returned to the page it asked for.
+func cookieAuthenticator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(UiSessionCookie)
+		if err == nil {
+			_, err = VerifyToken(cookie.Value)
+		}
+
+		if err != nil {
+			http.Redirect(w, r, UiLoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	BadgeFont       = "Verdana,Geneva,DejaVu Sans,sans-serif"
+	BadgeLabelColor = "#555"
+	BadgeHeight     = 20
+
+	BadgeStyleFlat       = "flat"
+	BadgeStyleFlatSquare = "flat-square"
+
+	BadgeColorNoData = "#9f9f9f"
+)
+
+var (
+	BadgeRgx *regexp.Regexp
+
+	BadgeColors = map[string]string{
+		"good":    "#4c1",
+		"fair":    "#dfb317",
+		"poor":    "#e05d44",
+		"neutral": "#007ec6",
+	}
+
+	// BadgeHeadlines are the field each metric's badge and heatmap show by
+	// default, with how the badge labels and writes it
+	BadgeHeadlines = map[string]BadgeHeadline{
+		SleepMetric.Name: {
+			Label:  "sleep",
+			Field:  "total_sleep",
+			Format: func(value float64) string { return formatSeconds(int64(value)) },
+			Good:   7 * 3600,
+			Fair:   6 * 3600,
+			Latest: func(ctx context.Context) (map[string][]DailyValue, error) {
+				results, err := ApiQueries.GetSleepsOlderThanCursor(ctx, latestKeyset)
+				return fieldValues(results), err
+			},
+		},
+		ReadyScoreMetric.Name: {
+			Label:  "readiness",
+			Field:  "score",
+			Format: func(value float64) string { return fmt.Sprintf("%.0f", value) },
+			Good:   85,
+			Fair:   70,
+			Latest: func(ctx context.Context) (map[string][]DailyValue, error) {
+				results, err := ApiQueries.GetReadyScoresOlderThanCursor(ctx, latestKeyset)
+				return fieldValues(results), err
+			},
+		},
+		HeartRateMetric.Name: {
+			Label:  "heart rate",
+			Field:  "average",
+			Format: func(value float64) string { return fmt.Sprintf("%.0f bpm", value) },
+			Latest: func(ctx context.Context) (map[string][]DailyValue, error) {
+				results, err := ApiQueries.GetHeartRatesOlderThanCursor(ctx, latestKeyset)
+				return fieldValues(results), err
+			},
+		},
+		StressMetric.Name: {
+			Label:  "high stress",
+			Field:  "high_stress_duration",
+			Format: func(value float64) string { return formatSeconds(int64(value)) },
+			Good:   1 * 3600,
+			Fair:   2 * 3600,
+			Latest: func(ctx context.Context) (map[string][]DailyValue, error) {
+				results, err := ApiQueries.GetStressesOlderThanCursor(ctx, latestKeyset)
+				return fieldValues(results), err
+			},
+		},
+		Spo2Metric.Name: {
+			Label:  "spo2",
+			Field:  "average_spo2",
+			Format: func(value float64) string { return fmt.Sprintf("%.1f%%", value) },
+			Good:   95,
+			Fair:   90,
+			Latest: func(ctx context.Context) (map[string][]DailyValue, error) {
+				results, err := ApiQueries.GetSpo2sOlderThanCursor(ctx, latestKeyset)
+				return fieldValues(results), err
+			},
+		},
+	}
+
+	// latestKeyset pages back from past every row, so a limit of one
+	// returns the newest
+	latestKeyset = KeysetParams{Date: LatestDate, ID: math.MaxInt64, RowLimit: 1}
+)
+
+type BadgeHandler struct{}
+
+// BadgeHeadline describes a metric's badge. The badge is green at or past
+// Good, yellow at or past Fair and red otherwise. Good below Fair means lower
+// is better, and leaving both zero keeps the badge blue.
+type BadgeHeadline struct {
+	Label  string
+	Field  string
+	Format func(value float64) string
+	Good   float64
+	Fair   float64
+	Latest func(ctx context.Context) (map[string][]DailyValue, error)
+}
+
+func init() {
+	BadgeRgx = regexp.MustCompile(`^/badge/([a-z0-9]+)\.svg$`)
+}
+
+func (h *BadgeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && BadgeRgx.MatchString(r.URL.Path):
+		h.getBadge(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get a status badge of a metric's latest value
+// @Security ApiKeyAuth
+// @Description Renders a shields.io style SVG badge of the most recent value of a metric, such as
+// @Description "sleep | 7h 32m" or "readiness | 84". sleep shows total sleep, readyscore the score,
+// @Description heartrate the average, stress the time in high stress and spo2 the average. The value is
+// @Description green, yellow or red against fixed thresholds, or blue for heart rate. Badges can be
+// @Description embedded without an Authorization header using a URL signed by /sign.
+// @Tags images
+// @Produce image/svg+xml
+// @Param metric path string true "Metric" Enums(sleep, readyscore, heartrate, stress, spo2)
+// @Param label query string false "Label, overriding the metric's"
+// @Param style query string false "Badge style" Enums(flat, flat-square) default(flat)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /badge/{metric}.svg [get]
+func (h *BadgeHandler) getBadge(w http.ResponseWriter, r *http.Request) {
+	name := BadgeRgx.FindStringSubmatch(r.URL.Path)[1]
+
+	headline, found := BadgeHeadlines[name]
+	if !found {
+		InfoLog.Printf("no badge for metric '%s'", name)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("No badge for %s", name))
+		return
+	}
+
+	query := r.URL.Query()
+
+	style := BadgeStyleFlat
+	if query.Has("style") {
+		style = query.Get("style")
+		if style != BadgeStyleFlat && style != BadgeStyleFlatSquare {
+			ErrorLog.Printf("unknown badge style '%s'", style)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Unknown style '%s', expected flat or flat-square", style))
+			return
+		}
+	}
+
+	label := headline.Label
+	if query.Has("label") {
+		label = query.Get("label")
+	}
+
+	values, err := headline.Latest(DatabaseContext)
+	if err != nil {
+		ErrorLog.Printf("error retrieving latest %s for badge: %v", name, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	message, color := "no data", BadgeColorNoData
+	if latest := values[headline.Field]; len(latest) > 0 {
+		message, color = headline.Format(latest[0].Value), headline.color(latest[0].Value)
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write([]byte(renderBadge(label, message, color, style)))
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+// status rates a value as good, fair or poor, or neutral when the metric has
+// no thresholds.
+func (b BadgeHeadline) status(value float64) string {
+	switch {
+	case b.Good == b.Fair:
+		return "neutral"
+	case b.Good > b.Fair && value >= b.Good, b.Good < b.Fair && value <= b.Good:
+		return "good"
+	case b.Good > b.Fair && value >= b.Fair, b.Good < b.Fair && value <= b.Fair:
+		return "fair"
+	default:
+		return "poor"
+	}
+}
+
+func (b BadgeHeadline) color(value float64) string {
+	return BadgeColors[b.status(value)]
+}
+
+// renderBadge draws a two part badge laid out like shields.io's, sizing each
+// part from an estimate of its text's width.
+func renderBadge(label string, message string, color string, style string) string {
+	labelWidth := badgeTextWidth(label) + 10
+	messageWidth := badgeTextWidth(message) + 10
+	if label == "" {
+		labelWidth = 0
+	}
+	width := labelWidth + messageWidth
+
+	radius := 3
+	if style == BadgeStyleFlatSquare {
+		radius = 0
+	}
+
+	title := html.EscapeString(message)
+	if label != "" {
+		title = html.EscapeString(label + ": " + message)
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s">`, width, BadgeHeight, title)
+	fmt.Fprintf(&svg, `<title>%s</title>`, title)
+	if style == BadgeStyleFlat {
+		svg.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	}
+	fmt.Fprintf(&svg, `<clipPath id="r"><rect width="%d" height="%d" rx="%d" fill="#fff"/></clipPath>`, width, BadgeHeight, radius)
+	svg.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="%s"/>`, labelWidth, BadgeHeight, BadgeLabelColor)
+	fmt.Fprintf(&svg, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, labelWidth, messageWidth, BadgeHeight, color)
+	if style == BadgeStyleFlat {
+		fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="url(#s)"/>`, width, BadgeHeight)
+	}
+	svg.WriteString(`</g>`)
+
+	fmt.Fprintf(&svg, `<g fill="#fff" text-anchor="middle" font-family="%s" font-size="11">`, BadgeFont)
+	for _, part := range []struct {
+		text   string
+		center float64
+	}{{label, float64(labelWidth) / 2}, {message, float64(labelWidth) + float64(messageWidth)/2}} {
+		if part.text == "" {
+			continue
+		}
+		fmt.Fprintf(&svg, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text>`, part.center, html.EscapeString(part.text))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="14">%s</text>`, part.center, html.EscapeString(part.text))
+	}
+	svg.WriteString(`</g></svg>`)
+
+	return svg.String()
+}
+
+// badgeTextWidth estimates the width of text in 11px Verdana, which has no
+// fixed width but keeps most characters close to one of a few sizes.
+func badgeTextWidth(text string) int {
+	width := 0.0
+	for _, character := range text {
+		switch {
+		case strings.ContainsRune("il.,:;!|'", character):
+			width += 3.5
+		case strings.ContainsRune(" fjrt()[]", character):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", character):
+			width += 11
+		case character >= 'A' && character <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCalendarWakeTime = "07:00"
+	DefaultCalendarDays     = 90
+
+	CalendarTimeFormat = "20060102T150405"
+	CalendarDateFormat = "20060102"
+
+	// CalendarLineOctets is the longest a content line may be before it is
+	// folded onto the next
+	CalendarLineOctets = 75
+)
+
+var (
+	CalendarRgx *regexp.Regexp
+
+	CalendarMetrics = []string{SleepMetric.Name, ReadyScoreMetric.Name, StressMetric.Name}
+
+	// CalendarWakeTime is when sleep events end, in the calendar's own time
+	// zone, since sleep is stored as durations without a bedtime
+	CalendarWakeTime time.Time
+
+	calendarText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+)
+
+type CalendarHandler struct{}
+
+// calendarEvent is one VEVENT. All day events use Date and timed events
+// Start and End, written as floating local times.
+type calendarEvent struct {
+	Uid         string
+	Stamp       time.Time
+	Date        time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+}
+
+func init() {
+	CalendarRgx = regexp.MustCompile(`^/calendar\.ics$`)
+}
+
+func (h *CalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && CalendarRgx.MatchString(r.URL.Path):
+		h.getCalendar(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get an iCalendar feed of sleep and daily scores
+// @Security ApiKeyAuth
+// @Description Returns an iCalendar (RFC 5545) feed to subscribe to from a calendar app. Each night's sleep
+// @Description is an event ending at CALENDAR_WAKE_TIME, floating in the calendar's time zone, as long as
+// @Description the total sleep, with total, deep, light and REM durations and the rating in its description.
+// @Description Readiness scores and high stress time are all day events. start and end accept a date
+// @Description (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and default to the last 90 days.
+// @Description Calendar apps that cannot send a bearer token may pass FEED_TOKEN as the token parameter.
+// @Tags feeds
+// @Produce text/calendar
+// @Param metrics query string false "Comma separated metrics" default(sleep,readyscore,stress)
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param token query string false "Feed token, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /calendar.ics [get]
+func (h *CalendarHandler) getCalendar(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	metrics := CalendarMetrics
+	if query.Has("metrics") {
+		metrics = nil
+		for _, name := range strings.Split(query.Get("metrics"), ",") {
+			name = strings.TrimSpace(name)
+			if !slices.Contains(CalendarMetrics, name) {
+				ErrorLog.Printf("unknown metric '%s' in calendar", name)
+				handleError(w, http.StatusBadRequest, fmt.Sprintf("Unknown metric '%s', expected one of %s", name, strings.Join(CalendarMetrics, ", ")))
+				return
+			}
+			metrics = append(metrics, name)
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	params := DateRangeParams{StartDate: today.AddDate(0, 0, -DefaultCalendarDays), EndDate: today}
+	if query.Get("start") != "" || query.Get("end") != "" {
+		startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+		if err != nil {
+			ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+			return
+		}
+		params = DateRangeParams{StartDate: startDate, EndDate: endDate}
+	}
+
+	var events []calendarEvent
+
+	if slices.Contains(metrics, SleepMetric.Name) {
+		sleeps, err := ApiQueries.GetSleepsByDateRange(r.Context(), params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving sleep for calendar: %v", err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		for _, sleep := range sleeps {
+			events = append(events, sleepEvent(sleep))
+		}
+	}
+
+	if slices.Contains(metrics, ReadyScoreMetric.Name) {
+		readyScores, err := ApiQueries.GetReadyScoresByDateRange(r.Context(), params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving ready score for calendar: %v", err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		for _, readyScore := range readyScores {
+			events = append(events, readyScoreEvent(readyScore))
+		}
+	}
+
+	if slices.Contains(metrics, StressMetric.Name) {
+		stresses, err := ApiQueries.GetStressesByDateRange(r.Context(), params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving stress for calendar: %v", err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		for _, stress := range stresses {
+			events = append(events, stressEvent(stress))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="austinapi.ics"`)
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write([]byte(writeCalendar(events)))
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+func sleepEvent(sleep austinapi_db.Sleep) calendarEvent {
+	end := time.Date(sleep.Date.Year(), sleep.Date.Month(), sleep.Date.Day(), CalendarWakeTime.Hour(), CalendarWakeTime.Minute(), 0, 0, time.UTC)
+
+	return calendarEvent{
+		Uid:     fmt.Sprintf("sleep-%s@austinapi", sleep.Date.Format("2006-01-02")),
+		Stamp:   sleep.UpdatedTimestamp,
+		Start:   end.Add(-time.Duration(sleep.TotalSleep) * time.Second),
+		End:     end,
+		Summary: fmt.Sprintf("Sleep %s (rating %d)", formatSeconds(int64(sleep.TotalSleep)), sleep.Rating),
+		Description: fmt.Sprintf("Total: %s\nDeep: %s\nLight: %s\nREM: %s\nRating: %d",
+			formatSeconds(int64(sleep.TotalSleep)),
+			formatSeconds(int64(sleep.DeepSleep)),
+			formatSeconds(int64(sleep.LightSleep)),
+			formatSeconds(int64(sleep.RemSleep)),
+			sleep.Rating,
+		),
+	}
+}
+
+func readyScoreEvent(readyScore austinapi_db.Readyscore) calendarEvent {
+	return calendarEvent{
+		Uid:         fmt.Sprintf("readyscore-%s@austinapi", readyScore.Date.Format("2006-01-02")),
+		Stamp:       readyScore.UpdatedTimestamp,
+		Date:        readyScore.Date,
+		Summary:     fmt.Sprintf("Readiness %d", readyScore.Score),
+		Description: fmt.Sprintf("Readiness score: %d", readyScore.Score),
+	}
+}
+
+func stressEvent(stress austinapi_db.Stress) calendarEvent {
+	return calendarEvent{
+		Uid:         fmt.Sprintf("stress-%s@austinapi", stress.Date.Format("2006-01-02")),
+		Stamp:       stress.UpdatedTimestamp,
+		Date:        stress.Date,
+		Summary:     fmt.Sprintf("High stress %s", formatSeconds(stress.HighStressDuration)),
+		Description: fmt.Sprintf("Time in high stress: %s", formatSeconds(stress.HighStressDuration)),
+	}
+}
+
+// writeCalendar renders events as a VCALENDAR with CRLF line endings and
+// long lines folded.
+func writeCalendar(events []calendarEvent) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//austinapi//calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:austinapi",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"X-PUBLISHED-TTL:PT1H",
+	}
+
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.Uid,
+			"DTSTAMP:"+event.Stamp.UTC().Format(CalendarTimeFormat)+"Z",
+		)
+
+		if event.Date.IsZero() {
+			lines = append(lines,
+				"DTSTART:"+event.Start.Format(CalendarTimeFormat),
+				"DTEND:"+event.End.Format(CalendarTimeFormat),
+			)
+		} else {
+			lines = append(lines,
+				"DTSTART;VALUE=DATE:"+event.Date.Format(CalendarDateFormat),
+				"DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format(CalendarDateFormat),
+				"TRANSP:TRANSPARENT",
+			)
+		}
+
+		lines = append(lines,
+			"SUMMARY:"+calendarText.Replace(event.Summary),
+			"DESCRIPTION:"+calendarText.Replace(event.Description),
+			"END:VEVENT",
+		)
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	var calendar strings.Builder
+	for _, line := range lines {
+		calendar.WriteString(foldCalendarLine(line))
+		calendar.WriteString("\r\n")
+	}
+	return calendar.String()
+}
+
+// foldCalendarLine splits a line longer than CalendarLineOctets, continuing
+// it on lines that start with a space. Lines are only split between UTF-8
+// characters.
+func foldCalendarLine(line string) string {
+	var folded strings.Builder
+
+	octets := 0
+	for _, character := range line {
+		size := len(string(character))
+		if octets+size > CalendarLineOctets {
+			folded.WriteString("\r\n ")
+			octets = 1
+		}
+		folded.WriteRune(character)
+		octets += size
+	}
+
+	return folded.String()
+}
+
+// formatSeconds writes a duration in seconds as hours and minutes, like 7h 05m.
+func formatSeconds(seconds int64) string {
+	minutes := (seconds + 30) / 60
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultChartDays   = 30
+	DefaultChartWidth  = 640
+	DefaultChartHeight = 320
+	DefaultChartTheme  = "light"
+
+	MinChartSize = 120
+	MaxChartSize = 2000
+	MaxChartDays = 731
+
+	ChartTypeLine    = "line"
+	ChartTypeBar     = "bar"
+	ChartTypeStacked = "stacked"
+
+	// ChartTickCount is roughly how many gridlines the value axis gets
+	ChartTickCount = 5
+
+	ChartFont = "-apple-system,'Segoe UI',Helvetica,Arial,sans-serif"
+)
+
+var (
+	ChartTypes = []string{ChartTypeLine, ChartTypeBar, ChartTypeStacked}
+
+	// ChartThemes are the named color schemes a chart can be drawn in. Fields
+	// take the series colors in the order they are requested.
+	ChartThemes = map[string]ChartTheme{
+		"light": {
+			Background: "#ffffff",
+			Text:       "#24292f",
+			Grid:       "#d8dee4",
+			Series:     []string{"#0969da", "#8250df", "#1a7f37", "#bf8700", "#cf222e", "#57606a"},
+			Scale:      []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"},
+		},
+		"dark": {
+			Background: "#0d1117",
+			Text:       "#c9d1d9",
+			Grid:       "#30363d",
+			Series:     []string{"#58a6ff", "#bc8cff", "#3fb950", "#d29922", "#f85149", "#8b949e"},
+			Scale:      []string{"#161b22", "#0e4429", "#006d32", "#26a641", "#39d353"},
+		},
+	}
+
+	// ChartHourFields are stored in seconds and charted in hours
+	ChartHourFields = []string{"total_sleep", "deep_sleep", "light_sleep", "rem_sleep", "high_stress_duration"}
+
+	ChartColorRgx *regexp.Regexp
+)
+
+type ChartTheme struct {
+	Background string
+	Text       string
+	Grid       string
+	Series     []string
+	// Scale colors heatmap cells from no value through to the highest
+	Scale []string
+}
+
+// ChartOptions describe one chart. Band shades a line chart between its
+// first and last fields, drawing any fields between them as lines.
+type ChartOptions struct {
+	Type   string
+	Fields []string
+	Start  time.Time
+	End    time.Time
+	Width  int
+	Height int
+	Theme  ChartTheme
+	Band   bool
+	Title  string
+}
+
+func init() {
+	ChartColorRgx = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+}
+
+// writeChart serves a /chart.svg request for one metric, drawing the
+// requested fields by day as a standalone SVG image.
+func writeChart(w http.ResponseWriter, r *http.Request, metric Metric) {
+	options, err := parseChartOptions(r.URL.Query(), metric)
+	if err != nil {
+		ErrorLog.Printf("error parsing %s chart options from url '%s': %v", metric.Name, r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid chart: %v", err))
+		return
+	}
+
+	InfoLog.Printf("URL chart match '%s' '%s' to '%s'\n", strings.Join(options.Fields, ","), options.Start.Format("2006-01-02"), options.End.Format("2006-01-02"))
+
+	values, err := metric.Values(DatabaseContext, DateRangeParams{StartDate: options.Start, EndDate: options.End})
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s for chart: %v", metric.Name, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write([]byte(renderChart(options, values)))
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+func parseChartOptions(query url.Values, metric Metric) (ChartOptions, error) {
+	options := ChartOptions{
+		Type:   ChartTypeLine,
+		Width:  DefaultChartWidth,
+		Height: DefaultChartHeight,
+	}
+
+	if query.Get("field") == "" {
+		return options, fmt.Errorf("field is required")
+	}
+	for _, field := range strings.Split(query.Get("field"), ",") {
+		field = strings.TrimSpace(field)
+		if !metric.HasField(field) {
+			return options, fmt.Errorf("unknown field '%s', expected one of %s", field, strings.Join(metric.Fields, ", "))
+		}
+		if slices.Contains(options.Fields, field) {
+			return options, fmt.Errorf("field '%s' is given more than once", field)
+		}
+		options.Fields = append(options.Fields, field)
+	}
+
+	// Values are drawn against one axis so the fields must share a unit
+	hours := slices.Contains(ChartHourFields, options.Fields[0])
+	for _, field := range options.Fields[1:] {
+		if slices.Contains(ChartHourFields, field) != hours {
+			return options, fmt.Errorf("fields %s do not share a unit", strings.Join(options.Fields, ", "))
+		}
+	}
+
+	if query.Has("type") {
+		options.Type = query.Get("type")
+		if !slices.Contains(ChartTypes, options.Type) {
+			return options, fmt.Errorf("unknown type '%s', expected one of %s", options.Type, strings.Join(ChartTypes, ", "))
+		}
+	}
+
+	if query.Has("band") {
+		band, err := strconv.ParseBool(query.Get("band"))
+		if err != nil {
+			return options, fmt.Errorf("band must be true or false")
+		}
+		if band && (options.Type != ChartTypeLine || len(options.Fields) < 2) {
+			return options, fmt.Errorf("band needs a line chart of at least two fields")
+		}
+		options.Band = band
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	options.Start, options.End = today.AddDate(0, 0, 1-DefaultChartDays), today
+	if query.Get("start") != "" || query.Get("end") != "" {
+		startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+		if err != nil {
+			return options, err
+		}
+		options.Start, options.End = startDate, endDate
+	}
+	if chartDays(options.Start, options.End) > MaxChartDays {
+		return options, fmt.Errorf("a chart covers at most %d days", MaxChartDays)
+	}
+
+	for _, size := range []struct {
+		name  string
+		value *int
+	}{{"width", &options.Width}, {"height", &options.Height}} {
+		if !query.Has(size.name) {
+			continue
+		}
+		value, err := strconv.Atoi(query.Get(size.name))
+		if err != nil || value < MinChartSize || value > MaxChartSize {
+			return options, fmt.Errorf("%s must be a whole number from %d to %d", size.name, MinChartSize, MaxChartSize)
+		}
+		*size.value = value
+	}
+
+	themeName := DefaultChartTheme
+	if query.Has("theme") {
+		themeName = query.Get("theme")
+	}
+	theme, found := ChartThemes[themeName]
+	if !found {
+		return options, fmt.Errorf("unknown theme '%s', expected light or dark", themeName)
+	}
+
+	if query.Has("colors") {
+		theme.Series = nil
+		for _, color := range strings.Split(query.Get("colors"), ",") {
+			color, err := chartColor(color)
+			if err != nil {
+				return options, err
+			}
+			theme.Series = append(theme.Series, color)
+		}
+	}
+
+	if query.Has("background") {
+		background := query.Get("background")
+		if background != "none" {
+			color, err := chartColor(background)
+			if err != nil {
+				return options, err
+			}
+			background = color
+		}
+		theme.Background = background
+	}
+	options.Theme = theme
+
+	options.Title = fmt.Sprintf("%s %s", metric.Name, strings.Join(options.Fields, ", "))
+	if hours {
+		options.Title += " (hours)"
+	}
+	if query.Has("title") {
+		options.Title = query.Get("title")
+	}
+
+	return options, nil
+}
+
+// chartColor accepts a hex color with or without its leading #, since a #
+// has to be escaped in a query string.
+func chartColor(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !ChartColorRgx.MatchString(value) {
+		return "", fmt.Errorf("invalid color '%s', expected a hex color such as 0969da", value)
+	}
+	return "#" + strings.TrimPrefix(value, "#"), nil
+}
+
+func chartDays(start time.Time, end time.Time) int {
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+// renderChart draws the chart. Days without a value are left empty, so gaps
+// break lines rather than being drawn as zero.
+func renderChart(options ChartOptions, values map[string][]DailyValue) string {
+	days := chartDays(options.Start, options.End)
+
+	series := make([][]float64, len(options.Fields))
+	present := make([][]bool, len(options.Fields))
+	for i, field := range options.Fields {
+		series[i] = make([]float64, days)
+		present[i] = make([]bool, days)
+
+		scale := 1.0
+		if slices.Contains(ChartHourFields, field) {
+			scale = 1.0 / 3600
+		}
+
+		for _, value := range values[field] {
+			day := chartDays(options.Start, value.Date) - 1
+			if day < 0 || day >= days {
+				continue
+			}
+			series[i][day] = value.Value * scale
+			present[i][day] = true
+		}
+	}
+
+	// Bars start from zero, lines from just below their lowest value
+	low, high := math.Inf(1), math.Inf(-1)
+	hasData := false
+	for day := 0; day < days; day++ {
+		total := 0.0
+		for i := range series {
+			if !present[i][day] {
+				continue
+			}
+			hasData = true
+			total += series[i][day]
+			low = min(low, series[i][day])
+			high = max(high, series[i][day])
+		}
+		if options.Type == ChartTypeStacked {
+			high = max(high, total)
+		}
+	}
+	if !hasData || options.Type != ChartTypeLine {
+		low = 0
+	}
+	if !hasData {
+		high = 1
+	}
+	low, high, step := chartTicks(low, high)
+
+	left, right := 52.0, float64(options.Width)-16
+	top, bottom := 48.0, float64(options.Height)-28
+	if options.Title == "" {
+		top = 32
+	}
+	slot := (right - left) / float64(days)
+	x := func(day int) float64 {
+		return left + (float64(day)+0.5)*slot
+	}
+	y := func(value float64) float64 {
+		return bottom - (value-low)/(high-low)*(bottom-top)
+	}
+	color := func(i int) string {
+		return options.Theme.Series[i%len(options.Theme.Series)]
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" font-family="%s" font-size="11">`,
+		options.Width, options.Height, options.Width, options.Height, ChartFont)
+	if options.Title != "" {
+		fmt.Fprintf(&svg, `<title>%s</title>`, html.EscapeString(options.Title))
+	}
+	if options.Theme.Background != "none" {
+		fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`, options.Theme.Background)
+	}
+
+	if options.Title != "" {
+		fmt.Fprintf(&svg, `<text x="%.1f" y="18" fill="%s" font-size="13" font-weight="600">%s</text>`, left, options.Theme.Text, html.EscapeString(options.Title))
+	}
+
+	legendX := left
+	for i, field := range options.Fields {
+		fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="%s"/>`, legendX, top-20, color(i))
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" fill="%s">%s</text>`, legendX+14, top-11, options.Theme.Text, html.EscapeString(field))
+		legendX += 14 + float64(len(field))*6.5 + 12
+	}
+
+	for n := 0; low+float64(n)*step <= high+step/2; n++ {
+		tick := low + float64(n)*step
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`, left, y(tick), right, y(tick), options.Theme.Grid)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="end">%s</text>`, left-6, y(tick)+4, options.Theme.Text, formatTick(tick, step))
+	}
+
+	labelEvery := int(math.Ceil(float64(days) / math.Max(1, (right-left)/72)))
+	labelFormat := "Jan 2"
+	if days > 366 {
+		labelFormat = "Jan 2006"
+	}
+	for day := 0; day < days; day += labelEvery {
+		date := options.Start.AddDate(0, 0, day)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">%s</text>`, x(day), bottom+18, options.Theme.Text, date.Format(labelFormat))
+	}
+
+	if !hasData {
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">No data</text>`, (left+right)/2, (top+bottom)/2, options.Theme.Text)
+	}
+
+	switch options.Type {
+	case ChartTypeLine:
+		if options.Band {
+			first, last := 0, len(series)-1
+			for _, run := range chartRuns(days, func(day int) bool { return present[first][day] && present[last][day] }) {
+				var points []string
+				for day := run[0]; day <= run[1]; day++ {
+					points = append(points, fmt.Sprintf("%.1f,%.1f", x(day), y(series[last][day])))
+				}
+				for day := run[1]; day >= run[0]; day-- {
+					points = append(points, fmt.Sprintf("%.1f,%.1f", x(day), y(series[first][day])))
+				}
+				fmt.Fprintf(&svg, `<polygon points="%s" fill="%s" fill-opacity="0.2"/>`, strings.Join(points, " "), color(first))
+			}
+		}
+
+		for i := range series {
+			width := 2
+			if options.Band && (i == 0 || i == len(series)-1) {
+				width = 1
+			}
+
+			var path strings.Builder
+			for _, run := range chartRuns(days, func(day int) bool { return present[i][day] }) {
+				fmt.Fprintf(&path, "M%.1f %.1f", x(run[0]), y(series[i][run[0]]))
+				for day := run[0] + 1; day <= run[1]; day++ {
+					fmt.Fprintf(&path, "L%.1f %.1f", x(day), y(series[i][day]))
+				}
+				if run[0] == run[1] {
+					fmt.Fprintf(&svg, `<circle cx="%.1f" cy="%.1f" r="2" fill="%s"/>`, x(run[0]), y(series[i][run[0]]), color(i))
+				}
+			}
+			if path.Len() > 0 {
+				fmt.Fprintf(&svg, `<path d="%s" fill="none" stroke="%s" stroke-width="%d" stroke-linejoin="round" stroke-linecap="round"/>`, path.String(), color(i), width)
+			}
+		}
+	case ChartTypeBar:
+		barWidth := slot * 0.8 / float64(len(series))
+		for day := 0; day < days; day++ {
+			for i := range series {
+				if !present[i][day] {
+					continue
+				}
+				barX := left + float64(day)*slot + slot*0.1 + float64(i)*barWidth
+				fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, barX, y(series[i][day]), barWidth, y(low)-y(series[i][day]), color(i))
+			}
+		}
+	case ChartTypeStacked:
+		for day := 0; day < days; day++ {
+			total := 0.0
+			for i := range series {
+				if !present[i][day] {
+					continue
+				}
+				fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, left+float64(day)*slot+slot*0.1, y(total+series[i][day]), slot*0.8, y(total)-y(total+series[i][day]), color(i))
+				total += series[i][day]
+			}
+		}
+	}
+
+	fmt.Fprintf(&svg, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`, left, bottom, right, bottom, options.Theme.Text)
+	svg.WriteString(`</svg>`)
+
+	return svg.String()
+}
+
+// chartRuns returns the first and last day of each unbroken run of days
+// for which present is true.
+func chartRuns(days int, present func(day int) bool) [][2]int {
+	var runs [][2]int
+	for day := 0; day < days; day++ {
+		if !present(day) {
+			continue
+		}
+		if len(runs) > 0 && runs[len(runs)-1][1] == day-1 {
+			runs[len(runs)-1][1] = day
+		} else {
+			runs = append(runs, [2]int{day, day})
+		}
+	}
+	return runs
+}
+
+// chartTicks widens low and high out to round numbers and picks a round
+// step between gridlines of 1, 2, 2.5 or 5 times a power of ten.
+func chartTicks(low float64, high float64) (float64, float64, float64) {
+	if high <= low {
+		high = low + 1
+	}
+
+	rough := (high - low) / ChartTickCount
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+	step := 10 * magnitude
+	for _, multiple := range []float64{1, 2, 2.5, 5} {
+		if rough <= multiple*magnitude {
+			step = multiple * magnitude
+			break
+		}
+	}
+
+	return math.Floor(low/step) * step, math.Ceil(high/step) * step, step
+}
+
+func formatTick(value float64, step float64) string {
+	decimals := 0
+	for scaled := step; math.Abs(scaled-math.Round(scaled)) > 1e-9 && decimals < 4; scaled *= 10 {
+		decimals++
+	}
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
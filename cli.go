@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runCommand runs the subcommand named by args[0] instead of the server and
+// returns the process exit code.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "import-apple-health":
+		return importAppleHealthCommand(args[1:])
+	case "backfill-oura-export":
+		return backfillOuraExportCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n", args[0])
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  import-apple-health [-since YYYY-MM-DD] export.xml")
+		fmt.Fprintln(os.Stderr, "  backfill-oura-export [-checkpoint file] [-restart] export.json")
+		return 2
+	}
+}
+
+func importAppleHealthCommand(args []string) int {
+	flags := flag.NewFlagSet("import-apple-health", flag.ContinueOnError)
+	sinceValue := flags.String("since", "", "only import days on or after this date (YYYY-MM-DD)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-apple-health [-since YYYY-MM-DD] export.xml")
+		return 2
+	}
+
+	var since time.Time
+	if *sinceValue != "" {
+		since, err = time.Parse("2006-01-02", *sinceValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-since must be YYYY-MM-DD, got '%s'\n", *sinceValue)
+			return 2
+		}
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		ErrorLog.Printf("error opening apple health export: %v", err)
+		return 1
+	}
+	defer file.Close()
+
+	report, err := ImportAppleHealth(DatabaseContext, file, since)
+	if err != nil {
+		ErrorLog.Printf("error importing apple health export: %v", err)
+		return 1
+	}
+
+	InfoLog.Printf("imported apple health export: %+v\n", report)
+	return 0
+}
+
+func backfillOuraExportCommand(args []string) int {
+	flags := flag.NewFlagSet("backfill-oura-export", flag.ContinueOnError)
+	checkpointPath := flags.String("checkpoint", "", "file recording progress so an interrupted run resumes (default export.json.checkpoint)")
+	restart := flags.Bool("restart", false, "ignore any checkpoint and load every day again")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: backfill-oura-export [-checkpoint file] [-restart] export.json")
+		return 2
+	}
+
+	if *checkpointPath == "" {
+		*checkpointPath = flags.Arg(0) + ".checkpoint"
+	}
+
+	// Stop between days on Ctrl-C so the checkpoint matches what was saved
+	ctx, stop := signal.NotifyContext(DatabaseContext, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := BackfillOuraExport(ctx, flags.Arg(0), *checkpointPath, *restart)
+	if errors.Is(err, context.Canceled) {
+		InfoLog.Printf("oura export backfill interrupted, run again to resume from '%s': %+v\n", *checkpointPath, report)
+		return 1
+	}
+	if err != nil {
+		ErrorLog.Printf("error backfilling oura export: %v", err)
+		return 1
+	}
+
+	InfoLog.Printf("backfilled oura export: %+v\n", report)
+	return 0
+}
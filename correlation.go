@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxCorrelationLag        = 30
+	MinCorrelationSampleSize = 3
+)
+
+var (
+	InsightsRgxCorrelations *regexp.Regexp
+)
+
+type InsightsHandler struct{}
+
+// Correlation compares field X on each day with field Y Lag days later.
+// Coefficients and p-values are null when either field does not vary.
+type Correlation struct {
+	X         string   `json:"x"`
+	Y         string   `json:"y"`
+	Lag       int      `json:"lag"`
+	N         int      `json:"n"`
+	Pearson   *float64 `json:"pearson"`
+	PearsonP  *float64 `json:"pearson_p"`
+	Spearman  *float64 `json:"spearman"`
+	SpearmanP *float64 `json:"spearman_p"`
+}
+
+type Correlations struct {
+	Start string        `json:"start"`
+	End   string        `json:"end"`
+	Lag   int           `json:"lag"`
+	Data  []Correlation `json:"data"`
+}
+
+func init() {
+	InsightsRgxCorrelations = regexp.MustCompile(`^/insights/correlations$`)
+}
+
+func (h *InsightsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && InsightsRgxCorrelations.MatchString(r.URL.Path):
+		h.getCorrelations(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get correlations between metric fields
+// @Security ApiKeyAuth
+// @Description Computes Pearson and Spearman correlations, with two sided p-values, between pairs of
+// @Description numeric fields across all metrics. Fields are named metric.field, e.g. sleep.deep_sleep.
+// @Description Each pair compares x on a day between start and end (inclusive) with y lag days later, so
+// @Description lag=1 pairs a night's sleep with the next day's ready score. With lag=0 each pair is
+// @Description listed once, otherwise both directions are listed. Pairs with fewer than 3 days in
+// @Description common are left out. Results are ordered by the strength of the Pearson correlation.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags insights
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param lag query int false "Days between x and y" default(0)
+// @Param fields query string false "Comma separated metric.field names to limit the pairs to"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Correlations
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /insights/correlations [get]
+func (h *InsightsHandler) getCorrelations(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(values.Get("start"), values.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	lag := 0
+	if values.Has("lag") {
+		lag, err = strconv.Atoi(values.Get("lag"))
+		if err != nil || lag < 0 || lag > MaxCorrelationLag {
+			ErrorLog.Printf("invalid correlation lag '%s'", values.Get("lag"))
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid lag, expected a whole number from 0 to %d", MaxCorrelationLag))
+			return
+		}
+	}
+
+	var fieldNames []string
+	for _, metric := range Metrics {
+		for _, field := range metric.Fields {
+			fieldNames = append(fieldNames, metric.Name+"."+field)
+		}
+	}
+
+	selected := fieldNames
+	if values.Has("fields") {
+		selected = nil
+		for _, name := range strings.Split(values.Get("fields"), ",") {
+			name = strings.TrimSpace(name)
+			if !slices.Contains(fieldNames, name) {
+				ErrorLog.Printf("invalid correlation field '%s'", name)
+				handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid field '%s', expected one of %s", name, strings.Join(fieldNames, ", ")))
+				return
+			}
+			if !slices.Contains(selected, name) {
+				selected = append(selected, name)
+			}
+		}
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s' lag '%d'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), lag)
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, lag),
+	}
+
+	daily := map[string]map[time.Time]float64{}
+	for _, metric := range Metrics {
+		metricValues, err := metric.Values(DatabaseContext, params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving %s values between '%s' and '%s': %v", metric.Name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+
+		for field, fieldValues := range metricValues {
+			byDate := map[time.Time]float64{}
+			for _, dailyValue := range fieldValues {
+				byDate[dailyValue.Date] = dailyValue.Value
+			}
+			daily[metric.Name+"."+field] = byDate
+		}
+	}
+
+	results := []Correlation{}
+	for i, x := range selected {
+		for j, y := range selected {
+			if i == j || (lag == 0 && j < i) {
+				continue
+			}
+
+			var xs, ys []float64
+			for date, xValue := range daily[x] {
+				if date.After(endDate) {
+					continue
+				}
+				if yValue, found := daily[y][date.AddDate(0, 0, lag)]; found {
+					xs = append(xs, xValue)
+					ys = append(ys, yValue)
+				}
+			}
+
+			if len(xs) < MinCorrelationSampleSize {
+				continue
+			}
+
+			correlation := Correlation{X: x, Y: y, Lag: lag, N: len(xs)}
+			if coefficient, ok := pearson(xs, ys); ok {
+				p := correlationPValue(coefficient, len(xs))
+				correlation.Pearson = &coefficient
+				correlation.PearsonP = &p
+			}
+			if coefficient, ok := pearson(ranks(xs), ranks(ys)); ok {
+				p := correlationPValue(coefficient, len(xs))
+				correlation.Spearman = &coefficient
+				correlation.SpearmanP = &p
+			}
+
+			results = append(results, correlation)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return correlationStrength(results[i]) > correlationStrength(results[j])
+	})
+
+	correlations := Correlations{
+		Start: startDate.Format("2006-01-02"),
+		End:   endDate.Format("2006-01-02"),
+		Lag:   lag,
+		Data:  results,
+	}
+
+	jsonBytes, err := json.Marshal(correlations)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+func correlationStrength(correlation Correlation) float64 {
+	if correlation.Pearson == nil {
+		return -1
+	}
+	return math.Abs(*correlation.Pearson)
+}
+
+// pearson returns the Pearson correlation coefficient of xs and ys, or false
+// when either has no variance.
+func pearson(xs []float64, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, varianceX, varianceY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+
+	if varianceX == 0 || varianceY == 0 {
+		return 0, false
+	}
+
+	r := covariance / math.Sqrt(varianceX*varianceY)
+	return math.Max(-1, math.Min(1, r)), true
+}
+
+// ranks replaces each value with its rank, giving tied values the average
+// of the ranks they span.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return values[order[i]] < values[order[j]]
+	})
+
+	ranked := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranked[order[k]] = rank
+		}
+		i = j + 1
+	}
+
+	return ranked
+}
+
+// correlationPValue returns the two sided p-value of a correlation
+// coefficient r over n samples using Student's t distribution with n-2
+// degrees of freedom.
+func correlationPValue(r float64, n int) float64 {
+	degrees := float64(n - 2)
+	if math.Abs(r) == 1 {
+		return 0
+	}
+
+	t := r * math.Sqrt(degrees/(1-r*r))
+	return regularizedIncompleteBeta(degrees/(degrees+t*t), degrees/2, 0.5)
+}
+
+// regularizedIncompleteBeta evaluates I_x(a, b) with the continued fraction
+// from Numerical Recipes.
+func regularizedIncompleteBeta(x float64, a float64, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgammaAB, _ := math.Lgamma(a + b)
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x float64, a float64, b float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		numerator := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		result *= d * c
+
+		numerator = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		result *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+
+	return result
+}
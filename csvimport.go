@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CsvDateField         = "date"
+	DefaultCsvDateFormat = "YYYY-MM-DD"
+
+	CsvUnitSeconds = "seconds"
+	CsvUnitMinutes = "minutes"
+	CsvUnitHours   = "hours"
+)
+
+var (
+	CsvProfileNameRgx *regexp.Regexp
+
+	// CsvDurationFields are the fields given in seconds that a profile may
+	// read in other units
+	CsvDurationFields = map[string][]string{
+		SleepMetric.Name:  {"total_sleep", "deep_sleep", "light_sleep", "rem_sleep"},
+		StressMetric.Name: {"high_stress_duration"},
+	}
+
+	// CsvWholeFields are the fields saved as integers, which a cell must
+	// hold a whole number for once its unit is applied
+	CsvWholeFields = map[string][]string{
+		SleepMetric.Name:      {"rating", "total_sleep", "deep_sleep", "light_sleep", "rem_sleep"},
+		ReadyScoreMetric.Name: {"score"},
+		HeartRateMetric.Name:  {"low", "high", "average"},
+		StressMetric.Name:     {"high_stress_duration"},
+	}
+
+	CsvUnitFactors = map[string]float64{
+		CsvUnitSeconds: 1,
+		CsvUnitMinutes: 60,
+		CsvUnitHours:   60 * 60,
+	}
+
+	// csvDateTokens turns the date format tokens profiles use into Go's
+	// reference time. Formats already written as Go layouts pass through.
+	csvDateTokens = strings.NewReplacer(
+		"YYYY", "2006",
+		"YY", "06",
+		"MM", "01",
+		"DD", "02",
+		"HH", "15",
+		"mm", "04",
+		"ss", "05",
+	)
+
+	// CsvTargets validate one row, already shaped like the metric's write
+	// input, and return a function that saves it
+	CsvTargets = map[string]func(data []byte) (csvSave, error){
+		SleepMetric.Name: func(data []byte) (csvSave, error) {
+			var input SleepInput
+			err := decodeJSON(bytes.NewReader(data), &input)
+			if err != nil {
+				return nil, err
+			}
+			params, err := input.params()
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, queries *Queries) (bool, error) {
+				_, inserted, err := queries.UpsertSleep(ctx, params)
+				return inserted, err
+			}, nil
+		},
+		ReadyScoreMetric.Name: func(data []byte) (csvSave, error) {
+			var input ReadyScoreInput
+			err := decodeJSON(bytes.NewReader(data), &input)
+			if err != nil {
+				return nil, err
+			}
+			params, err := input.params()
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, queries *Queries) (bool, error) {
+				_, inserted, err := queries.UpsertReadyScore(ctx, params)
+				return inserted, err
+			}, nil
+		},
+		HeartRateMetric.Name: func(data []byte) (csvSave, error) {
+			var input HeartRateInput
+			err := decodeJSON(bytes.NewReader(data), &input)
+			if err != nil {
+				return nil, err
+			}
+			params, err := input.params()
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, queries *Queries) (bool, error) {
+				_, inserted, err := queries.UpsertHeartRate(ctx, params)
+				return inserted, err
+			}, nil
+		},
+		StressMetric.Name: func(data []byte) (csvSave, error) {
+			var input StressInput
+			err := decodeJSON(bytes.NewReader(data), &input)
+			if err != nil {
+				return nil, err
+			}
+			params, err := input.params()
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, queries *Queries) (bool, error) {
+				_, inserted, err := queries.UpsertStress(ctx, params)
+				return inserted, err
+			}, nil
+		},
+		Spo2Metric.Name: func(data []byte) (csvSave, error) {
+			var input Spo2Input
+			err := decodeJSON(bytes.NewReader(data), &input)
+			if err != nil {
+				return nil, err
+			}
+			params, err := input.params()
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, queries *Queries) (bool, error) {
+				_, inserted, err := queries.UpsertSpo2(ctx, params)
+				return inserted, err
+			}, nil
+		},
+	}
+)
+
+type csvSave func(ctx context.Context, queries *Queries) (bool, error)
+
+// csvCellError is a cell that cannot be read as its field
+type csvCellError struct {
+	column string
+	reason string
+}
+
+func (e csvCellError) Error() string {
+	return fmt.Sprintf("column \"%s\": %s", e.column, e.reason)
+}
+
+// CsvProfile says how to read a CSV into one metric. Columns maps each CSV
+// header to a field of the metric, and one column must map to date. Units
+// gives the unit of duration fields, seconds by default. Dates are read with
+// DateFormat, written with YYYY, YY, MM, DD, HH, mm and ss or as a Go
+// layout, in Timezone, UTC by default. Defaults fills fields no column gives.
+type CsvProfile struct {
+	Name       string             `json:"name"`
+	Metric     string             `json:"metric"`
+	Columns    map[string]string  `json:"columns"`
+	DateFormat string             `json:"date_format"`
+	Timezone   string             `json:"timezone"`
+	Units      map[string]string  `json:"units"`
+	Defaults   map[string]float64 `json:"defaults"`
+}
+
+type CsvRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// CsvImport reports a CSV import. Nothing is saved unless every row is valid,
+// in which case Errors is empty. Rows are counted from 1 after the header.
+type CsvImport struct {
+	Profile string        `json:"profile"`
+	Metric  string        `json:"metric"`
+	Rows    int           `json:"rows"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []CsvRowError `json:"errors"`
+}
+
+type CsvProfiles struct {
+	Data []CsvProfile `json:"data"`
+}
+
+func init() {
+	CsvProfileNameRgx = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+}
+
+// validate checks a profile and fills in its defaults.
+func (p *CsvProfile) validate() error {
+	if !CsvProfileNameRgx.MatchString(p.Name) {
+		return fmt.Errorf("'name' must be 1 to 64 letters, digits, '-' or '_'")
+	}
+
+	metric, found := metricByName(p.Metric)
+	if !found {
+		return fmt.Errorf("'metric' must be one of sleep, readyscore, heartrate, stress or spo2, got '%s'", p.Metric)
+	}
+
+	fields := map[string]bool{}
+	for column, field := range p.Columns {
+		if field != CsvDateField && !metric.HasField(field) {
+			return fmt.Errorf("column '%s' maps to '%s', which is not a %s field", column, field, metric.Name)
+		}
+		if fields[field] {
+			return fmt.Errorf("more than one column maps to '%s'", field)
+		}
+		fields[field] = true
+	}
+
+	if !fields[CsvDateField] {
+		return fmt.Errorf("one column must map to '%s'", CsvDateField)
+	}
+
+	for field, value := range p.Defaults {
+		if !metric.HasField(field) {
+			return fmt.Errorf("default given for '%s', which is not a %s field", field, metric.Name)
+		}
+		if slices.Contains(CsvWholeFields[metric.Name], field) && value != math.Trunc(value) {
+			return fmt.Errorf("default for '%s' must be a whole number, got %v", field, value)
+		}
+	}
+
+	for field, unit := range p.Units {
+		if !slices.Contains(CsvDurationFields[metric.Name], field) {
+			return fmt.Errorf("unit given for '%s', which is not a %s duration", field, metric.Name)
+		}
+		if _, found := CsvUnitFactors[unit]; !found {
+			return fmt.Errorf("unit for '%s' must be seconds, minutes or hours, got '%s'", field, unit)
+		}
+	}
+
+	if p.DateFormat == "" {
+		p.DateFormat = DefaultCsvDateFormat
+	}
+
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+	_, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fmt.Errorf("unknown timezone '%s'", p.Timezone)
+	}
+
+	return nil
+}
+
+// column is the CSV column a field is read from.
+func (p CsvProfile) column(field string) string {
+	for column, mapped := range p.Columns {
+		if mapped == field {
+			return column
+		}
+	}
+	return field
+}
+
+func metricByName(name string) (Metric, bool) {
+	for _, metric := range Metrics {
+		if metric.Name == name {
+			return metric, true
+		}
+	}
+	return Metric{}, false
+}
+
+// ImportCsv validates every row of a CSV against profile and, only if all of
+// them are valid, upserts them in one transaction.
+func ImportCsv(ctx context.Context, reader io.Reader, profile CsvProfile) (CsvImport, error) {
+	csvImport := CsvImport{
+		Profile: profile.Name,
+		Metric:  profile.Metric,
+		Errors:  []CsvRowError{},
+	}
+
+	location, _ := time.LoadLocation(profile.Timezone)
+	layout := csvDateTokens.Replace(profile.DateFormat)
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return csvImport, fmt.Errorf("%w: error reading csv header: %v", ErrImportFormat, err)
+	}
+
+	// columns maps each mapped field to its index in the CSV
+	columns := map[string]int{}
+	for i, column := range header {
+		if field, found := profile.Columns[strings.TrimSpace(column)]; found {
+			columns[field] = i
+		}
+	}
+	for column, field := range profile.Columns {
+		if _, found := columns[field]; !found {
+			return csvImport, fmt.Errorf("%w: csv has no '%s' column", ErrImportFormat, column)
+		}
+	}
+
+	var saves []csvSave
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		csvImport.Rows++
+		if err != nil {
+			return csvImport, fmt.Errorf("%w: error reading csv row %d: %v", ErrImportFormat, csvImport.Rows, err)
+		}
+
+		save, err := profile.row(record, columns, layout, location)
+		if err != nil {
+			message := fmt.Sprintf("row %d: %v", csvImport.Rows, err)
+			if errors.As(err, &csvCellError{}) {
+				message = fmt.Sprintf("row %d, %v", csvImport.Rows, err)
+			}
+			csvImport.Errors = append(csvImport.Errors, CsvRowError{Row: csvImport.Rows, Error: message})
+			continue
+		}
+		saves = append(saves, save)
+	}
+
+	if len(csvImport.Errors) > 0 {
+		return csvImport, nil
+	}
+
+	tx, err := DatabaseConnection.Begin(ctx)
+	if err != nil {
+		return csvImport, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := NewQueries(tx)
+	for i, save := range saves {
+		inserted, err := save(ctx, queries)
+		if err != nil {
+			return csvImport, fmt.Errorf("error saving csv row %d: %w", i+1, err)
+		}
+
+		if inserted {
+			csvImport.Created++
+		} else {
+			csvImport.Updated++
+		}
+	}
+
+	return csvImport, tx.Commit(ctx)
+}
+
+// row turns one record into the metric's write input and validates it the
+// same way the write API does.
+func (p CsvProfile) row(record []string, columns map[string]int, layout string, location *time.Location) (csvSave, error) {
+	input := map[string]any{}
+	for field, value := range p.Defaults {
+		input[field] = value
+	}
+
+	for field, index := range columns {
+		if index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			continue
+		}
+		value := strings.TrimSpace(record[index])
+		column := p.column(field)
+
+		if field == CsvDateField {
+			timestamp, err := time.ParseInLocation(layout, value, location)
+			if err != nil {
+				return nil, csvCellError{column: column, reason: fmt.Sprintf("'%s' does not match date format '%s'", value, p.DateFormat)}
+			}
+			input[field] = timestamp.In(location).Format("2006-01-02")
+			continue
+		}
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, csvCellError{column: column, reason: "not a number"}
+		}
+
+		if slices.Contains(CsvDurationFields[p.Metric], field) {
+			unit := p.Units[field]
+			if unit == "" {
+				unit = CsvUnitSeconds
+			}
+			number = math.Round(number * CsvUnitFactors[unit])
+		}
+
+		if slices.Contains(CsvWholeFields[p.Metric], field) {
+			if number != math.Trunc(number) {
+				return nil, csvCellError{column: column, reason: "not a whole number"}
+			}
+			if math.Abs(number) > math.MaxInt32 {
+				return nil, csvCellError{column: column, reason: "out of range"}
+			}
+		}
+
+		input[field] = number
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return CsvTargets[p.Metric](data)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	DayRgx   *regexp.Regexp
+	WeekRgx  *regexp.Regexp
+	MonthRgx *regexp.Regexp
+
+	// EarliestDate and LatestDate bound requests that give no dates
+	EarliestDate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	LatestDate   = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func init() {
+	DayRgx = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	WeekRgx = regexp.MustCompile(`^([0-9]{4})-W([0-9]{2})$`)
+	MonthRgx = regexp.MustCompile(`^([0-9]{4})-([0-9]{2})$`)
+}
+
+// parseDateRange converts the start and end values of a range request into
+// an inclusive pair of dates. Each value may be a day (2024-02-19), an ISO
+// week (2024-W08) or a month (2024-02). A start resolves to the first day
+// of its period and an end to the last day of its period. When end is empty
+// the range covers just the period given by start.
+func parseDateRange(start string, end string) (time.Time, time.Time, error) {
+	if start == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start is required")
+	}
+
+	startDate, endDate, err := parsePeriod(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if end != "" {
+		_, endDate, err = parsePeriod(end)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end '%s' is before start '%s'", end, start)
+	}
+
+	return startDate, endDate, nil
+}
+
+// parsePeriod returns the first and last day covered by a day, ISO week or
+// month value.
+func parsePeriod(value string) (time.Time, time.Time, error) {
+	switch {
+	case DayRgx.MatchString(value):
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date '%s'", value)
+		}
+		return day, day, nil
+
+	case WeekRgx.MatchString(value):
+		matches := WeekRgx.FindStringSubmatch(value)
+		year, _ := strconv.Atoi(matches[1])
+		week, _ := strconv.Atoi(matches[2])
+
+		// January 4th always falls in ISO week 1, so walk back to the Monday
+		// of that week and then forward to the requested week.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		weekOneMonday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		monday := weekOneMonday.AddDate(0, 0, (week-1)*7)
+
+		isoYear, isoWeek := monday.ISOWeek()
+		if week < 1 || isoYear != year || isoWeek != week {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid ISO week '%s'", value)
+		}
+		return monday, monday.AddDate(0, 0, 6), nil
+
+	case MonthRgx.MatchString(value):
+		matches := MonthRgx.FindStringSubmatch(value)
+		year, _ := strconv.Atoi(matches[1])
+		month, _ := strconv.Atoi(matches[2])
+
+		if month < 1 || month > 12 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid month '%s'", value)
+		}
+
+		first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 1, -1), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("'%s' is not a date (YYYY-MM-DD), ISO week (YYYY-Www) or month (YYYY-MM)", value)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	DayRgxDate *regexp.Regexp
+)
+
+type DayHandler struct{}
+
+// Day combines every metric recorded for a single date. A metric with no
+// row for the date is returned as null rather than failing the request.
+type Day struct {
+	Date       string                   `json:"date"`
+	Sleep      *austinapi_db.Sleep      `json:"sleep"`
+	ReadyScore *austinapi_db.Readyscore `json:"readyscore"`
+	HeartRate  *austinapi_db.Heartrate  `json:"heartrate"`
+	Stress     *austinapi_db.Stress     `json:"stress"`
+	Spo2       *austinapi_db.Spo2       `json:"spo2"`
+}
+
+func init() {
+	DayRgxDate = regexp.MustCompile(`^/day/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+}
+
+func (h *DayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && DayRgxDate.MatchString(r.URL.String()):
+		h.getDay(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get all information for a date
+// @Security ApiKeyAuth
+// @Description Retrieves sleep, ready score, heart rate, stress and spo2 information for the specified date
+// @Description in a single response. Any metric not recorded for the date is returned as null.
+// @Tags day
+// @Accept json
+// @Produce json
+// @Param date path string true "Date"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Day
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /day/{date} [get]
+func (h *DayHandler) getDay(w http.ResponseWriter, r *http.Request) {
+	dateMatches := DayRgxDate.FindStringSubmatch(r.URL.String())
+
+	if len(dateMatches) < 2 {
+		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, DayRgxDate.String())
+		handleError(w, http.StatusInternalServerError, "Issue parsing specified date")
+		return
+	}
+
+	InfoLog.Printf("URL token match '%s'\n", dateMatches[1])
+
+	dateString := dateMatches[1]
+	date, err := time.Parse("2006-01-02", dateString)
+	if err != nil {
+		ErrorLog.Printf("Unable to parse '%s' to time.Time object: %v", dateString, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	day, err := loadDay(date)
+	if err != nil {
+		ErrorLog.Printf("error retrieving day with date '%s': %v", dateString, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	jsonBytes, err := json.Marshal(day)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v\n", err)
+	}
+}
+
+// loadDay fetches every metric recorded on date at once. A metric with no
+// row for the date is left nil.
+func loadDay(date time.Time) (Day, error) {
+	day := Day{Date: date.Format("2006-01-02")}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+
+	wg.Add(5)
+	go func() {
+		defer wg.Done()
+		result, err := ApiDatabase.GetSleepByDate(DatabaseContext, date)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if len(result) == 1 {
+			day.Sleep = &result[0]
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		result, err := ApiDatabase.GetReadyScoreByDate(DatabaseContext, date)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if len(result) == 1 {
+			day.ReadyScore = &result[0]
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		result, err := ApiDatabase.GetHeartRateByDate(DatabaseContext, date)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if len(result) == 1 {
+			day.HeartRate = &result[0]
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		result, err := ApiDatabase.GetStressByDate(DatabaseContext, date)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if len(result) == 1 {
+			day.Stress = &result[0]
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		result, err := ApiDatabase.GetSpo2ByDate(DatabaseContext, date)
+		if err != nil {
+			errs <- err
+			return
+		}
+		if len(result) == 1 {
+			day.Spo2 = &result[0]
+		}
+	}()
+	wg.Wait()
+	close(errs)
+
+	var failures []error
+	for err := range errs {
+		failures = append(failures, err)
+	}
+
+	return day, errors.Join(failures...)
+}
@@ -15,32 +15,59 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/heartrate/date/{date}": {
+        "/anomalies": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves heart rate information with specified date",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieves days between start and end (inclusive) where a numeric field of any metric\ndeviates from a personal baseline built from the preceding baseline_days days.\nThe zscore method compares against the baseline mean and standard deviation, the mad\nmethod against the median and median absolute deviation. A field needs at least\n7 baseline days before it is checked. Results are ordered by date, metric and field.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "heartrate"
+                    "anomalies"
                 ],
-                "summary": "Get heart rate information by date",
+                "summary": "Get anomalies across all metrics",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Date",
-                        "name": "date",
-                        "in": "path",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "zscore",
+                            "mad"
+                        ],
+                        "type": "string",
+                        "default": "mad",
+                        "description": "Scoring method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Score at or beyond which a day is flagged, 3 for zscore and 3.5 for mad by default",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Number of days before each day that make up its baseline",
+                        "name": "baseline_days",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
@@ -53,18 +80,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Heartrate"
+                            "$ref": "#/definitions/main.Anomalies"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -74,45 +101,83 @@ const docTemplate = `{
                 }
             }
         },
-        "/heartrate/id/{id}": {
+        "/badge/{metric}.svg": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves heart rate information with specified ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Renders a shields.io style SVG badge of the most recent value of a metric, such as\n\"sleep | 7h 32m\" or \"readiness | 84\". sleep shows total sleep, readyscore the score,\nheartrate the average, stress the time in high stress and spo2 the average. The value is\ngreen, yellow or red against fixed thresholds, or blue for heart rate. Badges can be\nembedded without an Authorization header using a URL signed by /sign.",
                 "produces": [
-                    "application/json"
+                    "image/svg+xml"
                 ],
                 "tags": [
-                    "heartrate"
+                    "images"
                 ],
-                "summary": "Get heart rate information by ID",
+                "summary": "Get a status badge of a metric's latest value",
                 "parameters": [
                     {
+                        "enum": [
+                            "sleep",
+                            "readyscore",
+                            "heartrate",
+                            "stress",
+                            "spo2"
+                        ],
                         "type": "string",
-                        "description": "Heart Rate ID",
-                        "name": "id",
+                        "description": "Metric",
+                        "name": "metric",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Label, overriding the metric's",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flat",
+                            "flat-square"
+                        ],
+                        "type": "string",
+                        "default": "flat",
+                        "description": "Badge style",
+                        "name": "style",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Heartrate"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
                     "401": {
@@ -133,42 +198,65 @@ const docTemplate = `{
                 }
             }
         },
-        "/heartrate/list": {
+        "/calendar.ics": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves list of heart rate information in descending order by date\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items.",
+                "description": "Returns an iCalendar (RFC 5545) feed to subscribe to from a calendar app. Each night's sleep\nis an event ending at CALENDAR_WAKE_TIME, floating in the calendar's time zone, as long as\nthe total sleep, with total, deep, light and REM durations and the rating in its description.\nReadiness scores and high stress time are all day events. start and end accept a date\n(2024-02-19), an ISO week (2024-W08) or a month (2024-02) and default to the last 90 days.\nCalendar apps that cannot send a bearer token may pass FEED_TOKEN as the token parameter.",
                 "produces": [
-                    "application/json"
+                    "text/calendar"
                 ],
                 "tags": [
-                    "heartrate"
+                    "feeds"
                 ],
-                "summary": "Get list of heart rate information",
+                "summary": "Get an iCalendar feed of sleep and daily scores",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "string",
-                        "description": "next list search by next_token",
-                        "name": "next_token",
+                        "default": "sleep,readyscore,stress",
+                        "description": "Comma separated metrics",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Feed token, instead of the Authorization header",
+                        "name": "token",
                         "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "Bearer Token",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.HeartRates"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
                     "401": {
@@ -183,14 +271,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/readyscore/date/{date}": {
+        "/day/{date}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves ready score information with specified date",
+                "description": "Retrieves sleep, ready score, heart rate, stress and spo2 information for the specified date\nin a single response. Any metric not recorded for the date is returned as null.",
                 "consumes": [
                     "application/json"
                 ],
@@ -198,9 +286,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "readyscore"
+                    "day"
                 ],
-                "summary": "Get ready score information by date",
+                "summary": "Get all information for a date",
                 "parameters": [
                     {
                         "type": "string",
@@ -221,18 +309,12 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                            "$ref": "#/definitions/main.Day"
                         }
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -242,31 +324,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/readyscore/id/{id}": {
+        "/export/parquet": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves ready score information with specified ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Exports every row of the chosen metrics between start and end (inclusive) as Apache Parquet,\nwith a typed column for each field: date as DATE, timestamps as TIMESTAMP_MICROS in UTC,\nwhole numbers as INT64 and spo2 as DOUBLE. One metric is returned as a .parquet file and\nseveral as a zip holding one .parquet file per metric. metrics defaults to all of them.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02);\nleaving both out exports everything.",
                 "produces": [
-                    "application/json"
+                    "application/vnd.apache.parquet",
+                    "application/zip"
                 ],
                 "tags": [
-                    "readyscore"
+                    "export"
                 ],
-                "summary": "Get ready score information by ID",
+                "summary": "Export metrics as Parquet",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Ready Score ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": "sleep,readyscore,heartrate,stress,spo2",
+                        "description": "Comma separated metrics",
+                        "name": "metrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -280,18 +372,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                            "type": "file"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -301,42 +393,73 @@ const docTemplate = `{
                 }
             }
         },
-        "/readyscore/list": {
+        "/feed.atom": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves list of ready score information in descending order by date\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items.",
+                "description": "Returns an Atom feed with one entry per day, newest first, summarising that day's sleep,\nreadiness, heart rate, stress and spo2. Entry ids are stable for a date and updated is the\nlatest updated_timestamp of the day's records. start and end accept a date (2024-02-19),\nan ISO week (2024-W08) or a month (2024-02) and default to the last 30 days. The response\ncarries an ETag and Last-Modified, and If-None-Match or If-Modified-Since give 304 when the\nfeed has not changed. Last-Modified is the latest updated_timestamp, or for the default\nwindow midnight UTC today if that is later, since a day leaves the window then. Deleting a\nday leaves no timestamp behind, so prefer If-None-Match. Feed readers that cannot send a\nbearer token may pass FEED_TOKEN as the token parameter.",
                 "produces": [
-                    "application/json"
+                    "application/atom+xml"
                 ],
                 "tags": [
-                    "readyscore"
+                    "feeds"
                 ],
-                "summary": "Get list of ready score information",
+                "summary": "Get an Atom feed of daily digests",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "string",
-                        "description": "next list search by next_token",
-                        "name": "next_token",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Feed token, instead of the Authorization header",
+                        "name": "token",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a previous response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.ReadyScores"
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
                     "401": {
@@ -351,31 +474,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/sleep/date/{date}": {
+        "/fhir/Observation": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves sleep information with specified date",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns a FHIR R4 searchset Bundle of daily Observations ordered by date and then code.\nObservations are published for heart rate (LOINC 8867-4, the daily average), SpO2\n(59408-5), sleep duration (93832-4) and deep (93831-6), light (93830-8) and REM\n(93829-0) sleep duration in minutes. code takes comma separated codes, optionally as\nhttp://loinc.org|code. date may be repeated and takes a day, ISO week or month with\nan optional eq, ge, le, gt or lt prefix. _count sets the page size, at most the server\nlist limit, and the next link pages on with _offset. Errors are OperationOutcomes.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sleep"
+                    "fhir"
                 ],
-                "summary": "Get sleep information by date",
+                "summary": "Search FHIR Observations",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Date",
+                        "description": "Comma separated LOINC codes",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Date with an optional eq, ge, le, gt or lt prefix",
                         "name": "date",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of Observations per page",
+                        "name": "_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of Observations to skip",
+                        "name": "_offset",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -389,49 +530,46 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Sleep"
+                            "$ref": "#/definitions/main.FhirBundle"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
+                            "$ref": "#/definitions/main.FhirOperationOutcome"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
+                            "$ref": "#/definitions/main.FhirOperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/sleep/id/{id}": {
+        "/fhir/Observation/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves sleep information with specified ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieves one daily Observation. Ids are the Observation's kind and date, such as\nheart-rate-2024-02-19 or sleep-duration-2024-02-19, as given in search results.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sleep"
+                    "fhir"
                 ],
-                "summary": "Get sleep information by ID",
+                "summary": "Get a FHIR Observation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Sleep ID",
+                        "description": "Observation id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -448,7 +586,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Sleep"
+                            "$ref": "#/definitions/main.FhirObservation"
                         }
                     },
                     "401": {
@@ -457,41 +595,34 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
+                            "$ref": "#/definitions/main.FhirOperationOutcome"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
+                            "$ref": "#/definitions/main.FhirOperationOutcome"
                         }
                     }
                 }
             }
         },
-        "/sleep/list": {
+        "/fhir/metadata": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves list of sleep information in descending order by date\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items.",
+                "description": "Describes the FHIR R4 interactions and search parameters this server supports",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sleep"
+                    "fhir"
                 ],
-                "summary": "Get list of sleep information",
+                "summary": "Get the FHIR capability statement",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "format": "string",
-                        "description": "next list search by next_token",
-                        "name": "next_token",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
@@ -504,29 +635,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Sleeps"
+                            "$ref": "#/definitions/main.FhirCapabilityStatement"
                         }
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/main.GenericMessage"
-                        }
                     }
                 }
             }
         },
-        "/spo2/date/{date}": {
-            "get": {
+        "/heartrate": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves spo2 information with specified date",
+                "description": "Saves heart rate information for a date. If heart rate already exists for the date it is\nupdated and 200 is returned, otherwise it is created and 201 is returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -534,16 +659,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "spo2"
+                    "heartrate"
                 ],
-                "summary": "Get spo2 information by date",
+                "summary": "Save heart rate information",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Date",
-                        "name": "date",
-                        "in": "path",
-                        "required": true
+                        "description": "Heart Rate",
+                        "name": "heartrate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeartRateInput"
+                        }
                     },
                     {
                         "type": "string",
@@ -557,18 +684,24 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Spo2"
+                            "$ref": "#/definitions/austinapi_db.Heartrate"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Heartrate"
+                        }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -578,37 +711,194 @@ const docTemplate = `{
                 }
             }
         },
-        "/spo2/id/{id}": {
+        "/heartrate/chart.svg": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves Spo2 information with specified ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Draws one or more numeric heart rate fields by day between start and end (inclusive) as a\nstandalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars\nor bars stacked in the order the fields are given, and band=true shades a line chart between\nits first and last fields. For example field=low,average,high\u0026band=true draws the daily average inside a band from low to high.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and\ndefault to the last 30 days. Days with no value are left as gaps. Charts can be embedded\nwithout an Authorization header using a URL signed by /sign.",
                 "produces": [
-                    "application/json"
+                    "image/svg+xml"
                 ],
                 "tags": [
-                    "spo2"
+                    "heartrate"
                 ],
-                "summary": "Get Spo2 information by ID",
+                "summary": "Get a chart of heart rate as SVG",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Spo2 ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Comma separated numeric fields to draw",
+                        "name": "field",
+                        "in": "query",
                         "required": true
                     },
                     {
+                        "enum": [
+                            "line",
+                            "bar",
+                            "stacked"
+                        ],
                         "type": "string",
-                        "description": "Bearer Token",
-                        "name": "Authorization",
-                        "in": "header",
+                        "default": "line",
+                        "description": "Chart type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shade a line chart between its first and last fields",
+                        "name": "band",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 640,
+                        "description": "Width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 320,
+                        "description": "Height in pixels",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated hex series colors, overriding the theme",
+                        "name": "colors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex background color, or none for transparent",
+                        "name": "background",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, or empty for none",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/heartrate/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves heart rate information with specified date\nalong with any anomalies found against the recent personal baseline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Get heart rate information by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     }
                 ],
@@ -616,7 +906,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Spo2"
+                            "$ref": "#/definitions/main.AnnotatedHeartRate"
                         }
                     },
                     "401": {
@@ -628,6 +918,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -637,42 +933,76 @@ const docTemplate = `{
                 }
             }
         },
-        "/spo2/list": {
+        "/heartrate/heatmap.svg": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves list of spo2 information in descending order by date\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items.",
+                "description": "Draws a year of one numeric heart rate field as a grid of days, one column per week starting\non Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls\nbetween the lowest and highest of the year, and hovering a day shows its value. field\ndefaults to average and year to the current year. Heatmaps can be embedded without an\nAuthorization header using a URL signed by /sign.",
                 "produces": [
-                    "application/json"
+                    "image/svg+xml"
                 ],
                 "tags": [
-                    "spo2"
+                    "heartrate"
                 ],
-                "summary": "Get list of spo2 information",
+                "summary": "Get a year heatmap of heart rate as SVG",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "format": "string",
-                        "description": "next list search by next_token",
-                        "name": "next_token",
+                        "default": "average",
+                        "description": "Numeric field to shade by",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
                         "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "Bearer Token",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Spo2s"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
                     "401": {
@@ -687,32 +1017,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/stress/date/{date}": {
+        "/heartrate/id/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves stress information with specified date",
+                "description": "Retrieves heart rate information with specified ID",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
                 ],
                 "tags": [
-                    "stress"
+                    "heartrate"
                 ],
-                "summary": "Get stress information by date",
+                "summary": "Get heart rate information by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Date",
-                        "name": "date",
+                        "description": "Heart Rate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
@@ -725,7 +1072,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Stress"
+                            "$ref": "#/definitions/austinapi_db.Heartrate"
                         }
                     },
                     "401": {
@@ -737,6 +1084,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -744,16 +1097,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/stress/id/{id}": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves stress information with specified ID",
+                "description": "Replaces all fields of the heart rate information with specified ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -761,17 +1112,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "stress"
+                    "heartrate"
                 ],
-                "summary": "Get stress information by ID",
+                "summary": "Replace heart rate information by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Stress ID",
+                        "description": "Heart Rate ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Heart Rate",
+                        "name": "heartrate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeartRateInput"
+                        }
+                    },
                     {
                         "type": "string",
                         "description": "Bearer Token",
@@ -784,7 +1144,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/austinapi_db.Stress"
+                            "$ref": "#/definitions/austinapi_db.Heartrate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
                     "401": {
@@ -796,6 +1162,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.GenericMessage"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -803,30 +1175,25 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/stress/list": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves list of stress information in descending order by date\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items.",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Deletes the heart rate information with specified ID",
                 "tags": [
-                    "stress"
+                    "heartrate"
                 ],
-                "summary": "Get list of stress information",
+                "summary": "Delete heart rate information by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "string",
-                        "description": "next list search by next_token",
-                        "name": "next_token",
-                        "in": "query"
+                        "description": "Heart Rate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
@@ -837,15 +1204,18 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/main.Stresses"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -853,192 +1223,6629 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "austinapi_db.Heartrate": {
-            "type": "object",
-            "properties": {
-                "average": {
-                    "type": "integer"
-                },
-                "created_timestamp": {
-                    "type": "string"
-                },
-                "date": {
-                    "type": "string"
-                },
-                "high": {
-                    "type": "integer"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "low": {
-                    "type": "integer"
-                },
-                "updated_timestamp": {
-                    "type": "string"
-                }
-            }
-        },
-        "austinapi_db.Readyscore": {
-            "type": "object",
-            "properties": {
-                "created_timestamp": {
-                    "type": "string"
-                },
-                "date": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "score": {
-                    "type": "integer"
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates only the fields given for the heart rate information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Update heart rate information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Heart Rate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Heart Rate fields to change",
+                        "name": "heartrate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeartRateInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Heartrate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/heartrate/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves list of heart rate information ordered by date, newest first unless order=asc\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items, or a prev_token to go back.\nTokens are opaque and remain stable while new items are added.\nNext, previous and first page URLs are also returned in the Link header.\nPaging past the end of the list returns an empty data array.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Get list of heart rate information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "next list search by next_token",
+                        "name": "next_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "previous list search by prev_token",
+                        "name": "prev_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items per page, at most the server list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "order by date",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of fields to include in each item",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HeartRates"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/heartrate/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves heart rate information between start and end (inclusive) in ascending order by date\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nA week or month start begins on the first day of that period and a week or month\nend finishes on the last day of that period. If end is omitted the range covers\nonly the period given by start. CSV and NDJSON are streamed a row at a time as they are read.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Get heart rate information for a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HeartRateRange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/heartrate/series": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the daily value of one numeric heart rate field between start and end (inclusive)\nalongside a rolling mean for each window of days and an exponentially weighted moving\naverage (EWMA). Rolling means average the days recorded within each window.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Get smoothed heart rate time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Numeric field to return",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7,30",
+                        "description": "Comma separated rolling mean windows in days",
+                        "name": "windows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "EWMA smoothing factor greater than 0 and at most 1",
+                        "name": "alpha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/heartrate/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves count, mean, median, min, max and standard deviation of each numeric\nheart rate field between start and end (inclusive), grouped by day, week or month.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nEach bucket is identified by its first day. stddev is null for a bucket with one value.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "heartrate"
+                ],
+                "summary": "Get heart rate statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period to group by",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/apple-health": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams the export.xml from an Apple Health export, found inside export.zip, and upserts\na daily heart rate, spo2 and sleep row for every day it covers. Heart rate samples are rolled\nup into a low, high and average. Sleep stages are added up for the day the sleep ended on,\nusing the source that recorded the most sleep that day. Apple Health has no sleep score so\na day that already has a sleep row keeps its rating, and a new one is saved with 0. The body\nis read as a stream so exports of several GB are fine.",
+                "consumes": [
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import an Apple Health export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only import days on or after this date",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/csv": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Imports a CSV into one metric using a mapping profile, either saved and named by the profile\nquery parameter or given as JSON in the mapping form field. Every row is checked with the\nsame rules as the write API before anything is saved. If any row is invalid nothing is saved\nand 400 is returned with every row's error, such as 'row 3, column \"Score\": not a number',\notherwise all rows are upserted by date in one transaction.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import a CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV with a header row",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mapping profile as JSON, when not using a saved profile",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of a saved mapping profile",
+                        "name": "profile",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvImport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/csv/profiles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves every saved CSV mapping profile ordered by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "List saved CSV mapping profiles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvProfiles"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/csv/profiles/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the CSV mapping profile saved with the specified name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Get a saved CSV mapping profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Profile name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Saves a CSV mapping profile under the specified name, replacing any profile already saved\nwith it. columns maps CSV headers to metric fields and one must map to date. date_format\nuses YYYY, YY, MM, DD, HH, mm and ss, or a Go layout, and defaults to YYYY-MM-DD. Dates are\nread in timezone, UTC by default. units sets seconds, minutes or hours for total_sleep,\ndeep_sleep, light_sleep, rem_sleep and high_stress_duration. defaults fills in fields no\ncolumn gives, such as a sleep rating. Returns 201 for a new profile and 200 for a replaced one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Save a CSV mapping profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Profile name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Profile",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvProfile"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvProfile"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.CsvProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/fit": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Decodes uploaded Garmin .fit activity, monitoring and sleep files and upserts the daily heart\nrate, stress, spo2 and sleep rows they cover. Heart rate comes from record and monitoring\nmessages, stress from stress_level, spo2 from spo2 readings and sleep from sleep_level\nstages. High stress is the time spent at a stress level of 76 or more. Sleep goes to the day\nit ended. FIT has no sleep score, so a day that already has a sleep row keeps its rating and a\nnew one is saved with 0. Samples from all files in the request are combined before saving, so\nupload all of a day's monitoring files together. Each file gets a report of the rows it created, updated or was skipped for.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import Garmin FIT files",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "One or more .fit files",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.FitImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/takeout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reads a Google Takeout zip of Fitbit data and upserts the daily rows it holds, recording\nthe archive file each row came from. Heart rate comes from heart_rate-*.json, sleep from\nthe main sleep in sleep-*.json with its rating from sleep_score.csv, spo2 from the Daily SpO2\nfiles and ready score from the Daily Readiness Score files. estimated_oxygen_variation and\nStress Score.csv are listed as skipped since neither measures what spo2 and stress store.\nWith dry_run=true nothing is saved and the response shows the changes that would be made,\nwith each changed field's value before and after. Otherwise all rows are saved in one\ntransaction.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import a Google Takeout Fitbit archive",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Takeout zip",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Only report the changes",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.TakeoutImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/insights/correlations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Computes Pearson and Spearman correlations, with two sided p-values, between pairs of\nnumeric fields across all metrics. Fields are named metric.field, e.g. sleep.deep_sleep.\nEach pair compares x on a day between start and end (inclusive) with y lag days later, so\nlag=1 pairs a night's sleep with the next day's ready score. With lag=0 each pair is\nlisted once, otherwise both directions are listed. Pairs with fewer than 3 days in\ncommon are left out. Results are ordered by the strength of the Pearson correlation.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "insights"
+                ],
+                "summary": "Get correlations between metric fields",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Days between x and y",
+                        "name": "lag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated metric.field names to limit the pairs to",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Correlations"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/omh/{metric}/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a metric's values on the specified date as Open mHealth data points.\nheartrate gives omh:heart-rate with average, minimum and maximum descriptive statistics,\nspo2 gives omh:oxygen-saturation and sleep gives omh:sleep-duration in minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "omh"
+                ],
+                "summary": "Get Open mHealth data points by date",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heartrate",
+                            "spo2",
+                            "sleep"
+                        ],
+                        "type": "string",
+                        "description": "Metric",
+                        "name": "metric",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.OmhDataPoints"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/omh/{metric}/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a metric's values between start and end (inclusive) as Open mHealth data points\nordered by date. start and end accept a date (2024-02-19), an ISO week (2024-W08) or a\nmonth (2024-02). If end is omitted the range covers only the period given by start.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "omh"
+                ],
+                "summary": "Get Open mHealth data points for a date range",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heartrate",
+                            "spo2",
+                            "sleep"
+                        ],
+                        "type": "string",
+                        "description": "Metric",
+                        "name": "metric",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.OmhDataPoints"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Saves ready score information for a date. If ready score already exists for the date it is\nupdated and 200 is returned, otherwise it is created and 201 is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Save ready score information",
+                "parameters": [
+                    {
+                        "description": "Ready Score",
+                        "name": "readyscore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadyScoreInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/chart.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws one or more numeric ready score fields by day between start and end (inclusive) as a\nstandalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars\nor bars stacked in the order the fields are given, and band=true shades a line chart between\nits first and last fields. For example field=score\u0026type=bar draws a bar for each day's score.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and\ndefault to the last 30 days. Days with no value are left as gaps. Charts can be embedded\nwithout an Authorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get a chart of ready score as SVG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated numeric fields to draw",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "line",
+                            "bar",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "default": "line",
+                        "description": "Chart type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shade a line chart between its first and last fields",
+                        "name": "band",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 640,
+                        "description": "Width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 320,
+                        "description": "Height in pixels",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated hex series colors, overriding the theme",
+                        "name": "colors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex background color, or none for transparent",
+                        "name": "background",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, or empty for none",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves ready score information with specified date\nalong with any anomalies found against the recent personal baseline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get ready score information by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AnnotatedReadyScore"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/heatmap.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws a year of one numeric ready score field as a grid of days, one column per week starting\non Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls\nbetween the lowest and highest of the year, and hovering a day shows its value. field\ndefaults to score and year to the current year. Heatmaps can be embedded without an\nAuthorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get a year heatmap of ready score as SVG",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "score",
+                        "description": "Numeric field to shade by",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/id/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves ready score information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get ready score information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ready Score ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all fields of the ready score information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Replace ready score information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ready Score ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ready Score",
+                        "name": "readyscore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadyScoreInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the ready score information with specified ID",
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Delete ready score information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ready Score ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates only the fields given for the ready score information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Update ready score information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ready Score ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ready Score fields to change",
+                        "name": "readyscore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadyScoreInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Readyscore"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves list of ready score information ordered by date, newest first unless order=asc\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items, or a prev_token to go back.\nTokens are opaque and remain stable while new items are added.\nNext, previous and first page URLs are also returned in the Link header.\nPaging past the end of the list returns an empty data array.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get list of ready score information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "next list search by next_token",
+                        "name": "next_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "previous list search by prev_token",
+                        "name": "prev_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items per page, at most the server list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "order by date",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of fields to include in each item",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadyScores"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves ready score information between start and end (inclusive) in ascending order by date\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nA week or month start begins on the first day of that period and a week or month\nend finishes on the last day of that period. If end is omitted the range covers\nonly the period given by start. CSV and NDJSON are streamed a row at a time as they are read.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get ready score information for a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadyScoreRange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/series": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the daily value of one numeric ready score field between start and end (inclusive)\nalongside a rolling mean for each window of days and an exponentially weighted moving\naverage (EWMA). Rolling means average the days recorded within each window.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get smoothed ready score time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Numeric field to return",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7,30",
+                        "description": "Comma separated rolling mean windows in days",
+                        "name": "windows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "EWMA smoothing factor greater than 0 and at most 1",
+                        "name": "alpha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyscore/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves count, mean, median, min, max and standard deviation of each numeric\nready score field between start and end (inclusive), grouped by day, week or month.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nEach bucket is identified by its first day. stddev is null for a bucket with one value.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "readyscore"
+                ],
+                "summary": "Get ready score statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period to group by",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sign": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a signed copy of a chart, heatmap or badge URL that can be embedded in pages and\nstatus boards without an Authorization header. The signature covers the path and every\nquery parameter, so the URL cannot be changed to show anything else. expires_in is how\nmany seconds the URL stays valid, or 0 for a URL that never expires. Changing\nSIGNED_URL_SECRET revokes every URL signed before.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "signing"
+                ],
+                "summary": "Sign an image URL",
+                "parameters": [
+                    {
+                        "description": "URL to sign",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SignUrlInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SignedUrl"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Saves sleep information for a date. If sleep already exists for the date it is\nupdated and 200 is returned, otherwise it is created and 201 is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Save sleep information",
+                "parameters": [
+                    {
+                        "description": "Sleep",
+                        "name": "sleep",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SleepInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Sleep"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Sleep"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/chart.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws one or more numeric sleep fields by day between start and end (inclusive) as a\nstandalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars\nor bars stacked in the order the fields are given, and band=true shades a line chart between\nits first and last fields. Sleep durations are drawn in hours. For example field=deep_sleep,rem_sleep,light_sleep\u0026type=stacked\nstacks each night's sleep stages.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and\ndefault to the last 30 days. Days with no value are left as gaps. Charts can be embedded\nwithout an Authorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get a chart of sleep as SVG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated numeric fields to draw",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "line",
+                            "bar",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "default": "line",
+                        "description": "Chart type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shade a line chart between its first and last fields",
+                        "name": "band",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 640,
+                        "description": "Width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 320,
+                        "description": "Height in pixels",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated hex series colors, overriding the theme",
+                        "name": "colors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex background color, or none for transparent",
+                        "name": "background",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, or empty for none",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves sleep information with specified date\nalong with any anomalies found against the recent personal baseline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get sleep information by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AnnotatedSleep"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/heatmap.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws a year of one numeric sleep field as a grid of days, one column per week starting\non Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls\nbetween the lowest and highest of the year, and hovering a day shows its value. field\ndefaults to total_sleep and year to the current year. Heatmaps can be embedded without an\nAuthorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get a year heatmap of sleep as SVG",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "total_sleep",
+                        "description": "Numeric field to shade by",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/id/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves sleep information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get sleep information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Sleep ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Sleep"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all fields of the sleep information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Replace sleep information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Sleep ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sleep",
+                        "name": "sleep",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SleepInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Sleep"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the sleep information with specified ID",
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Delete sleep information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Sleep ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates only the fields given for the sleep information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Update sleep information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Sleep ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sleep fields to change",
+                        "name": "sleep",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SleepInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Sleep"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves list of sleep information ordered by date, newest first unless order=asc\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items, or a prev_token to go back.\nTokens are opaque and remain stable while new items are added.\nNext, previous and first page URLs are also returned in the Link header.\nPaging past the end of the list returns an empty data array.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get list of sleep information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "next list search by next_token",
+                        "name": "next_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "previous list search by prev_token",
+                        "name": "prev_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items per page, at most the server list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "order by date",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of fields to include in each item",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Sleeps"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves sleep information between start and end (inclusive) in ascending order by date\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nA week or month start begins on the first day of that period and a week or month\nend finishes on the last day of that period. If end is omitted the range covers\nonly the period given by start. CSV and NDJSON are streamed a row at a time as they are read.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get sleep information for a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SleepRange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/series": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the daily value of one numeric sleep field between start and end (inclusive)\nalongside a rolling mean for each window of days and an exponentially weighted moving\naverage (EWMA). Rolling means average the days recorded within each window.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get smoothed sleep time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Numeric field to return",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7,30",
+                        "description": "Comma separated rolling mean windows in days",
+                        "name": "windows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "EWMA smoothing factor greater than 0 and at most 1",
+                        "name": "alpha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/sleep/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves count, mean, median, min, max and standard deviation of each numeric\nsleep field between start and end (inclusive), grouped by day, week or month.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nEach bucket is identified by its first day. stddev is null for a bucket with one value.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sleep"
+                ],
+                "summary": "Get sleep statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period to group by",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Saves spo2 information for a date. If spo2 already exists for the date it is\nupdated and 200 is returned, otherwise it is created and 201 is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Save spo2 information",
+                "parameters": [
+                    {
+                        "description": "Spo2",
+                        "name": "spo2",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Spo2Input"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Spo2"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Spo2"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/chart.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws one or more numeric spo2 fields by day between start and end (inclusive) as a\nstandalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars\nor bars stacked in the order the fields are given, and band=true shades a line chart between\nits first and last fields. For example field=average_spo2 draws a line of the daily average.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and\ndefault to the last 30 days. Days with no value are left as gaps. Charts can be embedded\nwithout an Authorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get a chart of spo2 as SVG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated numeric fields to draw",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "line",
+                            "bar",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "default": "line",
+                        "description": "Chart type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shade a line chart between its first and last fields",
+                        "name": "band",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 640,
+                        "description": "Width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 320,
+                        "description": "Height in pixels",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated hex series colors, overriding the theme",
+                        "name": "colors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex background color, or none for transparent",
+                        "name": "background",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, or empty for none",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves spo2 information with specified date\nalong with any anomalies found against the recent personal baseline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get spo2 information by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AnnotatedSpo2"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/heatmap.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws a year of one numeric spo2 field as a grid of days, one column per week starting\non Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls\nbetween the lowest and highest of the year, and hovering a day shows its value. field\ndefaults to average_spo2 and year to the current year. Heatmaps can be embedded without an\nAuthorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get a year heatmap of spo2 as SVG",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "average_spo2",
+                        "description": "Numeric field to shade by",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/id/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves Spo2 information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get Spo2 information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spo2 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Spo2"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all fields of the spo2 information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Replace spo2 information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spo2 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Spo2",
+                        "name": "spo2",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Spo2Input"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Spo2"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the spo2 information with specified ID",
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Delete spo2 information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spo2 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates only the fields given for the spo2 information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Update spo2 information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spo2 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Spo2 fields to change",
+                        "name": "spo2",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Spo2Input"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Spo2"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves list of spo2 information ordered by date, newest first unless order=asc\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items, or a prev_token to go back.\nTokens are opaque and remain stable while new items are added.\nNext, previous and first page URLs are also returned in the Link header.\nPaging past the end of the list returns an empty data array.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get list of spo2 information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "next list search by next_token",
+                        "name": "next_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "previous list search by prev_token",
+                        "name": "prev_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items per page, at most the server list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "order by date",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of fields to include in each item",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Spo2s"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves spo2 information between start and end (inclusive) in ascending order by date\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nA week or month start begins on the first day of that period and a week or month\nend finishes on the last day of that period. If end is omitted the range covers\nonly the period given by start. CSV and NDJSON are streamed a row at a time as they are read.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get spo2 information for a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Spo2Range"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/series": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the daily value of one numeric spo2 field between start and end (inclusive)\nalongside a rolling mean for each window of days and an exponentially weighted moving\naverage (EWMA). Rolling means average the days recorded within each window.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get smoothed spo2 time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Numeric field to return",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7,30",
+                        "description": "Comma separated rolling mean windows in days",
+                        "name": "windows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "EWMA smoothing factor greater than 0 and at most 1",
+                        "name": "alpha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/spo2/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves count, mean, median, min, max and standard deviation of each numeric\nspo2 field between start and end (inclusive), grouped by day, week or month.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nEach bucket is identified by its first day. stddev is null for a bucket with one value.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "spo2"
+                ],
+                "summary": "Get spo2 statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period to group by",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Saves stress information for a date. If stress already exists for the date it is\nupdated and 200 is returned, otherwise it is created and 201 is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Save stress information",
+                "parameters": [
+                    {
+                        "description": "Stress",
+                        "name": "stress",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.StressInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Stress"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Stress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/chart.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws one or more numeric stress fields by day between start and end (inclusive) as a\nstandalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars\nor bars stacked in the order the fields are given, and band=true shades a line chart between\nits first and last fields. High stress time is drawn in hours. For example field=high_stress_duration\u0026type=bar draws a bar for each day.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and\ndefault to the last 30 days. Days with no value are left as gaps. Charts can be embedded\nwithout an Authorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get a chart of stress as SVG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated numeric fields to draw",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "line",
+                            "bar",
+                            "stacked"
+                        ],
+                        "type": "string",
+                        "default": "line",
+                        "description": "Chart type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shade a line chart between its first and last fields",
+                        "name": "band",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 640,
+                        "description": "Width in pixels",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 320,
+                        "description": "Height in pixels",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated hex series colors, overriding the theme",
+                        "name": "colors",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex background color, or none for transparent",
+                        "name": "background",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, or empty for none",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/date/{date}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves stress information with specified date\nalong with any anomalies found against the recent personal baseline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get stress information by date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AnnotatedStress"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/heatmap.svg": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Draws a year of one numeric stress field as a grid of days, one column per week starting\non Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls\nbetween the lowest and highest of the year, and hovering a day shows its value. field\ndefaults to high_stress_duration and year to the current year. Heatmaps can be embedded without an\nAuthorization header using a URL signed by /sign.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get a year heatmap of stress as SVG",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "high_stress_duration",
+                        "description": "Numeric field to shade by",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "light",
+                            "dark"
+                        ],
+                        "type": "string",
+                        "default": "light",
+                        "description": "Color theme",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time a signed URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature from /sign, instead of the Authorization header",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/id/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves stress information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get stress information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stress ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Stress"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces all fields of the stress information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Replace stress information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stress ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stress",
+                        "name": "stress",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.StressInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Stress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the stress information with specified ID",
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Delete stress information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stress ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates only the fields given for the stress information with specified ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Update stress information by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stress ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stress fields to change",
+                        "name": "stress",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.StressInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/austinapi_db.Stress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves list of stress information ordered by date, newest first unless order=asc\nSpecifying no query parameters pulls list starting with latest\nCaller can then specify a next_token from previous calls to go\nforward in the list of items, or a prev_token to go back.\nTokens are opaque and remain stable while new items are added.\nNext, previous and first page URLs are also returned in the Link header.\nPaging past the end of the list returns an empty data array.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get list of stress information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "next list search by next_token",
+                        "name": "next_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "previous list search by prev_token",
+                        "name": "prev_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items per page, at most the server list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "order by date",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of fields to include in each item",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stresses"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/range": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves stress information between start and end (inclusive) in ascending order by date\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nA week or month start begins on the first day of that period and a week or month\nend finishes on the last day of that period. If end is omitted the range covers\nonly the period given by start. CSV and NDJSON are streamed a row at a time as they are read.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/msgpack",
+                    "application/vnd.openmhealth+json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get stress information for a date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "msgpack",
+                            "omh"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.StressRange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/series": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves the daily value of one numeric stress field between start and end (inclusive)\nalongside a rolling mean for each window of days and an exponentially weighted moving\naverage (EWMA). Rolling means average the days recorded within each window.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get smoothed stress time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Numeric field to return",
+                        "name": "field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "7,30",
+                        "description": "Comma separated rolling mean windows in days",
+                        "name": "windows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "EWMA smoothing factor greater than 0 and at most 1",
+                        "name": "alpha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/stress/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves count, mean, median, min, max and standard deviation of each numeric\nstress field between start and end (inclusive), grouped by day, week or month.\nstart and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).\nEach bucket is identified by its first day. stddev is null for a bucket with one value.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stress"
+                ],
+                "summary": "Get stress statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date, ISO week or month",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, ISO week or month",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Period to group by",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/oura": {
+            "get": {
+                "description": "Answers the challenge Oura sends when a webhook subscription is created. The\nverification_token must match OURA_WEBHOOK_VERIFICATION_TOKEN.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Verify the Oura webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token given when creating the subscription",
+                        "name": "verification_token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Value to echo back",
+                        "name": "challenge",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.OuraWebhookChallenge"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Accepts an event Oura sends when a document is created or updated and queues the\nday it belongs to for a re-sync of its metric. The x-oura-signature header must be\nthe hex HMAC-SHA256 of the x-oura-timestamp header followed by the raw body, keyed\nwith the Oura client secret. Events for data types that are not synced are\nacknowledged and ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Receive an Oura webhook event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 signature",
+                        "name": "x-oura-signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unix time the event was signed",
+                        "name": "x-oura-timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.OuraWebhookEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.GenericMessage"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "austinapi_db.Heartrate": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "integer"
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "austinapi_db.Readyscore": {
+            "type": "object",
+            "properties": {
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "austinapi_db.Sleep": {
+            "type": "object",
+            "properties": {
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deep_sleep": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "light_sleep": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "rem_sleep": {
+                    "type": "integer"
+                },
+                "total_sleep": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "austinapi_db.Spo2": {
+            "type": "object",
+            "properties": {
+                "average_spo2": {
+                    "type": "number"
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "austinapi_db.Stress": {
+            "type": "object",
+            "properties": {
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "high_stress_duration": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AnnotatedHeartRate": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "average": {
+                    "type": "integer"
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AnnotatedReadyScore": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AnnotatedSleep": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deep_sleep": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "light_sleep": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "rem_sleep": {
+                    "type": "integer"
+                },
+                "total_sleep": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AnnotatedSpo2": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "average_spo2": {
+                    "type": "number"
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AnnotatedStress": {
+            "type": "object",
+            "properties": {
+                "anomaly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "created_timestamp": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "high_stress_duration": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Anomalies": {
+            "type": "object",
+            "properties": {
+                "baseline_days": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Anomaly"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.Anomaly": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "spread": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.BucketStats": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/main.FieldStats"
+                    }
+                }
+            }
+        },
+        "main.Correlation": {
+            "type": "object",
+            "properties": {
+                "lag": {
+                    "type": "integer"
+                },
+                "n": {
+                    "type": "integer"
+                },
+                "pearson": {
+                    "type": "number"
+                },
+                "pearson_p": {
+                    "type": "number"
+                },
+                "spearman": {
+                    "type": "number"
+                },
+                "spearman_p": {
+                    "type": "number"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Correlations": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Correlation"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "lag": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CsvImport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CsvRowError"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.CsvProfile": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "date_format": {
+                    "type": "string"
+                },
+                "defaults": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "units": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.CsvProfiles": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CsvProfile"
+                    }
+                }
+            }
+        },
+        "main.CsvRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Day": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "heartrate": {
+                    "$ref": "#/definitions/austinapi_db.Heartrate"
+                },
+                "readyscore": {
+                    "$ref": "#/definitions/austinapi_db.Readyscore"
+                },
+                "sleep": {
+                    "$ref": "#/definitions/austinapi_db.Sleep"
+                },
+                "spo2": {
+                    "$ref": "#/definitions/austinapi_db.Spo2"
+                },
+                "stress": {
+                    "$ref": "#/definitions/austinapi_db.Stress"
+                }
+            }
+        },
+        "main.FhirBundle": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirBundleEntry"
+                    }
+                },
+                "link": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirBundleLink"
+                    }
+                },
+                "resourceType": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirBundleEntry": {
+            "type": "object",
+            "properties": {
+                "fullUrl": {
+                    "type": "string"
+                },
+                "resource": {
+                    "$ref": "#/definitions/main.FhirObservation"
+                },
+                "search": {
+                    "$ref": "#/definitions/main.FhirBundleSearch"
+                }
+            }
+        },
+        "main.FhirBundleLink": {
+            "type": "object",
+            "properties": {
+                "relation": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirBundleSearch": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCapabilityInteraction": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCapabilityResource": {
+            "type": "object",
+            "properties": {
+                "interaction": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCapabilityInteraction"
+                    }
+                },
+                "searchParam": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCapabilitySearchParam"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCapabilityRest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCapabilityResource"
+                    }
+                }
+            }
+        },
+        "main.FhirCapabilitySearchParam": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCapabilityStatement": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "fhirVersion": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "resourceType": {
+                    "type": "string"
+                },
+                "rest": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCapabilityRest"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCodeableConcept": {
+            "type": "object",
+            "properties": {
+                "coding": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCoding"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirCoding": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "display": {
+                    "type": "string"
+                },
+                "system": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirIssue": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirObservation": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirCodeableConcept"
+                    }
+                },
+                "code": {
+                    "$ref": "#/definitions/main.FhirCodeableConcept"
+                },
+                "effectivePeriod": {
+                    "$ref": "#/definitions/main.FhirPeriod"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "resourceType": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "valueQuantity": {
+                    "$ref": "#/definitions/main.FhirQuantity"
+                }
+            }
+        },
+        "main.FhirOperationOutcome": {
+            "type": "object",
+            "properties": {
+                "issue": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FhirIssue"
+                    }
+                },
+                "resourceType": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirPeriod": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FhirQuantity": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "system": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.FieldChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "number"
+                },
+                "before": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.FieldStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "median": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "stddev": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.FitFileReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ImportedRow"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "file_type": {
+                    "type": "string"
+                },
+                "messages": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SkippedRow"
+                    }
+                },
+                "updated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ImportedRow"
+                    }
+                },
+                "utc_offset": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FitImport": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FitFileReport"
+                    }
+                }
+            }
+        },
+        "main.GenericMessage": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HeartRateInput": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.HeartRateRange": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Heartrate"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HeartRates": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Heartrate"
+                    }
+                },
+                "next_token": {
+                    "type": "string"
+                },
+                "prev_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ImportReport": {
+            "type": "object",
+            "properties": {
+                "records": {
+                    "type": "integer"
+                },
+                "saved": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ImportedRow": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.OmhAcquisitionProvenance": {
+            "type": "object",
+            "properties": {
+                "modality": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.OmhDataPoint": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "object"
+                },
+                "header": {
+                    "$ref": "#/definitions/main.OmhHeader"
+                }
+            }
+        },
+        "main.OmhDataPoints": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.OmhDataPoint"
+                    }
+                }
+            }
+        },
+        "main.OmhHeader": {
+            "type": "object",
+            "properties": {
+                "acquisition_provenance": {
+                    "$ref": "#/definitions/main.OmhAcquisitionProvenance"
                 },
-                "updated_timestamp": {
+                "creation_date_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "schema_id": {
+                    "$ref": "#/definitions/main.OmhSchemaId"
+                }
+            }
+        },
+        "main.OmhSchemaId": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "version": {
                     "type": "string"
                 }
             }
         },
-        "austinapi_db.Sleep": {
+        "main.OuraWebhookChallenge": {
             "type": "object",
             "properties": {
-                "created_timestamp": {
+                "challenge": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.OuraWebhookEvent": {
+            "type": "object",
+            "properties": {
+                "data_type": {
+                    "type": "string"
+                },
+                "event_time": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "object_id": {
                     "type": "string"
                 },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ReadyScoreInput": {
+            "type": "object",
+            "properties": {
                 "date": {
                     "type": "string"
                 },
-                "deep_sleep": {
+                "score": {
                     "type": "integer"
+                }
+            }
+        },
+        "main.ReadyScoreRange": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Readyscore"
+                    }
                 },
-                "id": {
-                    "type": "integer"
+                "end": {
+                    "type": "string"
                 },
-                "light_sleep": {
-                    "type": "integer"
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ReadyScores": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Readyscore"
+                    }
                 },
-                "rating": {
-                    "type": "integer"
+                "next_token": {
+                    "type": "string"
                 },
-                "rem_sleep": {
-                    "type": "integer"
+                "prev_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.RowChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
                 },
-                "total_sleep": {
-                    "type": "integer"
+                "date": {
+                    "type": "string"
                 },
-                "updated_timestamp": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/main.FieldChange"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "source": {
                     "type": "string"
                 }
             }
         },
-        "austinapi_db.Spo2": {
+        "main.Series": {
             "type": "object",
             "properties": {
-                "average_spo2": {
+                "alpha": {
                     "type": "number"
                 },
-                "created_timestamp": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SeriesPoint"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "start": {
                     "type": "string"
                 },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "main.SeriesPoint": {
+            "type": "object",
+            "properties": {
                 "date": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "ewma": {
+                    "type": "number"
                 },
-                "updated_timestamp": {
+                "rolling_mean": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.SignUrlInput": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 2592000
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/badge/sleep.svg"
+                }
+            }
+        },
+        "main.SignedUrl": {
+            "type": "object",
+            "properties": {
+                "expires": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "austinapi_db.Stress": {
+        "main.SkippedRow": {
             "type": "object",
             "properties": {
-                "created_timestamp": {
+                "date": {
                     "type": "string"
                 },
+                "metric": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SleepInput": {
+            "type": "object",
+            "properties": {
                 "date": {
                     "type": "string"
                 },
-                "high_stress_duration": {
+                "deep_sleep": {
                     "type": "integer"
                 },
-                "id": {
+                "light_sleep": {
                     "type": "integer"
                 },
-                "updated_timestamp": {
-                    "type": "string"
+                "rating": {
+                    "type": "integer"
+                },
+                "rem_sleep": {
+                    "type": "integer"
+                },
+                "total_sleep": {
+                    "type": "integer"
                 }
             }
         },
-        "main.GenericMessage": {
+        "main.SleepRange": {
             "type": "object",
             "properties": {
-                "message": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Sleep"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
                     "type": "string"
                 }
             }
         },
-        "main.HeartRates": {
+        "main.Sleeps": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/austinapi_db.Heartrate"
+                        "$ref": "#/definitions/austinapi_db.Sleep"
                     }
                 },
                 "next_token": {
-                    "type": "integer"
+                    "type": "string"
+                },
+                "prev_token": {
+                    "type": "string"
                 }
             }
         },
-        "main.ReadyScores": {
+        "main.Spo2Input": {
+            "type": "object",
+            "properties": {
+                "average_spo2": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Spo2Range": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/austinapi_db.Readyscore"
+                        "$ref": "#/definitions/austinapi_db.Spo2"
                     }
                 },
-                "next_token": {
-                    "type": "integer"
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
                 }
             }
         },
-        "main.Sleeps": {
+        "main.Spo2s": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/austinapi_db.Sleep"
+                        "$ref": "#/definitions/austinapi_db.Spo2"
                     }
                 },
                 "next_token": {
-                    "type": "integer"
+                    "type": "string"
+                },
+                "prev_token": {
+                    "type": "string"
                 }
             }
         },
-        "main.Spo2s": {
+        "main.Stats": {
             "type": "object",
             "properties": {
+                "bucket": {
+                    "type": "string"
+                },
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/austinapi_db.Spo2"
+                        "$ref": "#/definitions/main.BucketStats"
                     }
                 },
-                "next_token": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.StressInput": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "high_stress_duration": {
                     "type": "integer"
                 }
             }
         },
+        "main.StressRange": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/austinapi_db.Stress"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
         "main.Stresses": {
             "type": "object",
             "properties": {
@@ -1049,7 +7856,36 @@ const docTemplate = `{
                     }
                 },
                 "next_token": {
+                    "type": "string"
+                },
+                "prev_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TakeoutImport": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.RowChange"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "files": {
                     "type": "integer"
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SkippedRow"
+                    }
+                },
+                "source": {
+                    "type": "string"
                 }
             }
         }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 )
 
 func GetString(key string) string {
@@ -39,3 +40,36 @@ func GetUint8(key string) uint8 {
 	returnInt := GetInt(key)
 	return uint8(returnInt)
 }
+
+// GetStringDefault returns the environment value for key, or fallback when
+// it is not set.
+func GetStringDefault(key string, fallback string) string {
+	value := GetString(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+func GetIntDefault(key string, fallback int) int {
+	if GetString(key) == "" {
+		return fallback
+	}
+
+	return GetInt(key)
+}
+
+func GetDurationDefault(key string, fallback time.Duration) time.Duration {
+	value := GetString(key)
+	if value == "" {
+		return fallback
+	}
+
+	returnDuration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("error converting environment value %s to duration", key)
+	}
+
+	return returnDuration
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	ExportRgxParquet *regexp.Regexp
+
+	// ParquetExports are the tables that can be exported, in the order they
+	// are written to a zip
+	ParquetExports = []ParquetExport{
+		{
+			Metric: SleepMetric,
+			Model:  austinapi_db.Sleep{},
+			Rows: func(ctx context.Context, params DateRangeParams) (any, error) {
+				return ApiQueries.GetSleepsByDateRange(ctx, params)
+			},
+		},
+		{
+			Metric: ReadyScoreMetric,
+			Model:  austinapi_db.Readyscore{},
+			Rows: func(ctx context.Context, params DateRangeParams) (any, error) {
+				return ApiQueries.GetReadyScoresByDateRange(ctx, params)
+			},
+		},
+		{
+			Metric: HeartRateMetric,
+			Model:  austinapi_db.Heartrate{},
+			Rows: func(ctx context.Context, params DateRangeParams) (any, error) {
+				return ApiQueries.GetHeartRatesByDateRange(ctx, params)
+			},
+		},
+		{
+			Metric: StressMetric,
+			Model:  austinapi_db.Stress{},
+			Rows: func(ctx context.Context, params DateRangeParams) (any, error) {
+				return ApiQueries.GetStressesByDateRange(ctx, params)
+			},
+		},
+		{
+			Metric: Spo2Metric,
+			Model:  austinapi_db.Spo2{},
+			Rows: func(ctx context.Context, params DateRangeParams) (any, error) {
+				return ApiQueries.GetSpo2sByDateRange(ctx, params)
+			},
+		},
+	}
+)
+
+type ExportHandler struct{}
+
+// ParquetExport loads every row of one metric's table in a date range.
+type ParquetExport struct {
+	Metric Metric
+	Model  any
+	Rows   func(ctx context.Context, params DateRangeParams) (any, error)
+}
+
+func init() {
+	ExportRgxParquet = regexp.MustCompile(`^/export/parquet$`)
+}
+
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && ExportRgxParquet.MatchString(r.URL.Path):
+		h.exportParquet(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Export metrics as Parquet
+// @Security ApiKeyAuth
+// @Description Exports every row of the chosen metrics between start and end (inclusive) as Apache Parquet,
+// @Description with a typed column for each field: date as DATE, timestamps as TIMESTAMP_MICROS in UTC,
+// @Description whole numbers as INT64 and spo2 as DOUBLE. One metric is returned as a .parquet file and
+// @Description several as a zip holding one .parquet file per metric. metrics defaults to all of them.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02);
+// @Description leaving both out exports everything.
+// @Tags export
+// @Produce application/vnd.apache.parquet,application/zip
+// @Param metrics query string false "Comma separated metrics" default(sleep,readyscore,heartrate,stress,spo2)
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {file} file
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /export/parquet [get]
+func (h *ExportHandler) exportParquet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	exports := ParquetExports
+	if query.Has("metrics") {
+		exports = nil
+		for _, name := range strings.Split(query.Get("metrics"), ",") {
+			name = strings.TrimSpace(name)
+			index := slices.IndexFunc(ParquetExports, func(export ParquetExport) bool {
+				return export.Metric.Name == name
+			})
+			if index < 0 {
+				ErrorLog.Printf("unknown metric '%s' in export", name)
+				handleError(w, http.StatusBadRequest, fmt.Sprintf("Unknown metric '%s', expected sleep, readyscore, heartrate, stress or spo2", name))
+				return
+			}
+			if !slices.ContainsFunc(exports, func(export ParquetExport) bool { return export.Metric.Name == name }) {
+				exports = append(exports, ParquetExports[index])
+			}
+		}
+	}
+
+	params := DateRangeParams{StartDate: EarliestDate, EndDate: LatestDate}
+	if query.Get("start") != "" || query.Get("end") != "" {
+		startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+		if err != nil {
+			ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+			return
+		}
+		params = DateRangeParams{StartDate: startDate, EndDate: endDate}
+	}
+
+	// Everything is loaded before the response starts so a database error
+	// can still be reported with a status code
+	tables := make([]any, len(exports))
+	for i, export := range exports {
+		rows, err := export.Rows(r.Context(), params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving %s for export: %v", export.Metric.Name, err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		tables[i] = rows
+	}
+
+	if len(exports) == 1 {
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.parquet"`, exports[0].Metric.Name))
+		w.WriteHeader(http.StatusOK)
+
+		err := writeParquet(w, exports[0].Model, tables[0])
+		if err != nil {
+			ErrorLog.Printf("error writing %s parquet export: %v", exports[0].Metric.Name, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="austinapi-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	zipWriter := zip.NewWriter(w)
+	for i, export := range exports {
+		file, err := zipWriter.Create(export.Metric.Name + ".parquet")
+		if err == nil {
+			err = writeParquet(file, export.Model, tables[i])
+		}
+		if err != nil {
+			ErrorLog.Printf("error writing %s parquet export: %v", export.Metric.Name, err)
+			return
+		}
+	}
+
+	err := zipWriter.Close()
+	if err != nil {
+		ErrorLog.Printf("error writing parquet export zip: %v", err)
+	}
+}
+
+func writeParquet(writer io.Writer, model any, rows any) error {
+	parquetWriter, err := NewParquetWriter(writer, model)
+	if err != nil {
+		return err
+	}
+
+	err = parquetWriter.Write(rows)
+	if err != nil {
+		return err
+	}
+
+	return parquetWriter.Close()
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultFeedDays = 30
+
+	AtomNamespace = "http://www.w3.org/2005/Atom"
+)
+
+var (
+	FeedRgxAtom *regexp.Regexp
+)
+
+type FeedHandler struct{}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type AtomEntry struct {
+	Id      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Link    []AtomLink `xml:"link"`
+	Summary AtomText   `xml:"summary"`
+	Content AtomText   `xml:"content"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []AtomLink  `xml:"link"`
+	Author  AtomPerson  `xml:"author"`
+	Entry   []AtomEntry `xml:"entry"`
+}
+
+func init() {
+	FeedRgxAtom = regexp.MustCompile(`^/feed\.atom$`)
+}
+
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && FeedRgxAtom.MatchString(r.URL.Path):
+		h.getAtomFeed(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get an Atom feed of daily digests
+// @Security ApiKeyAuth
+// @Description Returns an Atom feed with one entry per day, newest first, summarising that day's sleep,
+// @Description readiness, heart rate, stress and spo2. Entry ids are stable for a date and updated is the
+// @Description latest updated_timestamp of the day's records. start and end accept a date (2024-02-19),
+// @Description an ISO week (2024-W08) or a month (2024-02) and default to the last 30 days. The response
+// @Description carries an ETag and Last-Modified, and If-None-Match or If-Modified-Since give 304 when the
+// @Description feed has not changed. Last-Modified is the latest updated_timestamp, or for the default
+// @Description window midnight UTC today if that is later, since a day leaves the window then. Deleting a
+// @Description day leaves no timestamp behind, so prefer If-None-Match. Feed readers that cannot send a
+// @Description bearer token may pass FEED_TOKEN as the token parameter.
+// @Tags feeds
+// @Produce application/atom+xml
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param token query string false "Feed token, instead of the Authorization header"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param If-Modified-Since header string false "Last-Modified of a previous response"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Success 304
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /feed.atom [get]
+func (h *FeedHandler) getAtomFeed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	params := DateRangeParams{StartDate: today.AddDate(0, 0, -DefaultFeedDays), EndDate: today}
+	windowMoved := today
+	if query.Get("start") != "" || query.Get("end") != "" {
+		startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+		if err != nil {
+			ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+			return
+		}
+		params = DateRangeParams{StartDate: startDate, EndDate: endDate}
+		windowMoved = time.Time{}
+	}
+
+	days, err := digestDays(r, params)
+	if err != nil {
+		ErrorLog.Printf("error retrieving days for feed: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	baseUrl := requestBaseUrl(r)
+	feed := AtomFeed{
+		Xmlns: AtomNamespace,
+		Id:    "urn:austinapi:feed:daily",
+		Title: "austinapi daily digests",
+		Link: []AtomLink{
+			{Rel: "self", Href: baseUrl + r.URL.Path, Type: "application/atom+xml"},
+		},
+		Author: AtomPerson{Name: "austinapi"},
+	}
+
+	var feedUpdated time.Time
+	for _, day := range days {
+		updated := dayUpdated(day)
+		feedUpdated = later(feedUpdated, updated)
+
+		summary := digestSummary(day)
+		feed.Entry = append(feed.Entry, AtomEntry{
+			Id:      fmt.Sprintf("urn:austinapi:day:%s", day.Date),
+			Title:   fmt.Sprintf("Daily digest for %s", day.Date),
+			Updated: updated.UTC().Format(time.RFC3339),
+			Link:    []AtomLink{{Rel: "alternate", Href: fmt.Sprintf("%s/day/%s", baseUrl, day.Date), Type: "application/json"}},
+			Summary: AtomText{Type: "text", Body: strings.Join(summary, " ")},
+			Content: AtomText{Type: "text", Body: strings.Join(summary, "\n")},
+		})
+	}
+
+	// An empty feed is dated to the start of its range so it stays cacheable
+	if feedUpdated.IsZero() {
+		feedUpdated = params.StartDate
+	}
+	feed.Updated = feedUpdated.UTC().Format(time.RFC3339)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		ErrorLog.Printf("error marshaling atom feed: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+
+	hash := sha256.Sum256(body)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, hex.EncodeToString(hash[:16])))
+	w.Header().Set("Cache-Control", "private, max-age=0, must-revalidate")
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+
+	// The default window drops a day at midnight without any record being
+	// updated, so it is last modified no earlier than today. ServeContent
+	// answers If-None-Match, or failing that If-Modified-Since, with 304.
+	http.ServeContent(w, r, "", later(feedUpdated, windowMoved), bytes.NewReader(body))
+}
+
+// digestDays gathers every metric between the dates of params into one Day
+// per date, newest first. Dates with no records are left out.
+func digestDays(r *http.Request, params DateRangeParams) ([]Day, error) {
+	days := map[string]*Day{}
+	day := func(date time.Time) *Day {
+		key := date.Format("2006-01-02")
+		if days[key] == nil {
+			days[key] = &Day{Date: key}
+		}
+		return days[key]
+	}
+
+	sleeps, err := ApiQueries.GetSleepsByDateRange(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sleeps {
+		day(sleeps[i].Date).Sleep = &sleeps[i]
+	}
+
+	readyScores, err := ApiQueries.GetReadyScoresByDateRange(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range readyScores {
+		day(readyScores[i].Date).ReadyScore = &readyScores[i]
+	}
+
+	heartRates, err := ApiQueries.GetHeartRatesByDateRange(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range heartRates {
+		day(heartRates[i].Date).HeartRate = &heartRates[i]
+	}
+
+	stresses, err := ApiQueries.GetStressesByDateRange(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stresses {
+		day(stresses[i].Date).Stress = &stresses[i]
+	}
+
+	spo2s, err := ApiQueries.GetSpo2sByDateRange(r.Context(), params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range spo2s {
+		day(spo2s[i].Date).Spo2 = &spo2s[i]
+	}
+
+	keys := sortedKeys(days)
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	result := make([]Day, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *days[key])
+	}
+	return result, nil
+}
+
+// dayUpdated is the latest updated_timestamp of a day's records.
+func dayUpdated(day Day) time.Time {
+	var updated time.Time
+	if day.Sleep != nil {
+		updated = later(updated, day.Sleep.UpdatedTimestamp)
+	}
+	if day.ReadyScore != nil {
+		updated = later(updated, day.ReadyScore.UpdatedTimestamp)
+	}
+	if day.HeartRate != nil {
+		updated = later(updated, day.HeartRate.UpdatedTimestamp)
+	}
+	if day.Stress != nil {
+		updated = later(updated, day.Stress.UpdatedTimestamp)
+	}
+	if day.Spo2 != nil {
+		updated = later(updated, day.Spo2.UpdatedTimestamp)
+	}
+	return updated
+}
+
+// digestSummary writes one sentence for each metric recorded on a day.
+func digestSummary(day Day) []string {
+	var summary []string
+
+	if sleep := day.Sleep; sleep != nil {
+		summary = append(summary, sleepDigest(*sleep))
+	}
+	if day.ReadyScore != nil {
+		summary = append(summary, fmt.Sprintf("Readiness score %d.", day.ReadyScore.Score))
+	}
+	if heartRate := day.HeartRate; heartRate != nil {
+		summary = append(summary, fmt.Sprintf("Heart rate averaged %d bpm, ranging from %d to %d.", heartRate.Average, heartRate.Low, heartRate.High))
+	}
+	if day.Stress != nil {
+		summary = append(summary, fmt.Sprintf("%s in high stress.", formatSeconds(day.Stress.HighStressDuration)))
+	}
+	if day.Spo2 != nil {
+		summary = append(summary, fmt.Sprintf("Average SpO2 %.1f%%.", day.Spo2.AverageSpo2))
+	}
+
+	return summary
+}
+
+func sleepDigest(sleep austinapi_db.Sleep) string {
+	return fmt.Sprintf("Slept %s with a rating of %d: %s deep, %s light and %s REM.",
+		formatSeconds(int64(sleep.TotalSleep)),
+		sleep.Rating,
+		formatSeconds(int64(sleep.DeepSleep)),
+		formatSeconds(int64(sleep.LightSleep)),
+		formatSeconds(int64(sleep.RemSleep)),
+	)
+}
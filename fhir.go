@@ -0,0 +1,526 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FhirContentType = "application/fhir+json"
+
+	LoincSystem               = "http://loinc.org"
+	UcumSystem                = "http://unitsofmeasure.org"
+	ObservationCategorySystem = "http://terminology.hl7.org/CodeSystem/observation-category"
+)
+
+var (
+	FhirRgxMetadata      *regexp.Regexp
+	FhirRgxObservation   *regexp.Regexp
+	FhirRgxObservationId *regexp.Regexp
+
+	FhirSearchParameters = []string{"code", "date", "_count", "_offset"}
+	FhirDatePrefixes     = []string{"eq", "ge", "le", "gt", "lt"}
+
+	// FhirObservationCodes are the daily values published as Observations,
+	// each under its LOINC code. Scores and stress have no LOINC code and are
+	// left out. Durations are stored in seconds and published in minutes.
+	FhirObservationCodes = []FhirObservationCode{
+		{
+			Slug: "heart-rate", Metric: HeartRateMetric, Field: "average", Category: "vital-signs",
+			Coding: []FhirCoding{{System: LoincSystem, Code: "8867-4", Display: "Heart rate"}},
+			Unit:   "beats/minute", UnitCode: "/min", Scale: 1,
+		},
+		{
+			Slug: "spo2", Metric: Spo2Metric, Field: "average_spo2", Category: "vital-signs",
+			Coding: []FhirCoding{
+				{System: LoincSystem, Code: "59408-5", Display: "Oxygen saturation in Arterial blood by Pulse oximetry"},
+				{System: LoincSystem, Code: "2708-6", Display: "Oxygen saturation in Arterial blood"},
+			},
+			Unit: "%", UnitCode: "%", Scale: 1,
+		},
+		{
+			Slug: "sleep-duration", Metric: SleepMetric, Field: "total_sleep", Category: "activity",
+			Coding: []FhirCoding{{System: LoincSystem, Code: "93832-4", Display: "Sleep duration"}},
+			Unit:   "min", UnitCode: "min", Scale: 1.0 / 60,
+		},
+		{
+			Slug: "deep-sleep-duration", Metric: SleepMetric, Field: "deep_sleep", Category: "activity",
+			Coding: []FhirCoding{{System: LoincSystem, Code: "93831-6", Display: "Deep sleep duration"}},
+			Unit:   "min", UnitCode: "min", Scale: 1.0 / 60,
+		},
+		{
+			Slug: "light-sleep-duration", Metric: SleepMetric, Field: "light_sleep", Category: "activity",
+			Coding: []FhirCoding{{System: LoincSystem, Code: "93830-8", Display: "Light sleep duration"}},
+			Unit:   "min", UnitCode: "min", Scale: 1.0 / 60,
+		},
+		{
+			Slug: "rem-sleep-duration", Metric: SleepMetric, Field: "rem_sleep", Category: "activity",
+			Coding: []FhirCoding{{System: LoincSystem, Code: "93829-0", Display: "REM sleep duration"}},
+			Unit:   "min", UnitCode: "min", Scale: 1.0 / 60,
+		},
+	}
+)
+
+type FhirHandler struct{}
+
+// FhirObservationCode ties one LOINC coded Observation to the metric field
+// it is built from.
+type FhirObservationCode struct {
+	Slug     string
+	Metric   Metric
+	Field    string
+	Category string
+	Coding   []FhirCoding
+	Unit     string
+	UnitCode string
+	Scale    float64
+}
+
+type FhirCoding struct {
+	System  string `json:"system"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type FhirCodeableConcept struct {
+	Coding []FhirCoding `json:"coding"`
+	Text   string       `json:"text,omitempty"`
+}
+
+type FhirPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type FhirQuantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	System string  `json:"system"`
+	Code   string  `json:"code"`
+}
+
+// FhirObservation is a FHIR R4 Observation of one daily value. The day it
+// covers is given as a period since the values summarise the whole day.
+type FhirObservation struct {
+	ResourceType    string                `json:"resourceType"`
+	Id              string                `json:"id"`
+	Status          string                `json:"status"`
+	Category        []FhirCodeableConcept `json:"category"`
+	Code            FhirCodeableConcept   `json:"code"`
+	EffectivePeriod FhirPeriod            `json:"effectivePeriod"`
+	ValueQuantity   FhirQuantity          `json:"valueQuantity"`
+}
+
+type FhirBundleLink struct {
+	Relation string `json:"relation"`
+	Url      string `json:"url"`
+}
+
+type FhirBundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type FhirBundleEntry struct {
+	FullUrl  string           `json:"fullUrl"`
+	Resource FhirObservation  `json:"resource"`
+	Search   FhirBundleSearch `json:"search"`
+}
+
+// FhirBundle is a FHIR R4 searchset Bundle. Total counts every match and
+// Entry holds the page of them asked for.
+type FhirBundle struct {
+	ResourceType string            `json:"resourceType"`
+	Type         string            `json:"type"`
+	Total        int               `json:"total"`
+	Link         []FhirBundleLink  `json:"link"`
+	Entry        []FhirBundleEntry `json:"entry"`
+}
+
+type FhirIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics"`
+}
+
+type FhirOperationOutcome struct {
+	ResourceType string      `json:"resourceType"`
+	Issue        []FhirIssue `json:"issue"`
+}
+
+type FhirCapabilityStatement struct {
+	ResourceType string               `json:"resourceType"`
+	Status       string               `json:"status"`
+	Date         string               `json:"date"`
+	Kind         string               `json:"kind"`
+	FhirVersion  string               `json:"fhirVersion"`
+	Format       []string             `json:"format"`
+	Rest         []FhirCapabilityRest `json:"rest"`
+}
+
+type FhirCapabilityRest struct {
+	Mode     string                   `json:"mode"`
+	Resource []FhirCapabilityResource `json:"resource"`
+}
+
+type FhirCapabilityResource struct {
+	Type        string                      `json:"type"`
+	Interaction []FhirCapabilityInteraction `json:"interaction"`
+	SearchParam []FhirCapabilitySearchParam `json:"searchParam"`
+}
+
+type FhirCapabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+type FhirCapabilitySearchParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// fhirSearch is a parsed Observation search. Dates are inclusive.
+type fhirSearch struct {
+	codes  []FhirObservationCode
+	start  time.Time
+	end    time.Time
+	count  int
+	offset int
+}
+
+func init() {
+	FhirRgxMetadata = regexp.MustCompile(`^/fhir/metadata$`)
+	FhirRgxObservation = regexp.MustCompile(`^/fhir/Observation$`)
+	FhirRgxObservationId = regexp.MustCompile(`^/fhir/Observation/([a-z0-9-]+)-([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+}
+
+func (h *FhirHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", FhirContentType)
+
+	switch {
+	case r.Method == http.MethodGet && FhirRgxMetadata.MatchString(r.URL.Path):
+		h.getMetadata(w, r)
+	case r.Method == http.MethodGet && FhirRgxObservation.MatchString(r.URL.Path):
+		h.searchObservations(w, r)
+	case r.Method == http.MethodGet && FhirRgxObservationId.MatchString(r.URL.Path):
+		h.getObservation(w, r)
+	default:
+		writeOperationOutcome(w, http.StatusMethodNotAllowed, "not-supported", "Method not allowed")
+	}
+}
+
+// @Summary Get the FHIR capability statement
+// @Security ApiKeyAuth
+// @Description Describes the FHIR R4 interactions and search parameters this server supports
+// @Tags fhir
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} FhirCapabilityStatement
+// @Failure 401
+// @Router /fhir/metadata [get]
+func (h *FhirHandler) getMetadata(w http.ResponseWriter, r *http.Request) {
+	statement := FhirCapabilityStatement{
+		ResourceType: "CapabilityStatement",
+		Status:       "active",
+		Date:         time.Now().UTC().Format("2006-01-02"),
+		Kind:         "instance",
+		FhirVersion:  "4.0.1",
+		Format:       []string{"json"},
+		Rest: []FhirCapabilityRest{{
+			Mode: "server",
+			Resource: []FhirCapabilityResource{{
+				Type:        "Observation",
+				Interaction: []FhirCapabilityInteraction{{Code: "read"}, {Code: "search-type"}},
+				SearchParam: []FhirCapabilitySearchParam{
+					{Name: "code", Type: "token"},
+					{Name: "date", Type: "date"},
+					{Name: "_count", Type: "number"},
+				},
+			}},
+		}},
+	}
+
+	writeJSON(w, http.StatusOK, statement)
+}
+
+// @Summary Search FHIR Observations
+// @Security ApiKeyAuth
+// @Description Returns a FHIR R4 searchset Bundle of daily Observations ordered by date and then code.
+// @Description Observations are published for heart rate (LOINC 8867-4, the daily average), SpO2
+// @Description (59408-5), sleep duration (93832-4) and deep (93831-6), light (93830-8) and REM
+// @Description (93829-0) sleep duration in minutes. code takes comma separated codes, optionally as
+// @Description http://loinc.org|code. date may be repeated and takes a day, ISO week or month with
+// @Description an optional eq, ge, le, gt or lt prefix. _count sets the page size, at most the server
+// @Description list limit, and the next link pages on with _offset. Errors are OperationOutcomes.
+// @Tags fhir
+// @Produce json
+// @Param code query string false "Comma separated LOINC codes"
+// @Param date query []string false "Date with an optional eq, ge, le, gt or lt prefix" collectionFormat(multi)
+// @Param _count query int false "Number of Observations per page"
+// @Param _offset query int false "Number of Observations to skip"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} FhirBundle
+// @Failure 400 {object} FhirOperationOutcome
+// @Failure 500 {object} FhirOperationOutcome
+// @Failure 401
+// @Router /fhir/Observation [get]
+func (h *FhirHandler) searchObservations(w http.ResponseWriter, r *http.Request) {
+	search, err := parseFhirSearch(r.URL.Query())
+	if err != nil {
+		ErrorLog.Printf("error parsing fhir search '%s': %v", r.URL.RawQuery, err)
+		writeOperationOutcome(w, http.StatusBadRequest, "invalid", fmt.Sprintf("Invalid search: %v", err))
+		return
+	}
+
+	observations, err := fhirObservations(search.codes, search.start, search.end)
+	if err != nil {
+		ErrorLog.Printf("error retrieving fhir observations: %v", err)
+		writeOperationOutcome(w, http.StatusInternalServerError, "exception", "Internal Error")
+		return
+	}
+
+	baseUrl := requestBaseUrl(r)
+
+	bundle := FhirBundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Total:        len(observations),
+		Link:         []FhirBundleLink{{Relation: "self", Url: baseUrl + r.URL.RequestURI()}},
+		Entry:        []FhirBundleEntry{},
+	}
+
+	page := observations[min(search.offset, len(observations)):min(search.offset+search.count, len(observations))]
+	for _, observation := range page {
+		bundle.Entry = append(bundle.Entry, FhirBundleEntry{
+			FullUrl:  fmt.Sprintf("%s/fhir/Observation/%s", baseUrl, observation.Id),
+			Resource: observation,
+			Search:   FhirBundleSearch{Mode: "match"},
+		})
+	}
+
+	if search.offset+search.count < len(observations) {
+		values := r.URL.Query()
+		values.Set("_offset", strconv.Itoa(search.offset+search.count))
+		bundle.Link = append(bundle.Link, FhirBundleLink{Relation: "next", Url: fmt.Sprintf("%s%s?%s", baseUrl, r.URL.Path, values.Encode())})
+	}
+
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// @Summary Get a FHIR Observation
+// @Security ApiKeyAuth
+// @Description Retrieves one daily Observation. Ids are the Observation's kind and date, such as
+// @Description heart-rate-2024-02-19 or sleep-duration-2024-02-19, as given in search results.
+// @Tags fhir
+// @Produce json
+// @Param id path string true "Observation id"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} FhirObservation
+// @Failure 404 {object} FhirOperationOutcome
+// @Failure 500 {object} FhirOperationOutcome
+// @Failure 401
+// @Router /fhir/Observation/{id} [get]
+func (h *FhirHandler) getObservation(w http.ResponseWriter, r *http.Request) {
+	matches := FhirRgxObservationId.FindStringSubmatch(r.URL.Path)
+	slug, day := matches[1], matches[2]
+
+	index := slices.IndexFunc(FhirObservationCodes, func(code FhirObservationCode) bool {
+		return code.Slug == slug
+	})
+	date, err := time.Parse("2006-01-02", day)
+	if index < 0 || err != nil {
+		InfoLog.Printf("fhir observation id '%s-%s' is not one this server issues", slug, day)
+		writeOperationOutcome(w, http.StatusNotFound, "not-found", fmt.Sprintf("Observation not found with id %s-%s", slug, day))
+		return
+	}
+
+	observations, err := fhirObservations(FhirObservationCodes[index:index+1], date, date)
+	if err != nil {
+		ErrorLog.Printf("error retrieving fhir observation '%s-%s': %v", slug, day, err)
+		writeOperationOutcome(w, http.StatusInternalServerError, "exception", "Internal Error")
+		return
+	}
+
+	if len(observations) != 1 {
+		InfoLog.Printf("fhir observation '%s-%s' was not found in database", slug, day)
+		writeOperationOutcome(w, http.StatusNotFound, "not-found", fmt.Sprintf("Observation not found with id %s-%s", slug, day))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, observations[0])
+}
+
+// parseFhirSearch validates the search parameters of an Observation search.
+func parseFhirSearch(values url.Values) (fhirSearch, error) {
+	search := fhirSearch{
+		codes: FhirObservationCodes,
+		start: EarliestDate,
+		end:   LatestDate,
+		count: int(ListRowLimit),
+	}
+
+	for key := range values {
+		if !slices.Contains(FhirSearchParameters, key) {
+			return search, fmt.Errorf("unsupported search parameter '%s', expected one of %s", key, strings.Join(FhirSearchParameters, ", "))
+		}
+	}
+
+	if values.Has("code") {
+		tokens := strings.Split(values.Get("code"), ",")
+
+		search.codes = slices.DeleteFunc(slices.Clone(FhirObservationCodes), func(observationCode FhirObservationCode) bool {
+			return !slices.ContainsFunc(tokens, observationCode.matches)
+		})
+	}
+
+	for _, value := range values["date"] {
+		prefix := "eq"
+		if len(value) > 2 && slices.Contains(FhirDatePrefixes, value[:2]) {
+			prefix, value = value[:2], value[2:]
+		}
+
+		first, last, err := parsePeriod(value)
+		if err != nil {
+			return search, fmt.Errorf("date: %v", err)
+		}
+
+		switch prefix {
+		case "eq":
+			search.start = later(search.start, first)
+			search.end = earlier(search.end, last)
+		case "ge":
+			search.start = later(search.start, first)
+		case "gt":
+			search.start = later(search.start, last.AddDate(0, 0, 1))
+		case "le":
+			search.end = earlier(search.end, last)
+		case "lt":
+			search.end = earlier(search.end, first.AddDate(0, 0, -1))
+		}
+	}
+
+	if values.Has("_count") {
+		count, err := strconv.Atoi(values.Get("_count"))
+		if err != nil || count < 0 {
+			return search, fmt.Errorf("_count '%s' must be a whole number of zero or more", values.Get("_count"))
+		}
+		search.count = min(count, int(ListRowLimit))
+	}
+
+	if values.Has("_offset") {
+		offset, err := strconv.Atoi(values.Get("_offset"))
+		if err != nil || offset < 0 {
+			return search, fmt.Errorf("_offset '%s' must be a whole number of zero or more", values.Get("_offset"))
+		}
+		search.offset = offset
+	}
+
+	return search, nil
+}
+
+// fhirObservations builds the Observations for codes between start and end,
+// inclusive, ordered by date and then by the order of codes.
+// Each metric is read once however many of its fields are asked for.
+func fhirObservations(codes []FhirObservationCode, start time.Time, end time.Time) ([]FhirObservation, error) {
+	observations := []FhirObservation{}
+	if end.Before(start) {
+		return observations, nil
+	}
+
+	params := DateRangeParams{StartDate: start, EndDate: end}
+	metricValues := map[string]map[string][]DailyValue{}
+
+	for _, code := range codes {
+		values, found := metricValues[code.Metric.Name]
+		if !found {
+			var err error
+			values, err = code.Metric.Values(DatabaseContext, params)
+			if err != nil {
+				return nil, fmt.Errorf("error retrieving %s: %w", code.Metric.Name, err)
+			}
+			metricValues[code.Metric.Name] = values
+		}
+
+		for _, value := range values[code.Field] {
+			observations = append(observations, code.observation(value))
+		}
+	}
+
+	// Observations were added in code order, which a stable sort keeps
+	slices.SortStableFunc(observations, func(a FhirObservation, b FhirObservation) int {
+		return strings.Compare(a.EffectivePeriod.Start, b.EffectivePeriod.Start)
+	})
+
+	return observations, nil
+}
+
+// matches reports whether a code search token, a bare code or system|code,
+// names this Observation.
+func (c FhirObservationCode) matches(token string) bool {
+	system, code, found := strings.Cut(strings.TrimSpace(token), "|")
+	if !found {
+		system, code = "", system
+	}
+
+	return slices.ContainsFunc(c.Coding, func(coding FhirCoding) bool {
+		return coding.Code == code && (system == "" || system == coding.System)
+	})
+}
+
+func (c FhirObservationCode) observation(value DailyValue) FhirObservation {
+	day := value.Date.Format("2006-01-02")
+
+	return FhirObservation{
+		ResourceType: "Observation",
+		Id:           fmt.Sprintf("%s-%s", c.Slug, day),
+		Status:       "final",
+		Category: []FhirCodeableConcept{{
+			Coding: []FhirCoding{{System: ObservationCategorySystem, Code: c.Category}},
+		}},
+		Code: FhirCodeableConcept{
+			Coding: c.Coding,
+			Text:   c.Coding[0].Display,
+		},
+		EffectivePeriod: FhirPeriod{Start: day, End: day},
+		ValueQuantity: FhirQuantity{
+			Value:  math.Round(value.Value*c.Scale*100) / 100,
+			Unit:   c.Unit,
+			System: UcumSystem,
+			Code:   c.UnitCode,
+		},
+	}
+}
+
+func writeOperationOutcome(w http.ResponseWriter, statusCode int, code string, diagnostics string) {
+	w.Header().Set("Content-Type", FhirContentType)
+
+	outcome := FhirOperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []FhirIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+
+	jsonBytes, err := json.Marshal(outcome)
+	if err != nil {
+		ErrorLog.Printf("error marshaling OperationOutcome: %v", err)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+func earlier(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// FIT is Garmin's binary activity and monitoring file format. Only what is
+// needed to read numeric fields is decoded here: definition messages, data
+// messages, compressed timestamp headers, developer fields (skipped) and
+// chained files. Field values are kept as float64 with FIT's invalid values
+// left out, and only the first element of array fields is kept.
+
+const (
+	FitHeaderSizeShort = 12
+	FitHeaderSizeLong  = 14
+
+	// FitEpoch is 1989-12-31T00:00:00Z, the zero of FIT timestamps, in Unix
+	// seconds
+	FitEpoch = 631065600
+
+	FitFieldTimestamp = 253
+
+	FitMessageFileId         = 0
+	FitMessageRecord         = 20
+	FitMessageActivity       = 34
+	FitMessageMonitoring     = 55
+	FitMessageMonitoringInfo = 103
+	FitMessageStressLevel    = 227
+	FitMessageSpo2           = 269
+	FitMessageSleepLevel     = 275
+)
+
+type FitMessage struct {
+	Global uint16
+	Fields map[byte]float64
+}
+
+type fitFieldDefinition struct {
+	number   byte
+	size     byte
+	baseType byte
+}
+
+type fitDefinition struct {
+	global         uint16
+	byteOrder      binary.ByteOrder
+	fields         []fitFieldDefinition
+	developerBytes int
+}
+
+var fitCrcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+func (m FitMessage) Has(field byte) bool {
+	_, found := m.Fields[field]
+	return found
+}
+
+// DecodeFit calls handle with every data message in data, which may hold
+// several FIT files chained one after another.
+func DecodeFit(data []byte, handle func(FitMessage)) error {
+	if len(data) == 0 {
+		return fmt.Errorf("file is empty")
+	}
+
+	for offset := 0; offset < len(data); {
+		size, err := decodeFitFile(data[offset:], handle)
+		if err != nil {
+			return err
+		}
+		offset += size
+	}
+
+	return nil
+}
+
+// decodeFitFile decodes one FIT file from the start of data, returning how
+// many bytes it used.
+func decodeFitFile(data []byte, handle func(FitMessage)) (int, error) {
+	if len(data) < FitHeaderSizeShort {
+		return 0, fmt.Errorf("file is too short for a FIT header")
+	}
+
+	headerSize := int(data[0])
+	if (headerSize != FitHeaderSizeShort && headerSize != FitHeaderSizeLong) || len(data) < headerSize {
+		return 0, fmt.Errorf("invalid FIT header size %d", headerSize)
+	}
+
+	if string(data[8:12]) != ".FIT" {
+		return 0, fmt.Errorf("missing .FIT signature")
+	}
+
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if len(data) < end+2 {
+		return 0, fmt.Errorf("file is truncated, header gives %d bytes of records", dataSize)
+	}
+
+	expected := binary.LittleEndian.Uint16(data[end : end+2])
+	if expected != 0 && fitCrc(data[:end]) != expected {
+		return 0, fmt.Errorf("CRC does not match, file is corrupt")
+	}
+
+	definitions := map[byte]fitDefinition{}
+	var lastTimestamp uint32
+
+	for position := headerSize; position < end; {
+		header := data[position]
+		position++
+
+		// Compressed timestamp headers carry a data message and the low five
+		// bits of its timestamp as an offset from the last full timestamp
+		if header&0x80 != 0 {
+			definition, found := definitions[(header>>5)&0x03]
+			if !found {
+				return 0, fmt.Errorf("data message at byte %d uses undefined local message", position-1)
+			}
+
+			message, size, err := definition.decode(data[position:end])
+			if err != nil {
+				return 0, err
+			}
+			position += size
+
+			timeOffset := uint32(header & 0x1F)
+			lastTimestamp += (timeOffset - lastTimestamp&0x1F) & 0x1F
+			message.Fields[FitFieldTimestamp] = float64(lastTimestamp)
+
+			handle(message)
+			continue
+		}
+
+		localType := header & 0x0F
+
+		if header&0x40 != 0 {
+			definition, size, err := decodeFitDefinition(data[position:end], header&0x20 != 0)
+			if err != nil {
+				return 0, err
+			}
+			position += size
+			definitions[localType] = definition
+			continue
+		}
+
+		definition, found := definitions[localType]
+		if !found {
+			return 0, fmt.Errorf("data message at byte %d uses undefined local message %d", position-1, localType)
+		}
+
+		message, size, err := definition.decode(data[position:end])
+		if err != nil {
+			return 0, err
+		}
+		position += size
+
+		if timestamp, found := message.Fields[FitFieldTimestamp]; found {
+			lastTimestamp = uint32(timestamp)
+		}
+
+		handle(message)
+	}
+
+	return end + 2, nil
+}
+
+func decodeFitDefinition(data []byte, developer bool) (fitDefinition, int, error) {
+	var definition fitDefinition
+
+	if len(data) < 5 {
+		return definition, 0, fmt.Errorf("definition message is truncated")
+	}
+
+	definition.byteOrder = binary.LittleEndian
+	if data[1] == 1 {
+		definition.byteOrder = binary.BigEndian
+	}
+	definition.global = definition.byteOrder.Uint16(data[2:4])
+
+	fieldCount := int(data[4])
+	size := 5 + fieldCount*3
+	if len(data) < size {
+		return definition, 0, fmt.Errorf("definition message is truncated")
+	}
+
+	for i := 0; i < fieldCount; i++ {
+		field := data[5+i*3 : 8+i*3]
+		if field[1] == 0 || int(field[1])%fitBaseTypeSize(field[2]) != 0 {
+			return definition, 0, fmt.Errorf("field %d of message %d has invalid size %d for base type %#x", field[0], definition.global, field[1], field[2])
+		}
+		definition.fields = append(definition.fields, fitFieldDefinition{number: field[0], size: field[1], baseType: field[2]})
+	}
+
+	if developer {
+		if len(data) < size+1 {
+			return definition, 0, fmt.Errorf("definition message is truncated")
+		}
+		developerCount := int(data[size])
+		size++
+
+		if len(data) < size+developerCount*3 {
+			return definition, 0, fmt.Errorf("definition message is truncated")
+		}
+		for i := 0; i < developerCount; i++ {
+			definition.developerBytes += int(data[size+i*3+1])
+		}
+		size += developerCount * 3
+	}
+
+	return definition, size, nil
+}
+
+func (d fitDefinition) decode(data []byte) (FitMessage, int, error) {
+	message := FitMessage{Global: d.global, Fields: map[byte]float64{}}
+
+	size := 0
+	for _, field := range d.fields {
+		if len(data) < size+int(field.size) {
+			return message, 0, fmt.Errorf("data message %d is truncated", d.global)
+		}
+
+		value, valid := fitValue(data[size:size+int(field.size)], field.baseType, d.byteOrder)
+		if valid {
+			message.Fields[field.number] = value
+		}
+		size += int(field.size)
+	}
+
+	if len(data) < size+d.developerBytes {
+		return message, 0, fmt.Errorf("data message %d is truncated", d.global)
+	}
+
+	return message, size + d.developerBytes, nil
+}
+
+// fitValue reads the first element of a field, reporting false for strings,
+// byte arrays and FIT's invalid value for the base type.
+func fitValue(data []byte, baseType byte, byteOrder binary.ByteOrder) (float64, bool) {
+	switch baseType & 0x1F {
+	case 0x00, 0x02: // enum, uint8
+		if len(data) < 1 {
+			return 0, false
+		}
+		return float64(data[0]), data[0] != 0xFF
+	case 0x01: // sint8
+		if len(data) < 1 {
+			return 0, false
+		}
+		return float64(int8(data[0])), data[0] != 0x7F
+	case 0x0A: // uint8z
+		if len(data) < 1 {
+			return 0, false
+		}
+		return float64(data[0]), data[0] != 0
+	case 0x03: // sint16
+		if len(data) < 2 {
+			return 0, false
+		}
+		value := byteOrder.Uint16(data)
+		return float64(int16(value)), value != 0x7FFF
+	case 0x04: // uint16
+		if len(data) < 2 {
+			return 0, false
+		}
+		value := byteOrder.Uint16(data)
+		return float64(value), value != 0xFFFF
+	case 0x0B: // uint16z
+		if len(data) < 2 {
+			return 0, false
+		}
+		value := byteOrder.Uint16(data)
+		return float64(value), value != 0
+	case 0x05: // sint32
+		if len(data) < 4 {
+			return 0, false
+		}
+		value := byteOrder.Uint32(data)
+		return float64(int32(value)), value != 0x7FFFFFFF
+	case 0x06: // uint32
+		if len(data) < 4 {
+			return 0, false
+		}
+		value := byteOrder.Uint32(data)
+		return float64(value), value != 0xFFFFFFFF
+	case 0x0C: // uint32z
+		if len(data) < 4 {
+			return 0, false
+		}
+		value := byteOrder.Uint32(data)
+		return float64(value), value != 0
+	case 0x08: // float32
+		if len(data) < 4 {
+			return 0, false
+		}
+		value := byteOrder.Uint32(data)
+		return float64(math.Float32frombits(value)), value != 0xFFFFFFFF
+	case 0x09: // float64
+		if len(data) < 8 {
+			return 0, false
+		}
+		value := byteOrder.Uint64(data)
+		return math.Float64frombits(value), value != 0xFFFFFFFFFFFFFFFF
+	case 0x0E: // sint64
+		if len(data) < 8 {
+			return 0, false
+		}
+		value := byteOrder.Uint64(data)
+		return float64(int64(value)), value != 0x7FFFFFFFFFFFFFFF
+	case 0x0F: // uint64
+		if len(data) < 8 {
+			return 0, false
+		}
+		value := byteOrder.Uint64(data)
+		return float64(value), value != 0xFFFFFFFFFFFFFFFF
+	case 0x10: // uint64z
+		if len(data) < 8 {
+			return 0, false
+		}
+		value := byteOrder.Uint64(data)
+		return float64(value), value != 0
+	default:
+		return 0, false
+	}
+}
+
+// fitBaseTypeSize is the width in bytes of one element of a base type. Base
+// types this decoder does not know are treated as bytes.
+func fitBaseTypeSize(baseType byte) int {
+	switch baseType & 0x1F {
+	case 0x03, 0x04, 0x0B:
+		return 2
+	case 0x05, 0x06, 0x0C, 0x08:
+		return 4
+	case 0x09, 0x0E, 0x0F, 0x10:
+		return 8
+	default:
+		return 1
+	}
+}
+
+func fitCrc(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := fitCrcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCrcTable[b&0xF]
+
+		tmp = fitCrcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCrcTable[(b>>4)&0xF]
+	}
+	return crc
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// fitTestFile wraps records in a FIT header and a CRC of zero, which the
+// decoder takes as no CRC.
+func fitTestFile(records []byte) []byte {
+	data := make([]byte, FitHeaderSizeShort, FitHeaderSizeShort+len(records)+2)
+	data[0] = FitHeaderSizeShort
+	data[1] = 0x10
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(records)))
+	copy(data[8:12], ".FIT")
+	data = append(data, records...)
+	return append(data, 0, 0)
+}
+
+func TestDecodeFitRejectsMalformedFieldSizes(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		size     byte
+		baseType byte
+	}{
+		{"zero sized uint8", 0, 0x02},
+		{"zero sized enum", 0, 0x00},
+		{"odd sized uint16", 3, 0x84},
+		{"short uint32", 2, 0x86},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			records := []byte{
+				// definition of local message 0, global message 55 with one field
+				0x40, 0, 0, FitMessageMonitoring, 0, 1, 3, test.size, test.baseType,
+				// a data message using it
+				0x00,
+			}
+
+			err := DecodeFit(fitTestFile(records), func(FitMessage) {
+				t.Fatal("data message decoded from a malformed definition")
+			})
+			if err == nil || !strings.Contains(err.Error(), "invalid size") {
+				t.Fatalf("expected an invalid size error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeFitReadsFields(t *testing.T) {
+	records := []byte{
+		0x40, 0, 0, FitMessageMonitoring, 0, 2, 3, 1, 0x02, 4, 2, 0x84,
+		0x00, 72, 0x34, 0x12,
+	}
+
+	var messages []FitMessage
+	err := DecodeFit(fitTestFile(records), func(message FitMessage) {
+		messages = append(messages, message)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Fields[3] != 72 || messages[0].Fields[4] != 0x1234 {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+}
+
+func TestFitValueShortData(t *testing.T) {
+	for _, baseType := range []byte{0x00, 0x01, 0x02, 0x0A, 0x84, 0x86, 0x89} {
+		if _, valid := fitValue(nil, baseType, binary.LittleEndian); valid {
+			t.Errorf("base type %#x read a value from no data", baseType)
+		}
+	}
+}
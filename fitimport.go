@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	FitFieldTimestamp16 = 26
+
+	// Garmin calls a stress level of 76 to 100 high stress. Levels are
+	// sampled every three minutes and negative levels mean none was measured.
+	FitHighStressLevel    = 76
+	FitStressSampleLength = 3 * time.Minute
+
+	// FitSleepLevelMaxGap caps how long one sleep level counts for, so a gap
+	// in recording is not counted as sleep
+	FitSleepLevelMaxGap = time.Hour
+
+	FitSleepLevelLight = 2
+	FitSleepLevelDeep  = 3
+	FitSleepLevelRem   = 4
+)
+
+var FitFileTypes = map[float64]string{
+	4:  "activity",
+	15: "monitoring_a",
+	28: "monitoring_daily",
+	32: "monitoring_b",
+}
+
+// FitUpload is one uploaded FIT file
+type FitUpload struct {
+	Name string
+	Data []byte
+}
+
+// FitFileReport lists the daily rows a FIT file contributed to and what was
+// skipped. Files without a local time are read as UTC.
+type FitFileReport struct {
+	File      string         `json:"file"`
+	FileType  string         `json:"file_type,omitempty"`
+	UtcOffset string         `json:"utc_offset,omitempty"`
+	Messages  int            `json:"messages"`
+	Samples   map[string]int `json:"samples"`
+	Created   []ImportedRow  `json:"created"`
+	Updated   []ImportedRow  `json:"updated"`
+	Skipped   []SkippedRow   `json:"skipped"`
+	Error     string         `json:"error,omitempty"`
+}
+
+type FitImport struct {
+	Files []FitFileReport `json:"files"`
+}
+
+type fitSample struct {
+	time  time.Time
+	value float64
+}
+
+// fitFile holds the samples decoded from one file, in UTC until the file's
+// local offset is known
+type fitFile struct {
+	heartRates  []fitSample
+	stress      []fitSample
+	spo2s       []fitSample
+	sleepLevels []fitSample
+	offset      *time.Duration
+}
+
+// fitDays rolls samples from every file in an upload up into days, keeping
+// track of which files contributed to each day.
+type fitDays struct {
+	heartRates map[string]*heartRateRollup
+	stress     map[string]int64
+	spo2s      map[string]*spo2Rollup
+	sleeps     map[string]*austinapi_db.SaveSleepParams
+	files      map[ImportedRow]map[int]bool
+}
+
+// ImportFit decodes each upload and upserts the days they cover. Samples from
+// all the files are combined before saving, so a day split over several
+// monitoring files should be uploaded in one request.
+func ImportFit(ctx context.Context, uploads []FitUpload) (FitImport, error) {
+	fitImport := FitImport{Files: []FitFileReport{}}
+
+	days := fitDays{
+		heartRates: map[string]*heartRateRollup{},
+		stress:     map[string]int64{},
+		spo2s:      map[string]*spo2Rollup{},
+		sleeps:     map[string]*austinapi_db.SaveSleepParams{},
+		files:      map[ImportedRow]map[int]bool{},
+	}
+
+	for i, upload := range uploads {
+		report := FitFileReport{
+			File:    upload.Name,
+			Samples: map[string]int{},
+			Created: []ImportedRow{},
+			Updated: []ImportedRow{},
+			Skipped: []SkippedRow{},
+		}
+
+		file, err := decodeFitSamples(upload.Data, &report)
+		if err != nil {
+			report.Error = err.Error()
+			report.Skipped = append(report.Skipped, SkippedRow{Reason: fmt.Sprintf("could not be decoded: %v", err)})
+			fitImport.Files = append(fitImport.Files, report)
+			continue
+		}
+
+		report.Samples[HeartRateMetric.Name] = len(file.heartRates)
+		report.Samples[StressMetric.Name] = len(file.stress)
+		report.Samples[Spo2Metric.Name] = len(file.spo2s)
+		report.Samples[SleepMetric.Name] = len(file.sleepLevels)
+
+		if len(file.heartRates)+len(file.stress)+len(file.spo2s)+len(file.sleepLevels) == 0 {
+			report.Skipped = append(report.Skipped, SkippedRow{Reason: "no heart rate, stress, spo2 or sleep samples"})
+		}
+
+		days.add(i, file)
+		fitImport.Files = append(fitImport.Files, report)
+	}
+
+	err := days.save(ctx, fitImport.Files)
+	return fitImport, err
+}
+
+func decodeFitSamples(data []byte, report *FitFileReport) (fitFile, error) {
+	var file fitFile
+	var lastTimestamp float64
+
+	err := DecodeFit(data, func(message FitMessage) {
+		report.Messages++
+
+		if timestamp, found := message.Fields[FitFieldTimestamp]; found {
+			lastTimestamp = timestamp
+		}
+		timestamp := fitTime(lastTimestamp)
+
+		switch message.Global {
+		case FitMessageFileId:
+			if fileType, found := message.Fields[0]; found {
+				report.FileType = FitFileTypes[fileType]
+				if report.FileType == "" {
+					report.FileType = fmt.Sprintf("type %.0f", fileType)
+				}
+			}
+
+		case FitMessageActivity, FitMessageMonitoringInfo:
+			// Both give the local time alongside the UTC timestamp, in field 5
+			// for activity and field 0 for monitoring info
+			localField := byte(5)
+			if message.Global == FitMessageMonitoringInfo {
+				localField = 0
+			}
+			if local, found := message.Fields[localField]; found && message.Has(FitFieldTimestamp) {
+				offset := time.Duration(local-message.Fields[FitFieldTimestamp]) * time.Second
+				file.offset = &offset
+			}
+
+		case FitMessageRecord:
+			if heartRate, found := message.Fields[3]; found && heartRate > 0 {
+				file.heartRates = append(file.heartRates, fitSample{time: timestamp, value: heartRate})
+			}
+
+		case FitMessageMonitoring:
+			// Monitoring messages often carry only the low 16 bits of their
+			// timestamp, relative to the last full one
+			if !message.Has(FitFieldTimestamp) {
+				if timestamp16, found := message.Fields[FitFieldTimestamp16]; found {
+					last := uint32(lastTimestamp)
+					timestamp = fitTime(float64(last + (uint32(timestamp16)-last&0xFFFF)&0xFFFF))
+				}
+			}
+			if heartRate, found := message.Fields[27]; found && heartRate > 0 {
+				file.heartRates = append(file.heartRates, fitSample{time: timestamp, value: heartRate})
+			}
+
+		case FitMessageStressLevel:
+			if stressTime, found := message.Fields[1]; found {
+				timestamp = fitTime(stressTime)
+			}
+			if level, found := message.Fields[0]; found && level >= 0 {
+				file.stress = append(file.stress, fitSample{time: timestamp, value: level})
+			}
+
+		case FitMessageSpo2:
+			if spo2, found := message.Fields[0]; found && spo2 > 0 {
+				file.spo2s = append(file.spo2s, fitSample{time: timestamp, value: spo2})
+			}
+
+		case FitMessageSleepLevel:
+			if level, found := message.Fields[0]; found {
+				file.sleepLevels = append(file.sleepLevels, fitSample{time: timestamp, value: level})
+			}
+		}
+	})
+	if err != nil {
+		return file, err
+	}
+
+	if file.offset != nil {
+		report.UtcOffset = time.Unix(0, 0).In(time.FixedZone("", int(file.offset.Seconds()))).Format("-07:00")
+	}
+
+	return file, nil
+}
+
+func fitTime(timestamp float64) time.Time {
+	return time.Unix(int64(timestamp)+FitEpoch, 0).UTC()
+}
+
+func (d fitDays) localDay(file fitFile, timestamp time.Time) string {
+	if file.offset != nil {
+		timestamp = timestamp.Add(*file.offset)
+	}
+	return timestamp.Format("2006-01-02")
+}
+
+func (d fitDays) contributed(metric string, day string, fileIndex int) {
+	row := ImportedRow{Metric: metric, Date: day}
+	if d.files[row] == nil {
+		d.files[row] = map[int]bool{}
+	}
+	d.files[row][fileIndex] = true
+}
+
+func (d fitDays) add(fileIndex int, file fitFile) {
+	for _, sample := range file.heartRates {
+		day := d.localDay(file, sample.time)
+
+		heartRate, found := d.heartRates[day]
+		if !found {
+			heartRate = &heartRateRollup{low: sample.value, high: sample.value}
+			d.heartRates[day] = heartRate
+		}
+
+		heartRate.low = math.Min(heartRate.low, sample.value)
+		heartRate.high = math.Max(heartRate.high, sample.value)
+		heartRate.sum += sample.value
+		heartRate.count++
+		d.contributed(HeartRateMetric.Name, day, fileIndex)
+	}
+
+	for _, sample := range file.spo2s {
+		day := d.localDay(file, sample.time)
+
+		spo2, found := d.spo2s[day]
+		if !found {
+			spo2 = &spo2Rollup{}
+			d.spo2s[day] = spo2
+		}
+
+		spo2.sum += sample.value
+		spo2.count++
+		d.contributed(Spo2Metric.Name, day, fileIndex)
+	}
+
+	// A stress level counts until the next one, for at most one sample length
+	sort.Slice(file.stress, func(i, j int) bool { return file.stress[i].time.Before(file.stress[j].time) })
+	for i, sample := range file.stress {
+		day := d.localDay(file, sample.time)
+
+		var length time.Duration
+		if sample.value >= FitHighStressLevel {
+			length = FitStressSampleLength
+			if i+1 < len(file.stress) {
+				length = min(length, file.stress[i+1].time.Sub(sample.time))
+			}
+		}
+
+		d.stress[day] += int64(length.Seconds())
+		d.contributed(StressMetric.Name, day, fileIndex)
+	}
+
+	// A sleep level lasts until the next one. The whole file's sleep goes to
+	// the day it ended on.
+	sort.Slice(file.sleepLevels, func(i, j int) bool { return file.sleepLevels[i].time.Before(file.sleepLevels[j].time) })
+	if len(file.sleepLevels) > 1 {
+		day := d.localDay(file, file.sleepLevels[len(file.sleepLevels)-1].time)
+
+		sleep, found := d.sleeps[day]
+		if !found {
+			sleep = &austinapi_db.SaveSleepParams{}
+			d.sleeps[day] = sleep
+		}
+
+		for i, level := range file.sleepLevels[:len(file.sleepLevels)-1] {
+			seconds := int(min(file.sleepLevels[i+1].time.Sub(level.time), FitSleepLevelMaxGap).Seconds())
+
+			switch level.value {
+			case FitSleepLevelLight:
+				sleep.LightSleep += seconds
+			case FitSleepLevelDeep:
+				sleep.DeepSleep += seconds
+			case FitSleepLevelRem:
+				sleep.RemSleep += seconds
+			default:
+				continue
+			}
+			sleep.TotalSleep += seconds
+		}
+
+		d.contributed(SleepMetric.Name, day, fileIndex)
+	}
+}
+
+// save upserts every day and adds it to the report of each file that
+// contributed to it.
+func (d fitDays) save(ctx context.Context, reports []FitFileReport) error {
+	record := func(metric string, day string, inserted bool, reason string) {
+		row := ImportedRow{Metric: metric, Date: day}
+		for _, fileIndex := range sortedFileIndexes(d.files[row]) {
+			report := &reports[fileIndex]
+			switch {
+			case reason != "":
+				report.Skipped = append(report.Skipped, SkippedRow{Metric: metric, Date: day, Reason: reason})
+			case inserted:
+				report.Created = append(report.Created, row)
+			default:
+				report.Updated = append(report.Updated, row)
+			}
+		}
+	}
+
+	for _, day := range sortedKeys(d.heartRates) {
+		heartRate := d.heartRates[day]
+		date, _ := time.Parse("2006-01-02", day)
+
+		_, inserted, err := ApiQueries.UpsertHeartRate(ctx, austinapi_db.SaveHeartRateParams{
+			Date:    date,
+			Low:     int(math.Round(heartRate.low)),
+			High:    int(math.Round(heartRate.high)),
+			Average: int(math.Round(heartRate.sum / float64(heartRate.count))),
+		})
+		if err != nil {
+			return fmt.Errorf("error saving heart rate with date '%s': %w", day, err)
+		}
+		record(HeartRateMetric.Name, day, inserted, "")
+	}
+
+	for _, day := range sortedKeys(d.stress) {
+		date, _ := time.Parse("2006-01-02", day)
+
+		_, inserted, err := ApiQueries.UpsertStress(ctx, austinapi_db.SaveStressParams{
+			Date:               date,
+			HighStressDuration: d.stress[day],
+		})
+		if err != nil {
+			return fmt.Errorf("error saving stress with date '%s': %w", day, err)
+		}
+		record(StressMetric.Name, day, inserted, "")
+	}
+
+	for _, day := range sortedKeys(d.spo2s) {
+		spo2 := d.spo2s[day]
+		date, _ := time.Parse("2006-01-02", day)
+
+		_, inserted, err := ApiQueries.UpsertSpo2(ctx, austinapi_db.SaveSpo2Params{
+			Date:        date,
+			AverageSpo2: spo2.sum / float64(spo2.count),
+		})
+		if err != nil {
+			return fmt.Errorf("error saving spo2 with date '%s': %w", day, err)
+		}
+		record(Spo2Metric.Name, day, inserted, "")
+	}
+
+	for _, day := range sortedKeys(d.sleeps) {
+		sleep := d.sleeps[day]
+		if sleep.TotalSleep == 0 {
+			record(SleepMetric.Name, day, false, "no light, deep or rem sleep recorded")
+			continue
+		}
+
+		sleep.Date, _ = time.Parse("2006-01-02", day)
+
+		_, inserted, err := ApiQueries.UpsertSleepDurations(ctx, *sleep)
+		if err != nil {
+			return fmt.Errorf("error saving sleep with date '%s': %w", day, err)
+		}
+		record(SleepMetric.Name, day, inserted, "")
+	}
+
+	return nil
+}
+
+func sortedFileIndexes(files map[int]bool) []int {
+	indexes := make([]int, 0, len(files))
+	for index := range files {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
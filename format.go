@@ -0,0 +1,619 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatJson    = "json"
+	FormatCsv     = "csv"
+	FormatNdjson  = "ndjson"
+	FormatMsgpack = "msgpack"
+	FormatOmh     = "omh"
+)
+
+var (
+	ErrNotAcceptable = errors.New("not acceptable")
+
+	// FormatContentTypes is the Content-Type written for each format
+	FormatContentTypes = map[string]string{
+		FormatJson:    "application/json",
+		FormatCsv:     "text/csv; charset=utf-8",
+		FormatNdjson:  "application/x-ndjson",
+		FormatMsgpack: "application/msgpack",
+		FormatOmh:     "application/vnd.openmhealth+json",
+	}
+
+	// FormatMediaTypes maps the media types a caller may Accept to a format.
+	// Wildcards get JSON, as every response did before negotiation.
+	FormatMediaTypes = map[string]string{
+		"application/json":                 FormatJson,
+		"text/csv":                         FormatCsv,
+		"application/x-ndjson":             FormatNdjson,
+		"application/ndjson":               FormatNdjson,
+		"application/jsonl":                FormatNdjson,
+		"application/msgpack":              FormatMsgpack,
+		"application/x-msgpack":            FormatMsgpack,
+		"application/vnd.msgpack":          FormatMsgpack,
+		"application/vnd.openmhealth+json": FormatOmh,
+		"application/*":                    FormatJson,
+		"text/*":                           FormatCsv,
+		"*/*":                              FormatJson,
+	}
+)
+
+// jsonObject is a JSON object that keeps its keys in the order they were
+// read, so CSV columns and MessagePack maps follow the struct's field order.
+type jsonObject struct {
+	Keys   []string
+	Values map[string]any
+}
+
+// negotiateFormat picks the response format from the format query parameter,
+// or else the Accept header, preferring the highest q value and then the
+// earliest listed. With neither the response is JSON.
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, found := FormatContentTypes[format]; !found {
+			return "", fmt.Errorf("format '%s' must be one of json, csv, ndjson, msgpack or omh", format)
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return FormatJson, nil
+	}
+
+	format := ""
+	quality := 0.0
+	for _, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+
+		entryFormat, found := FormatMediaTypes[mediaType]
+		if !found {
+			continue
+		}
+
+		entryQuality := 1.0
+		if q, found := params["q"]; found {
+			entryQuality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if entryQuality > quality {
+			format = entryFormat
+			quality = entryQuality
+		}
+	}
+
+	if format == "" {
+		return "", ErrNotAcceptable
+	}
+
+	return format, nil
+}
+
+// responseFormat negotiates the format of a response, answering with 406 or
+// 400 and returning false when there is none to use.
+func responseFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	format, err := negotiateFormat(r)
+	if errors.Is(err, ErrNotAcceptable) {
+		InfoLog.Printf("no acceptable format in Accept header '%s'", r.Header.Get("Accept"))
+		handleError(w, http.StatusNotAcceptable, "Acceptable formats are application/json, text/csv, application/x-ndjson, application/msgpack and application/vnd.openmhealth+json")
+		return "", false
+	}
+	if err != nil {
+		ErrorLog.Printf("error negotiating response format: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid format: %v", err))
+		return "", false
+	}
+
+	return format, true
+}
+
+// writeFormatted writes a successful GET response in the negotiated format.
+// JSON and MessagePack carry body as it is. CSV and NDJSON carry rows, which
+// is either a slice of items or a single item, one line per item. Open
+// mHealth carries the data points of rows that have an Open mHealth schema. fields,
+// when given, keeps only those fields of each item, as on list routes. rows
+// is already in memory, which suits single rows and list pages of at most
+// the list limit; unbounded ranges go through writeRangeFormatted.
+func writeFormatted(w http.ResponseWriter, r *http.Request, body any, rows any, fields []string) {
+	format, ok := responseFormat(w, r)
+	if !ok {
+		return
+	}
+
+	switch format {
+	case FormatJson, FormatMsgpack:
+		jsonBytes, err := marshalFields(body, fields)
+		if err != nil {
+			ErrorLog.Printf("error marshaling JSON response: %v", err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+
+		if format == FormatMsgpack {
+			value, err := decodeOrdered(jsonBytes)
+			if err != nil {
+				ErrorLog.Printf("error decoding JSON response for MessagePack: %v", err)
+				handleError(w, http.StatusInternalServerError, "Internal Error")
+				return
+			}
+			jsonBytes = appendMsgpack(nil, value)
+		}
+
+		w.Header().Set("Content-Type", FormatContentTypes[format])
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(jsonBytes)
+		if err != nil {
+			ErrorLog.Printf("error writing http response: %v", err)
+		}
+
+	case FormatCsv, FormatNdjson:
+		items, columns, err := formatRows(rows, fields)
+		if err != nil {
+			ErrorLog.Printf("error preparing %s response: %v", format, err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+
+		rowWriter := newRowWriter(w, format, columns)
+		for _, item := range items {
+			err = rowWriter.write(item)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = rowWriter.close()
+		}
+		if err != nil {
+			ErrorLog.Printf("error writing http response: %v", err)
+		}
+
+	case FormatOmh:
+		items, columns, err := formatRows(rows, fields)
+		if err != nil {
+			ErrorLog.Printf("error preparing %s response: %v", format, err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+
+		dataPoints := []OmhDataPoint{}
+		for _, item := range items {
+			object, err := orderedItem(item)
+			if err == nil {
+				var values map[string][]DailyValue
+				values, err = omhRowValues(object, columns)
+				dataPoints = append(dataPoints, omhDataPoints(values)...)
+			}
+			if err != nil {
+				ErrorLog.Printf("error preparing %s response: %v", format, err)
+				handleError(w, http.StatusInternalServerError, "Internal Error")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", FormatContentTypes[format])
+		writeJSON(w, http.StatusOK, OmhDataPoints{Data: dataPoints})
+	}
+}
+
+// formatRows lists the items in rows and the columns to write for them. The
+// columns come from the item type, so an empty slice still has a CSV header.
+func formatRows(rows any, fields []string) ([]any, []string, error) {
+	value := reflect.ValueOf(rows)
+
+	var items []any
+	itemType := value.Type()
+	if value.Kind() == reflect.Slice {
+		itemType = itemType.Elem()
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, value.Index(i).Interface())
+		}
+	} else {
+		items = append(items, rows)
+	}
+
+	jsonBytes, err := json.Marshal(reflect.Zero(itemType).Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+	zero, err := decodeOrdered(jsonBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	object, ok := zero.(jsonObject)
+	if !ok {
+		return nil, nil, fmt.Errorf("rows of %s do not marshal to JSON objects", itemType)
+	}
+
+	columns := object.Keys
+	if len(fields) > 0 {
+		columns = slices.DeleteFunc(slices.Clone(columns), func(column string) bool {
+			return !slices.Contains(fields, column)
+		})
+	}
+
+	return items, columns, nil
+}
+
+// writeRangeFormatted writes a range response in the negotiated format.
+// stream calls each with the rows of the range in turn. CSV and NDJSON write
+// every row as it arrives, so a long range is never held in memory. The other
+// formats need the whole range, so its rows are collected and body builds the
+// response from them. An error from stream is returned for the caller to log,
+// after answering with a 500 if nothing has been written yet.
+func writeRangeFormatted[T any](w http.ResponseWriter, r *http.Request, stream func(each func(T) error) error, body func([]T) any) error {
+	format, ok := responseFormat(w, r)
+	if !ok {
+		return nil
+	}
+
+	if format != FormatCsv && format != FormatNdjson {
+		results := []T{}
+		err := stream(func(item T) error {
+			results = append(results, item)
+			return nil
+		})
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return err
+		}
+
+		writeFormatted(w, r, body(results), results, nil)
+		return nil
+	}
+
+	_, columns, err := formatRows([]T{}, nil)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return err
+	}
+
+	rowWriter := newRowWriter(w, format, columns)
+	err = stream(func(item T) error {
+		return rowWriter.write(item)
+	})
+	if err == nil {
+		return rowWriter.close()
+	}
+
+	if !rowWriter.started {
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+	}
+	return err
+}
+
+// rowWriter writes items as CSV or NDJSON lines, flushing each one so the
+// response streams. The status, and for CSV the header row, go out with the
+// first item or on close, leaving an error before then free to be answered
+// with an error status instead.
+type rowWriter struct {
+	w         http.ResponseWriter
+	format    string
+	columns   []string
+	csvWriter *csv.Writer
+	started   bool
+}
+
+func newRowWriter(w http.ResponseWriter, format string, columns []string) *rowWriter {
+	return &rowWriter{w: w, format: format, columns: columns, csvWriter: csv.NewWriter(w)}
+}
+
+func (rw *rowWriter) start() error {
+	if rw.started {
+		return nil
+	}
+	rw.started = true
+
+	rw.w.Header().Set("Content-Type", FormatContentTypes[rw.format])
+	rw.w.WriteHeader(http.StatusOK)
+
+	if rw.format == FormatCsv {
+		return rw.csvWriter.Write(rw.columns)
+	}
+	return nil
+}
+
+// write writes one item. Nested values such as anomaly lists are written
+// as JSON in one CSV cell.
+func (rw *rowWriter) write(item any) error {
+	err := rw.start()
+	if err != nil {
+		return err
+	}
+
+	object, err := orderedItem(item)
+	if err != nil {
+		return err
+	}
+
+	if rw.format == FormatCsv {
+		record := make([]string, len(rw.columns))
+		for i, column := range rw.columns {
+			record[i], err = csvCell(object.Values[column])
+			if err != nil {
+				return err
+			}
+		}
+
+		err = rw.csvWriter.Write(record)
+		if err != nil {
+			return err
+		}
+		rw.csvWriter.Flush()
+		err = rw.csvWriter.Error()
+	} else {
+		var lineBytes []byte
+		lineBytes, err = json.Marshal(jsonObject{Keys: rw.columns, Values: object.Values})
+		if err == nil {
+			_, err = rw.w.Write(append(lineBytes, '\n'))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	flush(rw.w)
+	return nil
+}
+
+// close starts a response that had no items, so an empty CSV still has its
+// header row.
+func (rw *rowWriter) close() error {
+	err := rw.start()
+	if err != nil {
+		return err
+	}
+
+	rw.csvWriter.Flush()
+	return rw.csvWriter.Error()
+}
+
+func orderedItem(item any) (jsonObject, error) {
+	jsonBytes, err := json.Marshal(item)
+	if err != nil {
+		return jsonObject{}, err
+	}
+
+	value, err := decodeOrdered(jsonBytes)
+	if err != nil {
+		return jsonObject{}, err
+	}
+
+	object, ok := value.(jsonObject)
+	if !ok {
+		return jsonObject{}, fmt.Errorf("item of type %T does not marshal to a JSON object", item)
+	}
+	return object, nil
+}
+
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		cell, err := json.Marshal(v)
+		return string(cell), err
+	}
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// decodeOrdered decodes JSON into nil, bool, json.Number, string, []any and
+// jsonObject values.
+func decodeOrdered(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := jsonObject{Values: map[string]any{}}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string)
+
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, found := object.Values[key]; !found {
+				object.Keys = append(object.Keys, key)
+			}
+			object.Values[key] = value
+		}
+		_, err = decoder.Token()
+		return object, err
+
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+
+	return token, nil
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+
+	for i, key := range o.Keys {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyBytes)
+		buffer.WriteByte(':')
+
+		valueBytes, err := json.Marshal(o.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(valueBytes)
+	}
+
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// appendMsgpack appends the MessagePack encoding of a value produced by
+// decodeOrdered. Whole numbers use the smallest integer encoding that holds
+// them and other numbers are written as float64.
+func appendMsgpack(data []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(data, 0xc0)
+
+	case bool:
+		if v {
+			return append(data, 0xc3)
+		}
+		return append(data, 0xc2)
+
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return appendMsgpackInt(data, number)
+		}
+		number, _ := v.Float64()
+		data = append(data, 0xcb)
+		return binary.BigEndian.AppendUint64(data, math.Float64bits(number))
+
+	case string:
+		length := len(v)
+		switch {
+		case length < 32:
+			data = append(data, 0xa0|byte(length))
+		case length <= math.MaxUint8:
+			data = append(data, 0xd9, byte(length))
+		case length <= math.MaxUint16:
+			data = append(data, 0xda)
+			data = binary.BigEndian.AppendUint16(data, uint16(length))
+		default:
+			data = append(data, 0xdb)
+			data = binary.BigEndian.AppendUint32(data, uint32(length))
+		}
+		return append(data, v...)
+
+	case []any:
+		data = appendMsgpackLength(data, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			data = appendMsgpack(data, item)
+		}
+		return data
+
+	case jsonObject:
+		data = appendMsgpackLength(data, len(v.Keys), 0x80, 0xde, 0xdf)
+		for _, key := range v.Keys {
+			data = appendMsgpack(data, key)
+			data = appendMsgpack(data, v.Values[key])
+		}
+		return data
+	}
+
+	return append(data, 0xc0)
+}
+
+func appendMsgpackInt(data []byte, number int64) []byte {
+	switch {
+	case number >= 0 && number <= math.MaxInt8:
+		return append(data, byte(number))
+	case number < 0 && number >= -32:
+		return append(data, byte(int8(number)))
+	case number >= 0 && number <= math.MaxUint8:
+		return append(data, 0xcc, byte(number))
+	case number >= 0 && number <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(data, 0xcd), uint16(number))
+	case number >= 0 && number <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(data, 0xce), uint32(number))
+	case number >= 0:
+		return binary.BigEndian.AppendUint64(append(data, 0xcf), uint64(number))
+	case number >= math.MinInt8:
+		return append(data, 0xd0, byte(int8(number)))
+	case number >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(data, 0xd1), uint16(int16(number)))
+	case number >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(data, 0xd2), uint32(int32(number)))
+	default:
+		return binary.BigEndian.AppendUint64(append(data, 0xd3), uint64(number))
+	}
+}
+
+// appendMsgpackLength writes an array or map header, fix being the fixarray
+// or fixmap prefix and short and long its 16 and 32 bit forms.
+func appendMsgpackLength(data []byte, length int, fix byte, short byte, long byte) []byte {
+	switch {
+	case length < 16:
+		return append(data, fix|byte(length))
+	case length <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(data, short), uint16(length))
+	default:
+		return binary.BigEndian.AppendUint32(append(data, long), uint32(length))
+	}
+}
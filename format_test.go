@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// msgpackReader decodes the MessagePack appendMsgpack writes, returning maps
+// as jsonObject so key order can be checked too.
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) []byte {
+	if r.pos+n > len(r.data) {
+		panic(fmt.Sprintf("need %d bytes at %d of %d", n, r.pos, len(r.data)))
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *msgpackReader) value() any {
+	prefix := r.next(1)[0]
+
+	switch {
+	case prefix <= 0x7f:
+		return int64(prefix)
+	case prefix >= 0xe0:
+		return int64(int8(prefix))
+	case prefix&0xe0 == 0xa0:
+		return string(r.next(int(prefix & 0x1f)))
+	case prefix&0xf0 == 0x90:
+		return r.array(int(prefix & 0x0f))
+	case prefix&0xf0 == 0x80:
+		return r.object(int(prefix & 0x0f))
+	}
+
+	switch prefix {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xcc:
+		return int64(r.next(1)[0])
+	case 0xcd:
+		return int64(binary.BigEndian.Uint16(r.next(2)))
+	case 0xce:
+		return int64(binary.BigEndian.Uint32(r.next(4)))
+	case 0xcf:
+		return int64(binary.BigEndian.Uint64(r.next(8)))
+	case 0xd0:
+		return int64(int8(r.next(1)[0]))
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(r.next(2))))
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(r.next(4))))
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(r.next(8)))
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(r.next(8)))
+	case 0xd9:
+		return string(r.next(int(r.next(1)[0])))
+	case 0xda:
+		return string(r.next(int(binary.BigEndian.Uint16(r.next(2)))))
+	case 0xdb:
+		return string(r.next(int(binary.BigEndian.Uint32(r.next(4)))))
+	case 0xdc:
+		return r.array(int(binary.BigEndian.Uint16(r.next(2))))
+	case 0xdd:
+		return r.array(int(binary.BigEndian.Uint32(r.next(4))))
+	case 0xde:
+		return r.object(int(binary.BigEndian.Uint16(r.next(2))))
+	case 0xdf:
+		return r.object(int(binary.BigEndian.Uint32(r.next(4))))
+	}
+
+	panic(fmt.Sprintf("unknown prefix %#x", prefix))
+}
+
+func (r *msgpackReader) array(length int) []any {
+	array := []any{}
+	for i := 0; i < length; i++ {
+		array = append(array, r.value())
+	}
+	return array
+}
+
+func (r *msgpackReader) object(length int) jsonObject {
+	object := jsonObject{Values: map[string]any{}}
+	for i := 0; i < length; i++ {
+		key := r.value().(string)
+		object.Keys = append(object.Keys, key)
+		object.Values[key] = r.value()
+	}
+	return object
+}
+
+// msgpackExpected turns a decodeOrdered value into what msgpackReader
+// should give back for it.
+func msgpackExpected(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+		number, _ := v.Float64()
+		return number
+	case []any:
+		array := []any{}
+		for _, item := range v {
+			array = append(array, msgpackExpected(item))
+		}
+		return array
+	case jsonObject:
+		object := jsonObject{Keys: v.Keys, Values: map[string]any{}}
+		for key, item := range v.Values {
+			object.Values[key] = msgpackExpected(item)
+		}
+		return object
+	}
+	return value
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	numbers := []string{}
+	for _, number := range []int64{
+		0, 1, 127, 128, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32 + 1, math.MaxInt64,
+		-1, -32, -33, -128, -129, -32768, -32769, math.MinInt32, math.MinInt32 - 1, math.MinInt64,
+	} {
+		numbers = append(numbers, fmt.Sprint(number))
+	}
+
+	keys := []string{}
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf(`"key%02d":%d`, 19-i, i))
+	}
+
+	for name, document := range map[string]string{
+		"scalars":      `[null,true,false,1.5,-0.25,1e300,""]`,
+		"integers":     "[" + strings.Join(numbers, ",") + "]",
+		"fixstr":       fmt.Sprintf(`"%s"`, strings.Repeat("a", 31)),
+		"str8":         fmt.Sprintf(`"%s"`, strings.Repeat("b", 32)),
+		"str16":        fmt.Sprintf(`"%s"`, strings.Repeat("c", 256)),
+		"str32":        fmt.Sprintf(`"%s"`, strings.Repeat("d", 65536)),
+		"fixarray":     `[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15]`,
+		"array16":      `[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16]`,
+		"fixmap":       `{"b":1,"a":{"nested":[1,"two",null]}}`,
+		"map16":        "{" + strings.Join(keys, ",") + "}",
+		"sleep":        `{"id":1,"date":"2024-02-19T00:00:00Z","rating":84,"total_sleep":27000}`,
+		"empty object": `{}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			value, err := decodeOrdered([]byte(document))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			reader := msgpackReader{data: appendMsgpack(nil, value)}
+			decoded := reader.value()
+
+			if reader.pos != len(reader.data) {
+				t.Fatalf("decoded %d of %d bytes", reader.pos, len(reader.data))
+			}
+			if expected := msgpackExpected(value); !reflect.DeepEqual(decoded, expected) {
+				t.Fatalf("round trip gave %v, expected %v", decoded, expected)
+			}
+		})
+	}
+}
@@ -1,44 +1,95 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
 	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
 	"time"
 )
 
 var (
-	HeartRateRgxId   *regexp.Regexp
-	HeartRateListRgx *regexp.Regexp
-	HeartRateRgxDate *regexp.Regexp
+	HeartRateRgxId         *regexp.Regexp
+	HeartRateListRgx       *regexp.Regexp
+	HeartRateRgxDate       *regexp.Regexp
+	HeartRateRgxRange      *regexp.Regexp
+	HeartRateRgxStats      *regexp.Regexp
+	HeartRateRgxSeries     *regexp.Regexp
+	HeartRateRgxChart      *regexp.Regexp
+	HeartRateRgxHeatmap    *regexp.Regexp
+	HeartRateRgxCollection *regexp.Regexp
 )
 
 type HeartRateHandler struct{}
 
 type HeartRates struct {
 	Data      []austinapi_db.Heartrate `json:"data"`
-	NextToken int32                    `json:"next_token"`
+	NextToken string                   `json:"next_token,omitempty"`
+	PrevToken string                   `json:"prev_token,omitempty"`
+}
+
+type HeartRateRange struct {
+	Start string                   `json:"start"`
+	End   string                   `json:"end"`
+	Data  []austinapi_db.Heartrate `json:"data"`
+}
+
+// AnnotatedHeartRate is a heart rate with any anomalies found in it on that date
+type AnnotatedHeartRate struct {
+	austinapi_db.Heartrate
+	Anomaly []Anomaly `json:"anomaly"`
+}
+
+// HeartRateInput is the JSON body accepted when writing heart rate in beats
+// per minute. PATCH requests may leave out fields to keep their stored values.
+type HeartRateInput struct {
+	Date    *string `json:"date"`
+	Low     *int    `json:"low"`
+	High    *int    `json:"high"`
+	Average *int    `json:"average"`
 }
 
 func init() {
 	HeartRateRgxId = regexp.MustCompile(`^/heartrate/id/([0-9]+)$`)
-	HeartRateListRgx = regexp.MustCompile(`^/heartrate/list(?:\?(next_token)=([0-9]+))?$`)
+	HeartRateListRgx = regexp.MustCompile(`^/heartrate/list$`)
 	HeartRateRgxDate = regexp.MustCompile(`^/heartrate/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	HeartRateRgxRange = regexp.MustCompile(`^/heartrate/range$`)
+	HeartRateRgxStats = regexp.MustCompile(`^/heartrate/stats$`)
+	HeartRateRgxSeries = regexp.MustCompile(`^/heartrate/series$`)
+	HeartRateRgxChart = regexp.MustCompile(`^/heartrate/chart\.svg$`)
+	HeartRateRgxHeatmap = regexp.MustCompile(`^/heartrate/heatmap\.svg$`)
+	HeartRateRgxCollection = regexp.MustCompile(`^/heartrate$`)
 }
 
 func (h *HeartRateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	switch {
-	case r.Method == http.MethodGet && HeartRateListRgx.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && HeartRateListRgx.MatchString(r.URL.Path):
 		h.listHeartRate(w, r)
-	case r.Method == http.MethodGet && HeartRateRgxId.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && HeartRateRgxId.MatchString(r.URL.Path):
 		h.getHeartRate(w, r)
-	case r.Method == http.MethodGet && HeartRateRgxDate.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && HeartRateRgxDate.MatchString(r.URL.Path):
 		h.getHeartRateByDate(w, r)
+	case r.Method == http.MethodGet && HeartRateRgxRange.MatchString(r.URL.Path):
+		h.getHeartRateByDateRange(w, r)
+	case r.Method == http.MethodGet && HeartRateRgxStats.MatchString(r.URL.Path):
+		h.getHeartRateStats(w, r)
+	case r.Method == http.MethodGet && HeartRateRgxSeries.MatchString(r.URL.Path):
+		h.getHeartRateSeries(w, r)
+	case r.Method == http.MethodGet && HeartRateRgxChart.MatchString(r.URL.Path):
+		h.getHeartRateChart(w, r)
+	case r.Method == http.MethodGet && HeartRateRgxHeatmap.MatchString(r.URL.Path):
+		h.getHeartRateHeatmap(w, r)
+	case r.Method == http.MethodPost && HeartRateRgxCollection.MatchString(r.URL.Path):
+		h.createHeartRate(w, r)
+	case r.Method == http.MethodPut && HeartRateRgxId.MatchString(r.URL.Path):
+		h.replaceHeartRate(w, r)
+	case r.Method == http.MethodPatch && HeartRateRgxId.MatchString(r.URL.Path):
+		h.patchHeartRate(w, r)
+	case r.Method == http.MethodDelete && HeartRateRgxId.MatchString(r.URL.Path):
+		h.deleteHeartRate(w, r)
 	default:
 		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
@@ -49,12 +100,14 @@ func (h *HeartRateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieves heart rate information with specified ID
 // @Tags heartrate
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param id path string true "Heart Rate ID"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} austinapi_db.Heartrate
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /heartrate/id/{id} [get]
 func (h *HeartRateHandler) getHeartRate(w http.ResponseWriter, r *http.Request) {
@@ -82,37 +135,29 @@ func (h *HeartRateHandler) getHeartRate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
-	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
-		handleError(w, http.StatusInternalServerError, "Internal Error")
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
-	}
+	writeFormatted(w, r, result[0], result[0], nil)
 
 }
 
 // @Summary Get heart rate information by date
 // @Security ApiKeyAuth
 // @Description Retrieves heart rate information with specified date
+// @Description along with any anomalies found against the recent personal baseline
 // @Tags heartrate
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param date path string true "Date"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
-// @Success 200 {object} austinapi_db.Heartrate
+// @Success 200 {object} AnnotatedHeartRate
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /heartrate/date/{date} [get]
 func (h *HeartRateHandler) getHeartRateByDate(w http.ResponseWriter, r *http.Request) {
 
-	dateMatches := HeartRateRgxDate.FindStringSubmatch(r.URL.String())
+	dateMatches := HeartRateRgxDate.FindStringSubmatch(r.URL.Path)
 
 	if len(dateMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, HeartRateRgxDate.String())
@@ -144,94 +189,508 @@ func (h *HeartRateHandler) getHeartRateByDate(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
+	anomalies, err := annotateAnomalies(HeartRateMetric, date)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error detecting heart rate anomalies with date '%s': %v", dateString, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	annotated := AnnotatedHeartRate{
+		Heartrate: result[0],
+		Anomaly:   anomalies,
+	}
+
+	writeFormatted(w, r, annotated, annotated, nil)
+}
+
+// @Summary Get heart rate information for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves heart rate information between start and end (inclusive) in ascending order by date
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description A week or month start begins on the first day of that period and a week or month
+// @Description end finishes on the last day of that period. If end is omitted the range covers
+// @Description only the period given by start. CSV and NDJSON are streamed a row at a time as they are read.
+// @Tags heartrate
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} HeartRateRange
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/range [get]
+func (h *HeartRateHandler) getHeartRateByDateRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
 	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	err = writeRangeFormatted(w, r, func(each func(austinapi_db.Heartrate) error) error {
+		return ApiQueries.EachHeartrateByDateRange(DatabaseContext, params, each)
+	}, func(results []austinapi_db.Heartrate) any {
+		return HeartRateRange{
+			Start: startDate.Format("2006-01-02"),
+			End:   endDate.Format("2006-01-02"),
+			Data:  results,
+		}
+	})
+	if err != nil {
+		ErrorLog.Printf("error retrieving heart rate between '%s' and '%s': %v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+	}
+}
+
+// @Summary Get heart rate statistics
+// @Security ApiKeyAuth
+// @Description Retrieves count, mean, median, min, max and standard deviation of each numeric
+// @Description heart rate field between start and end (inclusive), grouped by day, week or month.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description Each bucket is identified by its first day. stddev is null for a bucket with one value.
+// @Tags heartrate
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param bucket query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Stats
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/stats [get]
+func (h *HeartRateHandler) getHeartRateStats(w http.ResponseWriter, r *http.Request) {
+	writeStats(w, r, "heart rate", ApiQueries.GetHeartRateStats)
+}
+
+// @Summary Get smoothed heart rate time series
+// @Security ApiKeyAuth
+// @Description Retrieves the daily value of one numeric heart rate field between start and end (inclusive)
+// @Description alongside a rolling mean for each window of days and an exponentially weighted moving
+// @Description average (EWMA). Rolling means average the days recorded within each window.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags heartrate
+// @Produce json
+// @Param field query string true "Numeric field to return"
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param windows query string false "Comma separated rolling mean windows in days" default(7,30)
+// @Param alpha query number false "EWMA smoothing factor greater than 0 and at most 1" default(0.3)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Series
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/series [get]
+func (h *HeartRateHandler) getHeartRateSeries(w http.ResponseWriter, r *http.Request) {
+	writeSeries(w, r, HeartRateMetric)
+}
+
+// @Summary Get a chart of heart rate as SVG
+// @Security ApiKeyAuth
+// @Description Draws one or more numeric heart rate fields by day between start and end (inclusive) as a
+// @Description standalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars
+// @Description or bars stacked in the order the fields are given, and band=true shades a line chart between
+// @Description its first and last fields. For example field=low,average,high&band=true draws the daily average inside a band from low to high.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and
+// @Description default to the last 30 days. Days with no value are left as gaps. Charts can be embedded
+// @Description without an Authorization header using a URL signed by /sign.
+// @Tags heartrate
+// @Produce image/svg+xml
+// @Param field query string true "Comma separated numeric fields to draw"
+// @Param type query string false "Chart type" Enums(line, bar, stacked) default(line)
+// @Param band query bool false "Shade a line chart between its first and last fields"
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param width query int false "Width in pixels" default(640)
+// @Param height query int false "Height in pixels" default(320)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param colors query string false "Comma separated hex series colors, overriding the theme"
+// @Param background query string false "Hex background color, or none for transparent"
+// @Param title query string false "Title, or empty for none"
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/chart.svg [get]
+func (h *HeartRateHandler) getHeartRateChart(w http.ResponseWriter, r *http.Request) {
+	writeChart(w, r, HeartRateMetric)
+}
+
+// @Summary Get a year heatmap of heart rate as SVG
+// @Security ApiKeyAuth
+// @Description Draws a year of one numeric heart rate field as a grid of days, one column per week starting
+// @Description on Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls
+// @Description between the lowest and highest of the year, and hovering a day shows its value. field
+// @Description defaults to average and year to the current year. Heatmaps can be embedded without an
+// @Description Authorization header using a URL signed by /sign.
+// @Tags heartrate
+// @Produce image/svg+xml
+// @Param year query int false "Year"
+// @Param field query string false "Numeric field to shade by" default(average)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/heatmap.svg [get]
+func (h *HeartRateHandler) getHeartRateHeatmap(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, HeartRateMetric)
 }
 
 // @Summary Get list of heart rate information
 // @Security ApiKeyAuth
-// @Description Retrieves list of heart rate information in descending order by date
+// @Description Retrieves list of heart rate information ordered by date, newest first unless order=asc
 // @Description Specifying no query parameters pulls list starting with latest
 // @Description Caller can then specify a next_token from previous calls to go
-// @Description forward in the list of items.
+// @Description forward in the list of items, or a prev_token to go back.
+// @Description Tokens are opaque and remain stable while new items are added.
+// @Description Next, previous and first page URLs are also returned in the Link header.
+// @Description Paging past the end of the list returns an empty data array.
 // @Tags heartrate
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param next_token query string false "next list search by next_token" Format(string)
+// @Param prev_token query string false "previous list search by prev_token" Format(string)
+// @Param limit query int false "number of items per page, at most the server list limit"
+// @Param order query string false "order by date" Enums(asc, desc) default(desc)
+// @Param fields query string false "comma separated list of fields to include in each item"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} HeartRates
+// @Failure 400 {object} GenericMessage
 // @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /heartrate/list [get]
 func (h *HeartRateHandler) listHeartRate(w http.ResponseWriter, r *http.Request) {
-	urlMatches := HeartRateListRgx.FindStringSubmatch(r.URL.String())
-
-	if len(urlMatches) != 3 {
-		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, HeartRateListRgx.String())
-		handleError(w, http.StatusInternalServerError, "Issue parsing URL")
+	listQuery, err := parseListQuery(r.URL.Query(), austinapi_db.Heartrate{})
+	if err != nil {
+		ErrorLog.Printf("error parsing query string '%s': %v", r.URL.RawQuery, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %v", err))
 		return
 	}
 
-	queryType := urlMatches[1]
-	queryToken := urlMatches[2]
+	InfoLog.Printf("URL directive match '%s'\n", listQuery.Direction)
+	InfoLog.Printf("URL token match '%s'\n", listQuery.Token)
 
-	InfoLog.Printf("URL directive match '%s'\n", queryType)
-	InfoLog.Printf("URL token match '%s'\n", queryToken)
+	var results []austinapi_db.Heartrate
 
-	params := austinapi_db.GetHeartRatesParams{
-		RowOffset: 0,
-		RowLimit:  ListRowLimit,
-	}
+	// One row more than a page is requested to tell whether another page follows
+	rowLimit := listQuery.Limit + 1
 
-	if queryType == "next_token" {
-		rowOffset, err := strconv.ParseInt(queryToken, 10, 32)
-		if err != nil {
-			ErrorLog.Printf("error parsing specified query token '%v': %v", queryToken, err)
+	switch listQuery.Direction {
+	case PageNext, PagePrev:
+		cursor, cursorErr := decodeCursor(listQuery.Token)
+		if cursorErr != nil {
+			ErrorLog.Printf("error parsing specified query token '%v': %v", listQuery.Token, cursorErr)
 			handleError(w, http.StatusBadRequest, "Invalid query token")
 			return
 		}
 
-		params.RowOffset = int32(rowOffset)
+		params := KeysetParams{
+			Date:     cursor.Date,
+			ID:       cursor.ID,
+			RowLimit: rowLimit,
+		}
+
+		if listQuery.FetchesNewer() {
+			results, err = ApiQueries.GetHeartRatesNewerThanCursor(DatabaseContext, params)
+		} else {
+			results, err = ApiQueries.GetHeartRatesOlderThanCursor(DatabaseContext, params)
+		}
+	case PageFirst:
+		if listQuery.Order == OrderAsc {
+			results, err = ApiQueries.GetHeartRatesOldestFirst(DatabaseContext, rowLimit)
+		} else {
+			params := austinapi_db.GetHeartRatesParams{
+				RowOffset: 0,
+				RowLimit:  rowLimit,
+			}
+
+			results, err = ApiDatabase.GetHeartRates(DatabaseContext, params)
+		}
 	}
 
-	results, err := ApiDatabase.GetHeartRates(DatabaseContext, params)
 	if err != nil {
-		ErrorLog.Printf("error getting list of heart rates: %v", err)
+		ErrorLog.Printf("error getting list of heart rate: %v", err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	if len(results) < 1 {
-		ErrorLog.Printf("no heart rate results from database with '%s' token '%s'", queryType, queryToken)
-		handleError(w, http.StatusNotFound, "no results found")
-		return
+	keep, hasNext, hasPrev := trimPage(listQuery.Direction, len(results), listQuery.Limit)
+	results = results[:keep]
+	if listQuery.Direction == PagePrev {
+		slices.Reverse(results)
 	}
 
 	heartrates := HeartRates{
-		Data:      results,
-		NextToken: params.RowLimit + params.RowOffset,
+		Data: results,
+	}
+
+	if len(results) > 0 {
+		if hasNext {
+			last := results[len(results)-1]
+			heartrates.NextToken = encodeCursor(last.Date, last.ID)
+		}
+		if hasPrev {
+			first := results[0]
+			heartrates.PrevToken = encodeCursor(first.Date, first.ID)
+		}
+	}
+
+	setLinkHeader(w, r, heartrates.NextToken, heartrates.PrevToken)
+
+	writeFormatted(w, r, heartrates, results, listQuery.Fields)
+
+}
+
+func newHeartRateInput(heartRate austinapi_db.Heartrate) HeartRateInput {
+	date := heartRate.Date.Format("2006-01-02")
+	return HeartRateInput{
+		Date:    &date,
+		Low:     &heartRate.Low,
+		High:    &heartRate.High,
+		Average: &heartRate.Average,
+	}
+}
+
+func (i HeartRateInput) params() (austinapi_db.SaveHeartRateParams, error) {
+	var params austinapi_db.SaveHeartRateParams
+	var err error
+
+	if params.Date, err = parseInputDate(i.Date); err != nil {
+		return params, err
+	}
+	if params.Low, err = checkRange("low", i.Low, 0, 300); err != nil {
+		return params, err
+	}
+	if params.High, err = checkRange("high", i.High, 0, 300); err != nil {
+		return params, err
+	}
+	if params.Average, err = checkRange("average", i.Average, 0, 300); err != nil {
+		return params, err
+	}
+
+	if params.Low > params.Average || params.Average > params.High {
+		return params, fmt.Errorf("'low' %d, 'average' %d and 'high' %d must be in increasing order", params.Low, params.Average, params.High)
+	}
+
+	return params, nil
+}
+
+// @Summary Save heart rate information
+// @Security ApiKeyAuth
+// @Description Saves heart rate information for a date. If heart rate already exists for the date it is
+// @Description updated and 200 is returned, otherwise it is created and 201 is returned.
+// @Tags heartrate
+// @Accept json
+// @Produce json
+// @Param heartrate body HeartRateInput true "Heart Rate"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Heartrate
+// @Success 201 {object} austinapi_db.Heartrate
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate [post]
+func (h *HeartRateHandler) createHeartRate(w http.ResponseWriter, r *http.Request) {
+	var input HeartRateInput
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding heart rate request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid heart rate request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, inserted, err := ApiQueries.UpsertHeartRate(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error saving heart rate with date '%s': %v", params.Date.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	InfoLog.Printf("saved heart rate with id '%d' inserted '%t'\n", result.ID, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/heartrate/id/%d", result.ID))
+	writeJSON(w, statusCode, result)
+}
+
+// @Summary Replace heart rate information by ID
+// @Security ApiKeyAuth
+// @Description Replaces all fields of the heart rate information with specified ID
+// @Tags heartrate
+// @Accept json
+// @Produce json
+// @Param id path string true "Heart Rate ID"
+// @Param heartrate body HeartRateInput true "Heart Rate"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Heartrate
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/id/{id} [put]
+func (h *HeartRateHandler) replaceHeartRate(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(HeartRateRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	var input HeartRateInput
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding heart rate request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateHeartRate(w, id, input)
+}
+
+// @Summary Update heart rate information by ID
+// @Security ApiKeyAuth
+// @Description Updates only the fields given for the heart rate information with specified ID
+// @Tags heartrate
+// @Accept json
+// @Produce json
+// @Param id path string true "Heart Rate ID"
+// @Param heartrate body HeartRateInput true "Heart Rate fields to change"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Heartrate
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/id/{id} [patch]
+func (h *HeartRateHandler) patchHeartRate(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(HeartRateRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	existing, err := ApiDatabase.GetHeartRate(DatabaseContext, id)
+	if err != nil {
+		ErrorLog.Printf("error retrieving heart rate with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(existing) != 1 {
+		InfoLog.Printf("heart rate with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Heart Rate not found with id %d", id))
+		return
 	}
 
-	jsonBytes, err := json.Marshal(heartrates)
+	// Fields left out of the body keep the values decoded from the stored row
+	input := newHeartRateInput(existing[0])
+	err = decodeBody(r, &input)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error decoding heart rate request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateHeartRate(w, id, input)
+}
+
+func (h *HeartRateHandler) updateHeartRate(w http.ResponseWriter, id int64, input HeartRateInput) {
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid heart rate request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, err := ApiQueries.UpdateHeartRate(DatabaseContext, UpdateHeartRateParams{ID: id, SaveHeartRateParams: params})
+	if isUniqueViolation(err) {
+		InfoLog.Printf("heart rate with date '%s' already exists", params.Date.Format("2006-01-02"))
+		handleError(w, http.StatusConflict, fmt.Sprintf("Heart Rate already exists with date %s", params.Date.Format("2006-01-02")))
+		return
+	}
+
+	if err != nil {
+		ErrorLog.Printf("error updating heart rate with id '%d': %v", id, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	if len(result) != 1 {
+		InfoLog.Printf("heart rate with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Heart Rate not found with id %d", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result[0])
+}
+
+// @Summary Delete heart rate information by ID
+// @Security ApiKeyAuth
+// @Description Deletes the heart rate information with specified ID
+// @Tags heartrate
+// @Param id path string true "Heart Rate ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /heartrate/id/{id} [delete]
+func (h *HeartRateHandler) deleteHeartRate(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(HeartRateRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	result, err := ApiQueries.DeleteHeartRate(DatabaseContext, id)
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v", err)
+		ErrorLog.Printf("error deleting heart rate with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("heart rate with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Heart Rate not found with id %d", id))
+		return
 	}
 
+	InfoLog.Printf("deleted heart rate with id '%d'\n", id)
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeatmapCell and HeatmapGap size each day's square and the space
+	// between squares
+	HeatmapCell = 10
+	HeatmapGap  = 3
+
+	HeatmapLeft = 32
+	HeatmapTop  = 20
+)
+
+// writeHeatmap serves a /heatmap.svg request for one metric, drawing a
+// year of one field as a grid of days with a column for each week.
+func writeHeatmap(w http.ResponseWriter, r *http.Request, metric Metric) {
+	query := r.URL.Query()
+
+	year := time.Now().UTC().Year()
+	if query.Has("year") {
+		var err error
+		year, err = strconv.Atoi(query.Get("year"))
+		if err != nil || year < EarliestDate.Year() || year > LatestDate.Year() {
+			ErrorLog.Printf("invalid heatmap year '%s'", query.Get("year"))
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid year '%s'", query.Get("year")))
+			return
+		}
+	}
+
+	field := BadgeHeadlines[metric.Name].Field
+	if query.Has("field") {
+		field = query.Get("field")
+	}
+	if !metric.HasField(field) {
+		ErrorLog.Printf("invalid %s heatmap field '%s'", metric.Name, field)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid field '%s', expected one of %s", field, strings.Join(metric.Fields, ", ")))
+		return
+	}
+
+	themeName := DefaultChartTheme
+	if query.Has("theme") {
+		themeName = query.Get("theme")
+	}
+	theme, found := ChartThemes[themeName]
+	if !found {
+		ErrorLog.Printf("unknown heatmap theme '%s'", themeName)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Unknown theme '%s', expected light or dark", themeName))
+		return
+	}
+
+	InfoLog.Printf("URL heatmap match '%s' '%d'\n", field, year)
+
+	params := DateRangeParams{
+		StartDate: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	values, err := metric.Values(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s for heatmap: %v", metric.Name, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	title := fmt.Sprintf("%s %s %d", metric.Name, field, year)
+	_, err = w.Write([]byte(renderHeatmap(title, year, field, values[field], theme)))
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+// renderHeatmap draws a year of daily values. Weeks run down from Sunday,
+// and each day is shaded by where its value falls between the year's lowest
+// and highest, so the darkest days are the highest whatever the field.
+func renderHeatmap(title string, year int, field string, values []DailyValue, theme ChartTheme) string {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	days := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	offset := int(first.Weekday())
+	weeks := (days-1+offset)/7 + 1
+
+	step := HeatmapCell + HeatmapGap
+	width := HeatmapLeft + weeks*step + HeatmapGap
+	height := HeatmapTop + 7*step + 24
+
+	byDay := map[int]float64{}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if value.Date.Year() != year {
+			continue
+		}
+		byDay[value.Date.YearDay()-1] = value.Value
+		low = min(low, value.Value)
+		high = max(high, value.Value)
+	}
+
+	levels := len(theme.Scale) - 1
+	level := func(value float64) int {
+		if high == low {
+			return levels
+		}
+		return 1 + min(levels-1, int((value-low)/(high-low)*float64(levels)))
+	}
+
+	format := func(value float64) string {
+		if slices.Contains(ChartHourFields, field) {
+			return formatSeconds(int64(value))
+		}
+		return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" font-family="%s" font-size="10">`,
+		width, height, width, height, ChartFont)
+	fmt.Fprintf(&svg, `<title>%s</title>`, html.EscapeString(title))
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`, theme.Background)
+
+	for month := time.January; month <= time.December; month++ {
+		day := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).YearDay() - 1
+		week := (day + offset) / 7
+		if week >= weeks-1 {
+			continue
+		}
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s">%s</text>`, HeatmapLeft+week*step, HeatmapTop-6, theme.Text, month.String()[:3])
+	}
+
+	for _, weekday := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s">%s</text>`, 2, HeatmapTop+int(weekday)*step+HeatmapCell-1, theme.Text, weekday.String()[:3])
+	}
+
+	for day := 0; day < days; day++ {
+		date := first.AddDate(0, 0, day)
+		x := HeatmapLeft + (day+offset)/7*step
+		y := HeatmapTop + int(date.Weekday())*step
+
+		color, label := theme.Scale[0], "no value"
+		if value, found := byDay[day]; found {
+			color, label = theme.Scale[level(value)], format(value)
+		}
+
+		fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %s</title></rect>`,
+			x, y, HeatmapCell, HeatmapCell, color, date.Format("2006-01-02"), html.EscapeString(label))
+	}
+
+	legendX := width - HeatmapGap - len(theme.Scale)*step - 28
+	legendY := HeatmapTop + 7*step + 8
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s" text-anchor="end">Less</text>`, legendX-4, legendY+HeatmapCell-1, theme.Text)
+	for i, color := range theme.Scale {
+		fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"/>`, legendX+i*step, legendY, HeatmapCell, HeatmapCell, color)
+	}
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" fill="%s">More</text>`, legendX+len(theme.Scale)*step+2, legendY+HeatmapCell-1, theme.Text)
+
+	svg.WriteString(`</svg>`)
+
+	return svg.String()
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Uploads beyond MaxMultipartMemoryBytes are buffered on disk
+	MaxMultipartMemoryBytes = 32 << 20
+	MaxUploadFileBytes      = 256 << 20
+)
+
+var (
+	ImportRgxAppleHealth *regexp.Regexp
+	ImportRgxFit         *regexp.Regexp
+	ImportRgxTakeout     *regexp.Regexp
+	ImportRgxCsv         *regexp.Regexp
+	ImportRgxCsvProfiles *regexp.Regexp
+	ImportRgxCsvProfile  *regexp.Regexp
+
+	// ErrImportFormat wraps errors caused by the file being imported rather
+	// than by saving it
+	ErrImportFormat = errors.New("invalid import file")
+)
+
+type ImportHandler struct{}
+
+// ImportReport summarises an import. Saved counts the days upserted for each
+// metric and Skipped the records that were not understood or fell before the
+// since date.
+type ImportReport struct {
+	Source  string         `json:"source"`
+	Records int            `json:"records"`
+	Skipped int            `json:"skipped"`
+	Saved   map[string]int `json:"saved"`
+}
+
+// ImportedRow is a daily row an import created or updated
+type ImportedRow struct {
+	Metric string `json:"metric"`
+	Date   string `json:"date"`
+}
+
+// SkippedRow is something an import left out and why. Metric and Date are
+// empty when a whole file was skipped.
+type SkippedRow struct {
+	Metric string `json:"metric,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func init() {
+	ImportRgxAppleHealth = regexp.MustCompile(`^/import/apple-health$`)
+	ImportRgxFit = regexp.MustCompile(`^/import/fit$`)
+	ImportRgxTakeout = regexp.MustCompile(`^/import/takeout$`)
+	ImportRgxCsv = regexp.MustCompile(`^/import/csv$`)
+	ImportRgxCsvProfiles = regexp.MustCompile(`^/import/csv/profiles$`)
+	ImportRgxCsvProfile = regexp.MustCompile(`^/import/csv/profiles/([A-Za-z0-9_-]+)$`)
+}
+
+func NewImportReport(source string) ImportReport {
+	return ImportReport{
+		Source: source,
+		Saved:  map[string]int{},
+	}
+}
+
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPost && ImportRgxAppleHealth.MatchString(r.URL.Path):
+		h.importAppleHealth(w, r)
+	case r.Method == http.MethodPost && ImportRgxFit.MatchString(r.URL.Path):
+		h.importFit(w, r)
+	case r.Method == http.MethodPost && ImportRgxTakeout.MatchString(r.URL.Path):
+		h.importTakeout(w, r)
+	case r.Method == http.MethodPost && ImportRgxCsv.MatchString(r.URL.Path):
+		h.importCsv(w, r)
+	case r.Method == http.MethodGet && ImportRgxCsvProfiles.MatchString(r.URL.Path):
+		h.listCsvProfiles(w, r)
+	case r.Method == http.MethodGet && ImportRgxCsvProfile.MatchString(r.URL.Path):
+		h.getCsvProfile(w, r)
+	case r.Method == http.MethodPut && ImportRgxCsvProfile.MatchString(r.URL.Path):
+		h.saveCsvProfile(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func importErrorStatus(err error) int {
+	if errors.Is(err, ErrImportFormat) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// parseSince reads the optional since query parameter that limits an import
+// to days on or after it.
+func parseSince(r *http.Request) (time.Time, error) {
+	value := r.URL.Query().Get("since")
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	since, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'since' must be YYYY-MM-DD, got '%s'", value)
+	}
+
+	return since, nil
+}
+
+// @Summary Import an Apple Health export
+// @Security ApiKeyAuth
+// @Description Streams the export.xml from an Apple Health export, found inside export.zip, and upserts
+// @Description a daily heart rate, spo2 and sleep row for every day it covers. Heart rate samples are rolled
+// @Description up into a low, high and average. Sleep stages are added up for the day the sleep ended on,
+// @Description using the source that recorded the most sleep that day. Apple Health has no sleep score so
+// @Description a day that already has a sleep row keeps its rating, and a new one is saved with 0. The body
+// @Description is read as a stream so exports of several GB are fine.
+// @Tags import
+// @Accept xml
+// @Produce json
+// @Param since query string false "Only import days on or after this date"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} ImportReport
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/apple-health [post]
+func (h *ImportHandler) importAppleHealth(w http.ResponseWriter, r *http.Request) {
+	since, err := parseSince(r)
+	if err != nil {
+		ErrorLog.Printf("error parsing since from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := ImportAppleHealth(r.Context(), r.Body, since)
+	if err != nil {
+		ErrorLog.Printf("error importing apple health export: %v", err)
+		handleError(w, importErrorStatus(err), fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+
+	InfoLog.Printf("imported apple health export: %+v\n", report)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// @Summary Import Garmin FIT files
+// @Security ApiKeyAuth
+// @Description Decodes uploaded Garmin .fit activity, monitoring and sleep files and upserts the daily heart
+// @Description rate, stress, spo2 and sleep rows they cover. Heart rate comes from record and monitoring
+// @Description messages, stress from stress_level, spo2 from spo2 readings and sleep from sleep_level
+// @Description stages. High stress is the time spent at a stress level of 76 or more. Sleep goes to the day
+// @Description it ended. FIT has no sleep score, so a day that already has a sleep row keeps its rating and a
+// @Description new one is saved with 0. Samples from all files in the request are combined before saving, so
+// @Description upload all of a day's monitoring files together. Each file gets a report of the rows it created, updated or was skipped for.
+// @Tags import
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "One or more .fit files"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} FitImport
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/fit [post]
+func (h *ImportHandler) importFit(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(MaxMultipartMemoryBytes)
+	if err != nil {
+		ErrorLog.Printf("error parsing fit upload: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid upload, expected multipart/form-data: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	fileHeaders := r.MultipartForm.File["file"]
+	if len(fileHeaders) == 0 {
+		ErrorLog.Println("fit upload has no files")
+		handleError(w, http.StatusBadRequest, "Upload at least one .fit file in the 'file' field")
+		return
+	}
+
+	var uploads []FitUpload
+	for _, fileHeader := range fileHeaders {
+		data, err := readUpload(fileHeader)
+		if err != nil {
+			ErrorLog.Printf("error reading uploaded file '%s': %v", fileHeader.Filename, err)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Unable to read '%s': %v", fileHeader.Filename, err))
+			return
+		}
+		uploads = append(uploads, FitUpload{Name: fileHeader.Filename, Data: data})
+	}
+
+	fitImport, err := ImportFit(r.Context(), uploads)
+	if err != nil {
+		ErrorLog.Printf("error importing fit files: %v", err)
+		handleError(w, http.StatusInternalServerError, fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+
+	InfoLog.Printf("imported %d fit file(s)\n", len(fitImport.Files))
+	writeJSON(w, http.StatusOK, fitImport)
+}
+
+// @Summary Import a Google Takeout Fitbit archive
+// @Security ApiKeyAuth
+// @Description Reads a Google Takeout zip of Fitbit data and upserts the daily rows it holds, recording
+// @Description the archive file each row came from. Heart rate comes from heart_rate-*.json, sleep from
+// @Description the main sleep in sleep-*.json with its rating from sleep_score.csv, spo2 from the Daily SpO2
+// @Description files and ready score from the Daily Readiness Score files. estimated_oxygen_variation and
+// @Description Stress Score.csv are listed as skipped since neither measures what spo2 and stress store.
+// @Description With dry_run=true nothing is saved and the response shows the changes that would be made,
+// @Description with each changed field's value before and after. Otherwise all rows are saved in one
+// @Description transaction.
+// @Tags import
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Takeout zip"
+// @Param dry_run query bool false "Only report the changes" default(false)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} TakeoutImport
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/takeout [post]
+func (h *ImportHandler) importTakeout(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if r.URL.Query().Has("dry_run") {
+		var err error
+		dryRun, err = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		if err != nil {
+			ErrorLog.Printf("invalid dry_run '%s'", r.URL.Query().Get("dry_run"))
+			handleError(w, http.StatusBadRequest, "Invalid dry_run, expected true or false")
+			return
+		}
+	}
+
+	err := r.ParseMultipartForm(MaxMultipartMemoryBytes)
+	if err != nil {
+		ErrorLog.Printf("error parsing takeout upload: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid upload, expected multipart/form-data: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	fileHeaders := r.MultipartForm.File["file"]
+	if len(fileHeaders) != 1 {
+		ErrorLog.Printf("takeout upload has %d files", len(fileHeaders))
+		handleError(w, http.StatusBadRequest, "Upload a single Takeout zip in the 'file' field")
+		return
+	}
+
+	file, err := fileHeaders[0].Open()
+	if err != nil {
+		ErrorLog.Printf("error opening uploaded file '%s': %v", fileHeaders[0].Filename, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+	defer file.Close()
+
+	archive, err := zip.NewReader(file, fileHeaders[0].Size)
+	if err != nil {
+		ErrorLog.Printf("error reading takeout zip '%s': %v", fileHeaders[0].Filename, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid zip: %v", err))
+		return
+	}
+
+	takeoutImport, err := ImportTakeout(r.Context(), archive, dryRun)
+	if err != nil {
+		ErrorLog.Printf("error importing takeout archive: %v", err)
+		handleError(w, importErrorStatus(err), fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+
+	InfoLog.Printf("imported takeout archive with %d file(s) and %d change(s) dry run '%t'\n", takeoutImport.Files, len(takeoutImport.Changes), dryRun)
+	writeJSON(w, http.StatusOK, takeoutImport)
+}
+
+// @Summary Import a CSV
+// @Security ApiKeyAuth
+// @Description Imports a CSV into one metric using a mapping profile, either saved and named by the profile
+// @Description query parameter or given as JSON in the mapping form field. Every row is checked with the
+// @Description same rules as the write API before anything is saved. If any row is invalid nothing is saved
+// @Description and 400 is returned with every row's error, such as 'row 3, column "Score": not a number',
+// @Description otherwise all rows are upserted by date in one transaction.
+// @Tags import
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV with a header row"
+// @Param mapping formData string false "Mapping profile as JSON, when not using a saved profile"
+// @Param profile query string false "Name of a saved mapping profile"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} CsvImport
+// @Failure 400 {object} CsvImport
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/csv [post]
+func (h *ImportHandler) importCsv(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(MaxMultipartMemoryBytes)
+	if err != nil {
+		ErrorLog.Printf("error parsing csv upload: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid upload, expected multipart/form-data: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var profile CsvProfile
+	name := r.URL.Query().Get("profile")
+	mapping := r.MultipartForm.Value["mapping"]
+
+	switch {
+	case name != "" && len(mapping) > 0:
+		ErrorLog.Println("csv upload has both a profile name and a mapping")
+		handleError(w, http.StatusBadRequest, "Give either the profile query parameter or the mapping form field, not both")
+		return
+	case name != "":
+		profile, err = loadCsvProfile(r.Context(), name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			InfoLog.Printf("csv profile '%s' was not found in database", name)
+			handleError(w, http.StatusNotFound, fmt.Sprintf("Profile not found with name %s", name))
+			return
+		}
+		if err != nil {
+			ErrorLog.Printf("error retrieving csv profile '%s': %v", name, err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+	case len(mapping) == 1:
+		err = decodeJSON(strings.NewReader(mapping[0]), &profile)
+		if err == nil {
+			if profile.Name == "" {
+				profile.Name = "inline"
+			}
+			err = profile.validate()
+		}
+		if err != nil {
+			ErrorLog.Printf("invalid csv mapping: %v", err)
+			handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid mapping: %v", err))
+			return
+		}
+	default:
+		ErrorLog.Println("csv upload has no profile")
+		handleError(w, http.StatusBadRequest, "Give a saved profile name in the profile query parameter or a mapping form field")
+		return
+	}
+
+	fileHeaders := r.MultipartForm.File["file"]
+	if len(fileHeaders) != 1 {
+		ErrorLog.Printf("csv upload has %d files", len(fileHeaders))
+		handleError(w, http.StatusBadRequest, "Upload a single CSV in the 'file' field")
+		return
+	}
+
+	file, err := fileHeaders[0].Open()
+	if err != nil {
+		ErrorLog.Printf("error opening uploaded file '%s': %v", fileHeaders[0].Filename, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+	defer file.Close()
+
+	csvImport, err := ImportCsv(r.Context(), file, profile)
+	if err != nil {
+		ErrorLog.Printf("error importing csv '%s': %v", fileHeaders[0].Filename, err)
+		handleError(w, importErrorStatus(err), fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+
+	if len(csvImport.Errors) > 0 {
+		InfoLog.Printf("csv '%s' has %d invalid row(s), nothing imported\n", fileHeaders[0].Filename, len(csvImport.Errors))
+		writeJSON(w, http.StatusBadRequest, csvImport)
+		return
+	}
+
+	InfoLog.Printf("imported csv '%s' with profile '%s': %d created %d updated\n", fileHeaders[0].Filename, profile.Name, csvImport.Created, csvImport.Updated)
+	writeJSON(w, http.StatusOK, csvImport)
+}
+
+func loadCsvProfile(ctx context.Context, name string) (CsvProfile, error) {
+	var profile CsvProfile
+
+	data, err := ApiQueries.GetCsvProfile(ctx, name)
+	if err != nil {
+		return profile, err
+	}
+
+	err = json.Unmarshal(data, &profile)
+	return profile, err
+}
+
+// @Summary List saved CSV mapping profiles
+// @Security ApiKeyAuth
+// @Description Retrieves every saved CSV mapping profile ordered by name
+// @Tags import
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} CsvProfiles
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/csv/profiles [get]
+func (h *ImportHandler) listCsvProfiles(w http.ResponseWriter, r *http.Request) {
+	results, err := ApiQueries.GetCsvProfiles(r.Context())
+	if err != nil {
+		ErrorLog.Printf("error retrieving csv profiles: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	profiles := CsvProfiles{Data: []CsvProfile{}}
+	for _, data := range results {
+		var profile CsvProfile
+		err = json.Unmarshal(data, &profile)
+		if err != nil {
+			ErrorLog.Printf("error decoding saved csv profile: %v", err)
+			handleError(w, http.StatusInternalServerError, "Internal Error")
+			return
+		}
+		profiles.Data = append(profiles.Data, profile)
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+// @Summary Get a saved CSV mapping profile
+// @Security ApiKeyAuth
+// @Description Retrieves the CSV mapping profile saved with the specified name
+// @Tags import
+// @Produce json
+// @Param name path string true "Profile name"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} CsvProfile
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/csv/profiles/{name} [get]
+func (h *ImportHandler) getCsvProfile(w http.ResponseWriter, r *http.Request) {
+	name := ImportRgxCsvProfile.FindStringSubmatch(r.URL.Path)[1]
+
+	profile, err := loadCsvProfile(r.Context(), name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		InfoLog.Printf("csv profile '%s' was not found in database", name)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Profile not found with name %s", name))
+		return
+	}
+	if err != nil {
+		ErrorLog.Printf("error retrieving csv profile '%s': %v", name, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// @Summary Save a CSV mapping profile
+// @Security ApiKeyAuth
+// @Description Saves a CSV mapping profile under the specified name, replacing any profile already saved
+// @Description with it. columns maps CSV headers to metric fields and one must map to date. date_format
+// @Description uses YYYY, YY, MM, DD, HH, mm and ss, or a Go layout, and defaults to YYYY-MM-DD. Dates are
+// @Description read in timezone, UTC by default. units sets seconds, minutes or hours for total_sleep,
+// @Description deep_sleep, light_sleep, rem_sleep and high_stress_duration. defaults fills in fields no
+// @Description column gives, such as a sleep rating. Returns 201 for a new profile and 200 for a replaced one.
+// @Tags import
+// @Accept json
+// @Produce json
+// @Param name path string true "Profile name"
+// @Param profile body CsvProfile true "Profile"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} CsvProfile
+// @Success 201 {object} CsvProfile
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /import/csv/profiles/{name} [put]
+func (h *ImportHandler) saveCsvProfile(w http.ResponseWriter, r *http.Request) {
+	name := ImportRgxCsvProfile.FindStringSubmatch(r.URL.Path)[1]
+
+	var profile CsvProfile
+	err := decodeBody(r, &profile)
+	if err == nil {
+		if profile.Name == "" {
+			profile.Name = name
+		}
+		if profile.Name != name {
+			err = fmt.Errorf("'name' '%s' does not match the name in the URL", profile.Name)
+		} else {
+			err = profile.validate()
+		}
+	}
+	if err != nil {
+		ErrorLog.Printf("invalid csv profile '%s': %v", name, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid profile: %v", err))
+		return
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		ErrorLog.Printf("error marshaling csv profile: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	inserted, err := ApiQueries.SaveCsvProfile(r.Context(), SaveCsvProfileParams{Name: name, Profile: data})
+	if err != nil {
+		ErrorLog.Printf("error saving csv profile '%s': %v", name, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	InfoLog.Printf("saved csv profile '%s' inserted '%t'\n", name, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+	writeJSON(w, statusCode, profile)
+}
+
+func readUpload(fileHeader *multipart.FileHeader) ([]byte, error) {
+	if fileHeader.Size > MaxUploadFileBytes {
+		return nil, fmt.Errorf("file is larger than %d bytes", MaxUploadFileBytes)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+var ListQueryParameters = []string{PageNext, PagePrev, "limit", "order", "fields", "format"}
+
+// ListQuery holds the options a caller may give on a list endpoint.
+type ListQuery struct {
+	Direction string
+	Token     string
+	Limit     int32
+	Order     string
+	Fields    []string
+}
+
+// FetchesNewer reports whether the page is found by reading rows newer than
+// the cursor, which is the case when moving forward through an ascending
+// list or backward through a descending one.
+func (q ListQuery) FetchesNewer() bool {
+	if q.Order == OrderAsc {
+		return q.Direction == PageNext
+	}
+	return q.Direction == PagePrev
+}
+
+// parseListQuery validates the query string of a list request. Fields named
+// in fields= are checked against the json tags of model.
+func parseListQuery(values url.Values, model any) (ListQuery, error) {
+	listQuery := ListQuery{
+		Limit: ListRowLimit,
+		Order: OrderDesc,
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !slices.Contains(ListQueryParameters, key) {
+			return ListQuery{}, fmt.Errorf("unknown query parameter '%s', expected one of %s", key, strings.Join(ListQueryParameters, ", "))
+		}
+		if len(values[key]) != 1 {
+			return ListQuery{}, fmt.Errorf("query parameter '%s' may only be given once", key)
+		}
+	}
+
+	if values.Has(PageNext) && values.Has(PagePrev) {
+		return ListQuery{}, fmt.Errorf("only one of %s and %s may be given", PageNext, PagePrev)
+	}
+
+	for _, direction := range []string{PageNext, PagePrev} {
+		if values.Has(direction) {
+			listQuery.Direction = direction
+			listQuery.Token = values.Get(direction)
+
+			if listQuery.Token == "" {
+				return ListQuery{}, fmt.Errorf("%s must not be empty", direction)
+			}
+		}
+	}
+
+	if values.Has("limit") {
+		limit, err := strconv.ParseInt(values.Get("limit"), 10, 32)
+		if err != nil || limit < 1 {
+			return ListQuery{}, fmt.Errorf("limit '%s' must be a positive whole number", values.Get("limit"))
+		}
+
+		listQuery.Limit = min(int32(limit), ListRowLimit)
+	}
+
+	if values.Has("order") {
+		order := values.Get("order")
+		if order != OrderAsc && order != OrderDesc {
+			return ListQuery{}, fmt.Errorf("order '%s' must be %s or %s", order, OrderAsc, OrderDesc)
+		}
+
+		listQuery.Order = order
+	}
+
+	if values.Has("fields") {
+		available := jsonFieldNames(model)
+
+		for _, field := range strings.Split(values.Get("fields"), ",") {
+			field = strings.TrimSpace(field)
+			if !slices.Contains(available, field) {
+				return ListQuery{}, fmt.Errorf("unknown field '%s', expected one of %s", field, strings.Join(available, ", "))
+			}
+			if !slices.Contains(listQuery.Fields, field) {
+				listQuery.Fields = append(listQuery.Fields, field)
+			}
+		}
+	}
+
+	return listQuery, nil
+}
+
+// jsonFieldNames returns the json tag names of a struct's fields in order.
+func jsonFieldNames(model any) []string {
+	var names []string
+
+	modelType := reflect.TypeOf(model)
+	for i := 0; i < modelType.NumField(); i++ {
+		name, _, _ := strings.Cut(modelType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// marshalFields marshals a list response, keeping only the given fields of
+// each item in its data array. With no fields the whole item is kept.
+func marshalFields(list any, fields []string) ([]byte, error) {
+	jsonBytes, err := json.Marshal(list)
+	if err != nil || len(fields) == 0 {
+		return jsonBytes, err
+	}
+
+	var response map[string]json.RawMessage
+	err = json.Unmarshal(jsonBytes, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]json.RawMessage
+	err = json.Unmarshal(response["data"], &items)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		for key := range item {
+			if !slices.Contains(fields, key) {
+				delete(item, key)
+			}
+		}
+	}
+
+	response["data"], err = json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(response)
+}
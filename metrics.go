@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DailyValue is the value of one numeric field on one day.
+type DailyValue struct {
+	Date  time.Time
+	Value float64
+}
+
+// Metric ties a metric's name to its numeric fields and a way of loading the
+// daily values of every one of those fields for a date range.
+type Metric struct {
+	Name   string
+	Fields []string
+	Values func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error)
+}
+
+var (
+	SleepMetric = Metric{
+		Name:   "sleep",
+		Fields: numericFields(austinapi_db.Sleep{}),
+		Values: func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error) {
+			results, err := ApiQueries.GetSleepsByDateRange(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return fieldValues(results), nil
+		},
+	}
+
+	ReadyScoreMetric = Metric{
+		Name:   "readyscore",
+		Fields: numericFields(austinapi_db.Readyscore{}),
+		Values: func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error) {
+			results, err := ApiQueries.GetReadyScoresByDateRange(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return fieldValues(results), nil
+		},
+	}
+
+	HeartRateMetric = Metric{
+		Name:   "heartrate",
+		Fields: numericFields(austinapi_db.Heartrate{}),
+		Values: func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error) {
+			results, err := ApiQueries.GetHeartRatesByDateRange(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return fieldValues(results), nil
+		},
+	}
+
+	StressMetric = Metric{
+		Name:   "stress",
+		Fields: numericFields(austinapi_db.Stress{}),
+		Values: func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error) {
+			results, err := ApiQueries.GetStressesByDateRange(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return fieldValues(results), nil
+		},
+	}
+
+	Spo2Metric = Metric{
+		Name:   "spo2",
+		Fields: numericFields(austinapi_db.Spo2{}),
+		Values: func(ctx context.Context, params DateRangeParams) (map[string][]DailyValue, error) {
+			results, err := ApiQueries.GetSpo2sByDateRange(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return fieldValues(results), nil
+		},
+	}
+
+	Metrics = []Metric{SleepMetric, ReadyScoreMetric, HeartRateMetric, StressMetric, Spo2Metric}
+)
+
+func (m Metric) HasField(field string) bool {
+	return slices.Contains(m.Fields, field)
+}
+
+// numericFields returns the json names of a model's numeric fields, leaving
+// out the row id.
+func numericFields(model any) []string {
+	var names []string
+
+	modelType := reflect.TypeOf(model)
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+			if name != "id" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// fieldValues pulls every numeric field out of each row, pairing the values
+// with the row's date and grouping them by the field's json name.
+func fieldValues[T any](rows []T) map[string][]DailyValue {
+	values := map[string][]DailyValue{}
+
+	for _, row := range rows {
+		rowValue := reflect.ValueOf(row)
+		rowType := rowValue.Type()
+		date := rowValue.FieldByName("Date").Interface().(time.Time)
+
+		for i := 0; i < rowType.NumField(); i++ {
+			name, _, _ := strings.Cut(rowType.Field(i).Tag.Get("json"), ",")
+			fieldValue := rowValue.Field(i)
+
+			switch {
+			case name == "id":
+				continue
+			case fieldValue.CanInt():
+				values[name] = append(values[name], DailyValue{Date: date, Value: float64(fieldValue.Int())})
+			case fieldValue.CanFloat():
+				values[name] = append(values[name], DailyValue{Date: date, Value: fieldValue.Float()})
+			}
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+)
+
+const (
+	OmhNamespace  = "omh"
+	OmhSourceName = "austinapi"
+)
+
+var (
+	OmhRgxDate  *regexp.Regexp
+	OmhRgxRange *regexp.Regexp
+
+	// OmhMappings publish metric fields as Open mHealth data points. Heart
+	// rate gives one data point per statistic. Scores and stress have no
+	// Open mHealth schema and are left out.
+	OmhMappings = []OmhMapping{
+		{Metric: HeartRateMetric, Field: "average", Schema: "heart-rate", Version: "2.0", Property: "heart_rate", Unit: "beats/min", Scale: 1, Statistic: "average"},
+		{Metric: HeartRateMetric, Field: "low", Schema: "heart-rate", Version: "2.0", Property: "heart_rate", Unit: "beats/min", Scale: 1, Statistic: "minimum"},
+		{Metric: HeartRateMetric, Field: "high", Schema: "heart-rate", Version: "2.0", Property: "heart_rate", Unit: "beats/min", Scale: 1, Statistic: "maximum"},
+		{Metric: Spo2Metric, Field: "average_spo2", Schema: "oxygen-saturation", Version: "2.0", Property: "oxygen_saturation", Unit: "%", Scale: 1, Statistic: "average"},
+		{Metric: SleepMetric, Field: "total_sleep", Schema: "sleep-duration", Version: "2.0", Property: "sleep_duration", Unit: "min", Scale: 1.0 / 60},
+	}
+)
+
+type OmhHandler struct{}
+
+// OmhMapping ties one metric field to the Open mHealth schema its values are
+// published under. Statistic is the descriptive_statistic of the value, if
+// it summarises more than one reading.
+type OmhMapping struct {
+	Metric    Metric
+	Field     string
+	Schema    string
+	Version   string
+	Property  string
+	Unit      string
+	Scale     float64
+	Statistic string
+}
+
+type OmhSchemaId struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+}
+
+type OmhAcquisitionProvenance struct {
+	SourceName string `json:"source_name"`
+	Modality   string `json:"modality"`
+}
+
+type OmhHeader struct {
+	Id                    string                   `json:"id"`
+	CreationDateTime      string                   `json:"creation_date_time"`
+	SchemaId              OmhSchemaId              `json:"schema_id"`
+	AcquisitionProvenance OmhAcquisitionProvenance `json:"acquisition_provenance"`
+}
+
+type OmhUnitValue struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type OmhTimeInterval struct {
+	StartDateTime string `json:"start_date_time"`
+	EndDateTime   string `json:"end_date_time"`
+}
+
+type OmhTimeFrame struct {
+	TimeInterval OmhTimeInterval `json:"time_interval"`
+}
+
+// OmhDataPoint is an Open mHealth data point. Body follows the schema named
+// in the header, such as omh:heart-rate:2.0, with the measure first, then
+// effective_time_frame and descriptive_statistic.
+type OmhDataPoint struct {
+	Header OmhHeader  `json:"header"`
+	Body   jsonObject `json:"body" swaggertype:"object"`
+}
+
+type OmhDataPoints struct {
+	Data []OmhDataPoint `json:"data"`
+}
+
+func init() {
+	OmhRgxDate = regexp.MustCompile(`^/omh/([a-z0-9]+)/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	OmhRgxRange = regexp.MustCompile(`^/omh/([a-z0-9]+)/range$`)
+}
+
+func (h *OmhHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && OmhRgxDate.MatchString(r.URL.Path):
+		h.getOmhByDate(w, r)
+	case r.Method == http.MethodGet && OmhRgxRange.MatchString(r.URL.Path):
+		h.getOmhByDateRange(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Get Open mHealth data points by date
+// @Security ApiKeyAuth
+// @Description Retrieves a metric's values on the specified date as Open mHealth data points.
+// @Description heartrate gives omh:heart-rate with average, minimum and maximum descriptive statistics,
+// @Description spo2 gives omh:oxygen-saturation and sleep gives omh:sleep-duration in minutes.
+// @Tags omh
+// @Produce json
+// @Param metric path string true "Metric" Enums(heartrate, spo2, sleep)
+// @Param date path string true "Date"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} OmhDataPoints
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /omh/{metric}/date/{date} [get]
+func (h *OmhHandler) getOmhByDate(w http.ResponseWriter, r *http.Request) {
+	matches := OmhRgxDate.FindStringSubmatch(r.URL.Path)
+
+	metric, found := omhMetric(matches[1])
+	if !found {
+		InfoLog.Printf("metric '%s' has no open mhealth schema", matches[1])
+		handleError(w, http.StatusNotFound, fmt.Sprintf("No Open mHealth schema for %s", matches[1]))
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", matches[2])
+	if err != nil {
+		ErrorLog.Printf("Unable to parse '%s' to time.Time object: %v", matches[2], err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date %s", matches[2]))
+		return
+	}
+
+	values, err := metric.Values(DatabaseContext, DateRangeParams{StartDate: date, EndDate: date})
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s with date '%s': %v", metric.Name, matches[2], err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	dataPoints := omhDataPoints(values)
+	if len(dataPoints) == 0 {
+		InfoLog.Printf("%s with date '%s' was not found in database", metric.Name, matches[2])
+		handleError(w, http.StatusNotFound, fmt.Sprintf("%s not found with date %s", metric.Name, matches[2]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, OmhDataPoints{Data: dataPoints})
+}
+
+// @Summary Get Open mHealth data points for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves a metric's values between start and end (inclusive) as Open mHealth data points
+// @Description ordered by date. start and end accept a date (2024-02-19), an ISO week (2024-W08) or a
+// @Description month (2024-02). If end is omitted the range covers only the period given by start.
+// @Tags omh
+// @Produce json
+// @Param metric path string true "Metric" Enums(heartrate, spo2, sleep)
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} OmhDataPoints
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /omh/{metric}/range [get]
+func (h *OmhHandler) getOmhByDateRange(w http.ResponseWriter, r *http.Request) {
+	name := OmhRgxRange.FindStringSubmatch(r.URL.Path)[1]
+
+	metric, found := omhMetric(name)
+	if !found {
+		InfoLog.Printf("metric '%s' has no open mhealth schema", name)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("No Open mHealth schema for %s", name))
+		return
+	}
+
+	query := r.URL.Query()
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	values, err := metric.Values(DatabaseContext, DateRangeParams{StartDate: startDate, EndDate: endDate})
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s between '%s' and '%s': %v", metric.Name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, OmhDataPoints{Data: omhDataPoints(values)})
+}
+
+func omhMetric(name string) (Metric, bool) {
+	metric, found := metricByName(name)
+	if !found {
+		return metric, false
+	}
+
+	return metric, slices.ContainsFunc(OmhMappings, func(mapping OmhMapping) bool {
+		return mapping.Metric.Name == metric.Name
+	})
+}
+
+// omhDataPoints turns daily values, grouped by field as Metric.Values
+// returns them, into data points ordered by date and then by OmhMappings.
+func omhDataPoints(values map[string][]DailyValue) []OmhDataPoint {
+	type datedDataPoint struct {
+		date      time.Time
+		dataPoint OmhDataPoint
+	}
+
+	var dated []datedDataPoint
+	for _, mapping := range OmhMappings {
+		for _, value := range values[mapping.Field] {
+			dated = append(dated, datedDataPoint{date: value.Date, dataPoint: mapping.dataPoint(value)})
+		}
+	}
+
+	slices.SortStableFunc(dated, func(a datedDataPoint, b datedDataPoint) int {
+		return a.date.Compare(b.date)
+	})
+
+	dataPoints := []OmhDataPoint{}
+	for _, d := range dated {
+		dataPoints = append(dataPoints, d.dataPoint)
+	}
+
+	return dataPoints
+}
+
+func (m OmhMapping) dataPoint(value DailyValue) OmhDataPoint {
+	day := value.Date.Format("2006-01-02")
+
+	id := fmt.Sprintf("%s-%s", m.Schema, day)
+	if m.Statistic != "" {
+		id = fmt.Sprintf("%s-%s-%s", m.Schema, m.Statistic, day)
+	}
+
+	body := jsonObject{
+		Keys: []string{m.Property, "effective_time_frame"},
+		Values: map[string]any{
+			m.Property: OmhUnitValue{Value: math.Round(value.Value*m.Scale*100) / 100, Unit: m.Unit},
+			"effective_time_frame": OmhTimeFrame{TimeInterval: OmhTimeInterval{
+				StartDateTime: value.Date.Format(time.RFC3339),
+				EndDateTime:   value.Date.AddDate(0, 0, 1).Format(time.RFC3339),
+			}},
+		},
+	}
+	if m.Statistic != "" {
+		body.Keys = append(body.Keys, "descriptive_statistic")
+		body.Values["descriptive_statistic"] = m.Statistic
+	}
+
+	return OmhDataPoint{
+		Header: OmhHeader{
+			Id:                    id,
+			CreationDateTime:      time.Now().UTC().Format(time.RFC3339),
+			SchemaId:              OmhSchemaId{Namespace: OmhNamespace, Name: m.Schema, Version: m.Version},
+			AcquisitionProvenance: OmhAcquisitionProvenance{SourceName: OmhSourceName, Modality: "sensed"},
+		},
+		Body: body,
+	}
+}
+
+// omhRowValues reads the date and numeric fields of a row already decoded
+// from its JSON, for content negotiation where rows come in many types.
+func omhRowValues(object jsonObject, columns []string) (map[string][]DailyValue, error) {
+	values := map[string][]DailyValue{}
+
+	dateValue, _ := object.Values["date"].(string)
+	date, err := time.Parse(time.RFC3339, dateValue)
+	if err != nil {
+		return nil, fmt.Errorf("row has no date: %w", err)
+	}
+
+	for _, column := range columns {
+		number, ok := object.Values[column].(json.Number)
+		if !ok {
+			continue
+		}
+		value, err := number.Float64()
+		if err != nil {
+			return nil, err
+		}
+		values[column] = append(values[column], DailyValue{Date: date, Value: value})
+	}
+
+	return values, nil
+}
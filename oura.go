@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultOuraBaseUrl = "https://api.ouraring.com"
+
+	OuraMaxAttempts    = 5
+	OuraInitialBackoff = time.Second
+	OuraMaxBackoff     = time.Minute
+	OuraRequestTimeout = 30 * time.Second
+
+	// OuraHeartRateMaxDays is the longest range the heartrate endpoint
+	// accepts in one request
+	OuraHeartRateMaxDays = 30
+)
+
+// OuraClient reads the Oura Cloud API v2 with a personal access token.
+// Requests that fail with a network error, 429 or 5xx are retried with
+// exponential backoff, honoring Retry-After when Oura sends it.
+type OuraClient struct {
+	BaseUrl        string
+	AccessToken    string
+	HttpClient     *http.Client
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// ouraPage is the envelope every v2 usercollection endpoint returns
+type ouraPage[T any] struct {
+	Data      []T     `json:"data"`
+	NextToken *string `json:"next_token"`
+}
+
+type OuraDailySleep struct {
+	Day   string `json:"day"`
+	Score *int64 `json:"score"`
+}
+
+// OuraSleep is one sleep period. Durations are in seconds. BedtimeEnd is in
+// the user's local offset, unlike heart rate timestamps which are in UTC.
+type OuraSleep struct {
+	Day                string    `json:"day"`
+	Type               string    `json:"type"`
+	BedtimeEnd         time.Time `json:"bedtime_end"`
+	TotalSleepDuration *int      `json:"total_sleep_duration"`
+	DeepSleepDuration  *int      `json:"deep_sleep_duration"`
+	LightSleepDuration *int      `json:"light_sleep_duration"`
+	RemSleepDuration   *int      `json:"rem_sleep_duration"`
+}
+
+type OuraDailyReadiness struct {
+	Day   string `json:"day"`
+	Score *int   `json:"score"`
+}
+
+type OuraHeartRate struct {
+	Bpm       int       `json:"bpm"`
+	Source    string    `json:"source"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// OuraDailyStress holds the seconds spent in high stress on a day
+type OuraDailyStress struct {
+	Day        string `json:"day"`
+	StressHigh *int64 `json:"stress_high"`
+}
+
+type OuraDailySpo2 struct {
+	Day            string `json:"day"`
+	Spo2Percentage *struct {
+		Average *float64 `json:"average"`
+	} `json:"spo2_percentage"`
+}
+
+func NewOuraClient(baseUrl string, accessToken string) *OuraClient {
+	return &OuraClient{
+		BaseUrl:        strings.TrimSuffix(baseUrl, "/"),
+		AccessToken:    accessToken,
+		HttpClient:     &http.Client{Timeout: OuraRequestTimeout},
+		MaxAttempts:    OuraMaxAttempts,
+		InitialBackoff: OuraInitialBackoff,
+		MaxBackoff:     OuraMaxBackoff,
+	}
+}
+
+// ouraList fetches every page of a usercollection endpoint.
+func ouraList[T any](ctx context.Context, client *OuraClient, path string, query url.Values) ([]T, error) {
+	var items []T
+
+	for {
+		var page ouraPage[T]
+		err := client.get(ctx, path, query, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Data...)
+
+		if page.NextToken == nil || *page.NextToken == "" {
+			return items, nil
+		}
+
+		query.Set("next_token", *page.NextToken)
+	}
+}
+
+// ouraDateQuery builds the query for the daily endpoints, which treat
+// end_date as exclusive.
+func ouraDateQuery(start time.Time, end time.Time) url.Values {
+	query := url.Values{}
+	query.Set("start_date", start.Format("2006-01-02"))
+	query.Set("end_date", end.AddDate(0, 0, 1).Format("2006-01-02"))
+	return query
+}
+
+func (c *OuraClient) get(ctx context.Context, path string, query url.Values, out any) error {
+	endpoint := fmt.Sprintf("%s%s?%s", c.BaseUrl, path, query.Encode())
+	backoff := c.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		retry, wait, err := c.fetch(ctx, endpoint, out)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= c.MaxAttempts {
+			return fmt.Errorf("oura request '%s' failed after %d attempt(s): %w", path, attempt, err)
+		}
+
+		if wait <= 0 {
+			wait = backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+			backoff = min(backoff*2, c.MaxBackoff)
+		}
+		wait = min(wait, c.MaxBackoff)
+
+		InfoLog.Printf("retrying oura request '%s' in %s after attempt %d: %v\n", path, wait, attempt, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fetch makes a single request, reporting whether a failure is worth retrying
+// and how long Oura asked to wait before doing so.
+func (c *OuraClient) fetch(ctx context.Context, endpoint string, out any) (bool, time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.HttpClient.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+		return true, parseRetryAfter(response.Header.Get("Retry-After")), fmt.Errorf("oura returned status %d", response.StatusCode)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return false, 0, fmt.Errorf("oura returned status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	err = json.NewDecoder(response.Body).Decode(out)
+	if err != nil {
+		return false, 0, fmt.Errorf("error decoding oura response: %w", err)
+	}
+
+	return false, 0, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date, returning zero when there is none.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
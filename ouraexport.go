@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"time"
+)
+
+const (
+	OuraExportSource = "oura_export"
+
+	// OuraBackfillCheckpointEvery is how many saved days go by between
+	// checkpoint writes
+	OuraBackfillCheckpointEvery = 100
+)
+
+// ouraExportSleep reads both the sleep periods of the current export, shaped
+// like the v2 sleep documents, and the older export's sleep summaries, which
+// carry a summary_date and the score on the same record.
+type ouraExportSleep struct {
+	OuraSleep
+	SummaryDate string `json:"summary_date"`
+	IsLongest   *int   `json:"is_longest"`
+	Score       *int64 `json:"score"`
+	Total       *int   `json:"total"`
+	Deep        *int   `json:"deep"`
+	Light       *int   `json:"light"`
+	Rem         *int   `json:"rem"`
+}
+
+// ouraExportReadiness reads daily_readiness and the older readiness summaries
+type ouraExportReadiness struct {
+	OuraDailyReadiness
+	SummaryDate string `json:"summary_date"`
+}
+
+// OuraBackfillCheckpoint records how far a backfill got. Saved is the last
+// day saved for each metric; days are saved in order, so a resumed run skips
+// everything up to it. File and Size tie the checkpoint to one export.
+type OuraBackfillCheckpoint struct {
+	File  string            `json:"file"`
+	Size  int64             `json:"size"`
+	Saved map[string]string `json:"saved"`
+}
+
+// ouraExportDays accumulates the export into one entry per day, the same way
+// the sync combines daily_sleep with sleep periods.
+type ouraExportDays struct {
+	sleeps     map[string]*austinapi_db.SaveSleepParams
+	ratings    map[string]int64
+	readiness  map[string]int
+	heartRates map[string]*heartRateRollup
+	stresses   map[string]int64
+	spo2s      map[string]float64
+}
+
+// BackfillOuraExport loads every day of the JSON data export downloaded from
+// the Oura web app. Each section of the export is an array keyed by its
+// collection name (daily_sleep, sleep, daily_readiness, heartrate,
+// daily_stress, daily_spo2), and the older export's sleep and readiness
+// summaries are read too. Days are upserted by date, so running it again is
+// harmless, and progress is written to checkpointPath so an interrupted run
+// resumes where it stopped. The checkpoint is removed once every day is in.
+func BackfillOuraExport(ctx context.Context, exportPath string, checkpointPath string, restart bool) (ImportReport, error) {
+	report := NewImportReport(OuraExportSource)
+
+	file, err := os.Open(exportPath)
+	if err != nil {
+		return report, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return report, err
+	}
+
+	checkpoint := OuraBackfillCheckpoint{File: info.Name(), Size: info.Size(), Saved: map[string]string{}}
+	if !restart {
+		saved, err := readOuraBackfillCheckpoint(checkpointPath)
+		if err != nil {
+			return report, err
+		}
+		if saved != nil {
+			if saved.File != checkpoint.File || saved.Size != checkpoint.Size {
+				return report, fmt.Errorf("checkpoint '%s' is for '%s' (%d bytes), not this export; use -restart to start over", checkpointPath, saved.File, saved.Size)
+			}
+			checkpoint.Saved = saved.Saved
+			InfoLog.Printf("resuming oura export backfill from checkpoint %v\n", checkpoint.Saved)
+		}
+	}
+
+	days := ouraExportDays{
+		sleeps:     map[string]*austinapi_db.SaveSleepParams{},
+		ratings:    map[string]int64{},
+		readiness:  map[string]int{},
+		heartRates: map[string]*heartRateRollup{},
+		stresses:   map[string]int64{},
+		spo2s:      map[string]float64{},
+	}
+
+	err = days.read(ctx, file, &report)
+	if err != nil {
+		return report, err
+	}
+
+	err = days.save(ctx, &report, &checkpoint, checkpointPath)
+	if err != nil {
+		if checkpointErr := writeOuraBackfillCheckpoint(checkpointPath, checkpoint); checkpointErr != nil {
+			ErrorLog.Printf("error writing checkpoint '%s': %v", checkpointPath, checkpointErr)
+		}
+		return report, err
+	}
+
+	err = os.Remove(checkpointPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// read walks the export's top level object, decoding the arrays it knows one
+// element at a time so the whole file is never held in memory.
+func (d ouraExportDays) read(ctx context.Context, reader io.Reader, report *ImportReport) error {
+	decoder := json.NewDecoder(reader)
+
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return fmt.Errorf("%w: export must be a JSON object", ErrImportFormat)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("%w: error reading export: %v", ErrImportFormat, err)
+		}
+		section, _ := token.(string)
+
+		var add func(decoder *json.Decoder) (bool, error)
+		switch section {
+		case "daily_sleep":
+			add = d.addDailySleep
+		case "sleep":
+			add = d.addSleep
+		case "daily_readiness", "readiness":
+			add = d.addReadiness
+		case "heartrate", "heart_rate":
+			add = d.addHeartRate
+		case "daily_stress":
+			add = d.addStress
+		case "daily_spo2":
+			add = d.addSpo2
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+			if err != nil {
+				return fmt.Errorf("%w: error reading export section '%s': %v", ErrImportFormat, section, err)
+			}
+			InfoLog.Printf("skipped oura export section '%s'\n", section)
+			continue
+		}
+
+		token, err = decoder.Token()
+		if err != nil || token != json.Delim('[') {
+			return fmt.Errorf("%w: export section '%s' must be an array", ErrImportFormat, section)
+		}
+
+		records := 0
+		for decoder.More() {
+			ok, err := add(decoder)
+			if err != nil {
+				return fmt.Errorf("%w: error reading export section '%s' record %d: %v", ErrImportFormat, section, records+1, err)
+			}
+
+			records++
+			report.Records++
+			if !ok {
+				report.Skipped++
+			}
+
+			if report.Records%100000 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+
+		_, err = decoder.Token()
+		if err != nil {
+			return fmt.Errorf("%w: error reading export section '%s': %v", ErrImportFormat, section, err)
+		}
+
+		InfoLog.Printf("read %d record(s) from oura export section '%s'\n", records, section)
+	}
+
+	return nil
+}
+
+func (d ouraExportDays) addDailySleep(decoder *json.Decoder) (bool, error) {
+	var dailySleep OuraDailySleep
+	err := decoder.Decode(&dailySleep)
+	if err != nil {
+		return false, err
+	}
+
+	if dailySleep.Score == nil {
+		return false, nil
+	}
+	if _, err = parseOuraDay(dailySleep.Day); err != nil {
+		return false, nil
+	}
+
+	d.ratings[dailySleep.Day] = *dailySleep.Score
+	return true, nil
+}
+
+// addSleep adds a sleep period's durations to its day. Naps and rest periods
+// are left out, as in the sync, and so are the older export's summaries of
+// anything but the longest sleep of the night.
+func (d ouraExportDays) addSleep(decoder *json.Decoder) (bool, error) {
+	var sleep ouraExportSleep
+	err := decoder.Decode(&sleep)
+	if err != nil {
+		return false, err
+	}
+
+	day := sleep.Day
+	total, deep, light, rem := sleep.TotalSleepDuration, sleep.DeepSleepDuration, sleep.LightSleepDuration, sleep.RemSleepDuration
+
+	if sleep.SummaryDate != "" {
+		if sleep.IsLongest != nil && *sleep.IsLongest == 0 {
+			return false, nil
+		}
+
+		// The older export dates a night by the day it started, the current
+		// one by the day it ended
+		date, err := parseOuraDay(sleep.SummaryDate)
+		if err != nil {
+			return false, nil
+		}
+		day = date.AddDate(0, 0, 1).Format("2006-01-02")
+		total, deep, light, rem = sleep.Total, sleep.Deep, sleep.Light, sleep.Rem
+
+		if sleep.Score != nil {
+			d.ratings[day] = *sleep.Score
+		}
+	} else if sleep.Type != "long_sleep" && sleep.Type != "sleep" {
+		return false, nil
+	}
+
+	if _, err = parseOuraDay(day); err != nil {
+		return false, nil
+	}
+
+	params, found := d.sleeps[day]
+	if !found {
+		params = &austinapi_db.SaveSleepParams{}
+		d.sleeps[day] = params
+	}
+
+	params.TotalSleep += valueOrZero(total)
+	params.DeepSleep += valueOrZero(deep)
+	params.LightSleep += valueOrZero(light)
+	params.RemSleep += valueOrZero(rem)
+
+	return true, nil
+}
+
+func (d ouraExportDays) addReadiness(decoder *json.Decoder) (bool, error) {
+	var readiness ouraExportReadiness
+	err := decoder.Decode(&readiness)
+	if err != nil {
+		return false, err
+	}
+
+	day := readiness.Day
+	if day == "" {
+		day = readiness.SummaryDate
+	}
+
+	if readiness.Score == nil {
+		return false, nil
+	}
+	if _, err = parseOuraDay(day); err != nil {
+		return false, nil
+	}
+
+	d.readiness[day] = *readiness.Score
+	return true, nil
+}
+
+func (d ouraExportDays) addHeartRate(decoder *json.Decoder) (bool, error) {
+	var sample OuraHeartRate
+	err := decoder.Decode(&sample)
+	if err != nil {
+		return false, err
+	}
+
+	if sample.Timestamp.IsZero() || sample.Bpm <= 0 {
+		return false, nil
+	}
+
+	day := sample.Timestamp.Format("2006-01-02")
+	value := float64(sample.Bpm)
+
+	heartRate, found := d.heartRates[day]
+	if !found {
+		heartRate = &heartRateRollup{low: value, high: value}
+		d.heartRates[day] = heartRate
+	}
+
+	heartRate.low = math.Min(heartRate.low, value)
+	heartRate.high = math.Max(heartRate.high, value)
+	heartRate.sum += value
+	heartRate.count++
+
+	return true, nil
+}
+
+func (d ouraExportDays) addStress(decoder *json.Decoder) (bool, error) {
+	var stress OuraDailyStress
+	err := decoder.Decode(&stress)
+	if err != nil {
+		return false, err
+	}
+
+	if stress.StressHigh == nil {
+		return false, nil
+	}
+	if _, err = parseOuraDay(stress.Day); err != nil {
+		return false, nil
+	}
+
+	d.stresses[stress.Day] = *stress.StressHigh
+	return true, nil
+}
+
+func (d ouraExportDays) addSpo2(decoder *json.Decoder) (bool, error) {
+	var spo2 OuraDailySpo2
+	err := decoder.Decode(&spo2)
+	if err != nil {
+		return false, err
+	}
+
+	if spo2.Spo2Percentage == nil || spo2.Spo2Percentage.Average == nil {
+		return false, nil
+	}
+	if _, err = parseOuraDay(spo2.Day); err != nil {
+		return false, nil
+	}
+
+	d.spo2s[spo2.Day] = *spo2.Spo2Percentage.Average
+	return true, nil
+}
+
+// save upserts each metric's days in date order, skipping those the
+// checkpoint says are already in, and reports progress as it goes.
+func (d ouraExportDays) save(ctx context.Context, report *ImportReport, checkpoint *OuraBackfillCheckpoint, checkpointPath string) error {
+	// Sleep is only saved for nights with a rating, as in the sync
+	var sleepDays []string
+	for _, day := range sortedKeys(d.sleeps) {
+		if _, found := d.ratings[day]; found {
+			sleepDays = append(sleepDays, day)
+		}
+	}
+
+	metrics := []struct {
+		metric Metric
+		days   []string
+		save   func(day string, date time.Time) error
+	}{
+		{SleepMetric, sleepDays, func(day string, date time.Time) error {
+			params := *d.sleeps[day]
+			params.Date = date
+			params.Rating = d.ratings[day]
+			_, _, err := ApiQueries.UpsertSleep(ctx, params)
+			return err
+		}},
+		{ReadyScoreMetric, sortedKeys(d.readiness), func(day string, date time.Time) error {
+			_, _, err := ApiQueries.UpsertReadyScore(ctx, austinapi_db.SaveReadyScoreParams{Date: date, Score: d.readiness[day]})
+			return err
+		}},
+		{HeartRateMetric, sortedKeys(d.heartRates), func(day string, date time.Time) error {
+			heartRate := d.heartRates[day]
+			_, _, err := ApiQueries.UpsertHeartRate(ctx, austinapi_db.SaveHeartRateParams{
+				Date:    date,
+				Low:     int(math.Round(heartRate.low)),
+				High:    int(math.Round(heartRate.high)),
+				Average: int(math.Round(heartRate.sum / float64(heartRate.count))),
+			})
+			return err
+		}},
+		{StressMetric, sortedKeys(d.stresses), func(day string, date time.Time) error {
+			_, _, err := ApiQueries.UpsertStress(ctx, austinapi_db.SaveStressParams{Date: date, HighStressDuration: d.stresses[day]})
+			return err
+		}},
+		{Spo2Metric, sortedKeys(d.spo2s), func(day string, date time.Time) error {
+			_, _, err := ApiQueries.UpsertSpo2(ctx, austinapi_db.SaveSpo2Params{Date: date, AverageSpo2: d.spo2s[day]})
+			return err
+		}},
+	}
+
+	sinceCheckpoint := 0
+	for _, m := range metrics {
+		done := checkpoint.Saved[m.metric.Name]
+
+		for i, day := range m.days {
+			if day <= done {
+				continue
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			date, _ := time.Parse("2006-01-02", day)
+			err := m.save(day, date)
+			if err != nil {
+				return fmt.Errorf("error saving %s with date '%s': %w", m.metric.Name, day, err)
+			}
+
+			report.Saved[m.metric.Name]++
+			checkpoint.Saved[m.metric.Name] = day
+
+			sinceCheckpoint++
+			if sinceCheckpoint == OuraBackfillCheckpointEvery {
+				sinceCheckpoint = 0
+				err = writeOuraBackfillCheckpoint(checkpointPath, *checkpoint)
+				if err != nil {
+					return fmt.Errorf("error writing checkpoint '%s': %w", checkpointPath, err)
+				}
+				InfoLog.Printf("%s: %d/%d days (through %s)\n", m.metric.Name, i+1, len(m.days), day)
+			}
+		}
+
+		InfoLog.Printf("%s: %d/%d days done\n", m.metric.Name, len(m.days), len(m.days))
+	}
+
+	return nil
+}
+
+// readOuraBackfillCheckpoint returns nil when there is no checkpoint.
+func readOuraBackfillCheckpoint(path string) (*OuraBackfillCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint OuraBackfillCheckpoint
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint '%s' is not valid, remove it or use -restart: %w", path, err)
+	}
+	if checkpoint.Saved == nil {
+		checkpoint.Saved = map[string]string{}
+	}
+
+	return &checkpoint, nil
+}
+
+// writeOuraBackfillCheckpoint replaces the checkpoint through a rename so a
+// crash part way through a write never leaves it truncated.
+func writeOuraBackfillCheckpoint(path string, checkpoint OuraBackfillCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".tmp", data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
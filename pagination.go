@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	PageFirst = ""
+	PageNext  = "next_token"
+	PagePrev  = "prev_token"
+)
+
+// Cursor is the position of a row in a list ordered by (date, id)
+// descending. It is handed to clients as an opaque, signed token so paging
+// is stable while new days are being added.
+type Cursor struct {
+	Date time.Time
+	ID   int64
+}
+
+type cursorPayload struct {
+	Date string `json:"d"`
+	ID   int64  `json:"i"`
+}
+
+func encodeCursor(date time.Time, id int64) string {
+	payload, _ := json.Marshal(cursorPayload{Date: date.Format("2006-01-02"), ID: id})
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	encodedSignature := base64.RawURLEncoding.EncodeToString(signCursor(encodedPayload))
+
+	return encodedPayload + "." + encodedSignature
+}
+
+func decodeCursor(token string) (Cursor, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return Cursor{}, fmt.Errorf("cursor '%s' is not in the expected format", token)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("unable to decode cursor signature: %v", err)
+	}
+
+	if !hmac.Equal(signature, signCursor(encodedPayload)) {
+		return Cursor{}, fmt.Errorf("cursor '%s' has an invalid signature", token)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("unable to decode cursor payload: %v", err)
+	}
+
+	var decoded cursorPayload
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("unable to unmarshal cursor payload: %v", err)
+	}
+
+	date, err := time.Parse("2006-01-02", decoded.Date)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("unable to parse cursor date '%s': %v", decoded.Date, err)
+	}
+
+	return Cursor{Date: date, ID: decoded.ID}, nil
+}
+
+func signCursor(encodedPayload string) []byte {
+	mac := hmac.New(sha256.New, CursorSecretKey)
+	mac.Write([]byte(encodedPayload))
+	return mac.Sum(nil)
+}
+
+// trimPage takes the number of rows returned when asking the database for
+// one row more than the page limit and works out how many rows belong on
+// the page and whether there are pages either side of it.
+func trimPage(direction string, rowCount int, limit int32) (int, bool, bool) {
+	keep := min(rowCount, int(limit))
+	overflow := rowCount > int(limit)
+
+	switch direction {
+	case PageNext:
+		return keep, overflow, true
+	case PagePrev:
+		return keep, true, overflow
+	default:
+		return keep, overflow, false
+	}
+}
+
+// setLinkHeader adds an RFC 8288 Link header pointing at the first, next and
+// previous pages of a list. Any other query parameters such as limit and
+// order are carried over to each link.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, nextToken string, prevToken string) {
+	pageUrl := func(direction string, token string) string {
+		values := r.URL.Query()
+		values.Del(PageNext)
+		values.Del(PagePrev)
+
+		if direction != "" {
+			values.Set(direction, token)
+		}
+
+		if len(values) == 0 {
+			return r.URL.Path
+		}
+
+		return fmt.Sprintf("%s?%s", r.URL.Path, values.Encode())
+	}
+
+	links := []string{
+		fmt.Sprintf(`<%s>; rel="first"`, pageUrl("", "")),
+	}
+
+	if nextToken != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageUrl(PageNext, nextToken)))
+	}
+
+	if prevToken != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageUrl(PagePrev, prevToken)))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Parquet is written here with only what our models need: one required
+// column per struct field, PLAIN encoded and uncompressed, one data page
+// per column in each row group, and the footer in Thrift's compact protocol.
+// Row groups are written as they are added so a long export streams.
+
+const (
+	ParquetMagic = "PAR1"
+
+	// ParquetRowGroupRows is the most rows buffered before a row group is
+	// written out
+	ParquetRowGroupRows = 100000
+
+	parquetTypeInt32  = 1
+	parquetTypeInt64  = 2
+	parquetTypeDouble = 5
+
+	parquetRepetitionRequired = 0
+
+	parquetConvertedDate            = 6
+	parquetConvertedTimestampMicros = 10
+
+	parquetEncodingPlain = 0
+	parquetEncodingRle   = 3
+
+	parquetCodecUncompressed = 0
+	parquetPageData          = 0
+
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+type parquetColumn struct {
+	name          string
+	field         int
+	physicalType  int32
+	convertedType int32
+	// value appends the PLAIN encoding of one field value
+	value func(data []byte, value reflect.Value) []byte
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	rows    int64
+	size    int64
+	columns []parquetColumnChunk
+}
+
+// ParquetWriter writes rows of one struct type as a Parquet file. Call
+// Write with slices of the model and then Close to write the footer.
+type ParquetWriter struct {
+	writer    io.Writer
+	offset    int64
+	modelType reflect.Type
+	columns   []parquetColumn
+	pending   []reflect.Value
+	rowGroups []parquetRowGroup
+}
+
+// NewParquetWriter starts a Parquet file of model's type. The Date field
+// becomes a DATE column, other times TIMESTAMP_MICROS columns in UTC, ints
+// INT64 columns and floats DOUBLE columns, each named by its json tag.
+func NewParquetWriter(writer io.Writer, model any) (*ParquetWriter, error) {
+	p := &ParquetWriter{writer: writer, modelType: reflect.TypeOf(model)}
+
+	for i := 0; i < p.modelType.NumField(); i++ {
+		field := p.modelType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		column := parquetColumn{name: name, field: i, convertedType: -1}
+
+		switch {
+		case field.Type == reflect.TypeOf(time.Time{}) && field.Name == "Date":
+			column.physicalType = parquetTypeInt32
+			column.convertedType = parquetConvertedDate
+			column.value = func(data []byte, value reflect.Value) []byte {
+				date := value.Interface().(time.Time)
+				days := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+				return binary.LittleEndian.AppendUint32(data, uint32(int32(days)))
+			}
+		case field.Type == reflect.TypeOf(time.Time{}):
+			column.physicalType = parquetTypeInt64
+			column.convertedType = parquetConvertedTimestampMicros
+			column.value = func(data []byte, value reflect.Value) []byte {
+				return binary.LittleEndian.AppendUint64(data, uint64(value.Interface().(time.Time).UnixMicro()))
+			}
+		case field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Int64:
+			column.physicalType = parquetTypeInt64
+			column.value = func(data []byte, value reflect.Value) []byte {
+				return binary.LittleEndian.AppendUint64(data, uint64(value.Int()))
+			}
+		case field.Type.Kind() == reflect.Float64:
+			column.physicalType = parquetTypeDouble
+			column.value = func(data []byte, value reflect.Value) []byte {
+				return binary.LittleEndian.AppendUint64(data, math.Float64bits(value.Float()))
+			}
+		default:
+			return nil, fmt.Errorf("field %s of type %s has no parquet column type", field.Name, field.Type)
+		}
+
+		p.columns = append(p.columns, column)
+	}
+
+	err := p.write([]byte(ParquetMagic))
+	return p, err
+}
+
+// Write adds a slice of rows, writing a row group whenever enough are
+// buffered.
+func (p *ParquetWriter) Write(rows any) error {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice || value.Type().Elem() != p.modelType {
+		return fmt.Errorf("parquet writer for %s was given %T", p.modelType, rows)
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		p.pending = append(p.pending, value.Index(i))
+		if len(p.pending) == ParquetRowGroupRows {
+			err := p.writeRowGroup()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close writes any buffered rows and the footer. It does not close the
+// underlying writer.
+func (p *ParquetWriter) Close() error {
+	if len(p.pending) > 0 {
+		err := p.writeRowGroup()
+		if err != nil {
+			return err
+		}
+	}
+
+	footer := p.footer()
+
+	err := p.write(footer)
+	if err != nil {
+		return err
+	}
+
+	err = p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	if err != nil {
+		return err
+	}
+
+	return p.write([]byte(ParquetMagic))
+}
+
+func (p *ParquetWriter) write(data []byte) error {
+	n, err := p.writer.Write(data)
+	p.offset += int64(n)
+	return err
+}
+
+func (p *ParquetWriter) writeRowGroup() error {
+	rowGroup := parquetRowGroup{rows: int64(len(p.pending))}
+
+	for _, column := range p.columns {
+		var values []byte
+		for _, row := range p.pending {
+			values = column.value(values, row.Field(column.field))
+		}
+
+		header := thriftWriter{}
+		header.i32(1, parquetPageData)
+		header.i32(2, int32(len(values)))
+		header.i32(3, int32(len(values)))
+		header.structBegin(5)
+		header.i32(1, int32(len(p.pending)))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRle)
+		header.i32(4, parquetEncodingRle)
+		header.structEnd()
+		header.stop()
+
+		chunk := parquetColumnChunk{offset: p.offset, size: int64(header.buffer.Len() + len(values))}
+
+		err := p.write(header.buffer.Bytes())
+		if err == nil {
+			err = p.write(values)
+		}
+		if err != nil {
+			return err
+		}
+
+		rowGroup.columns = append(rowGroup.columns, chunk)
+		rowGroup.size += chunk.size
+	}
+
+	p.rowGroups = append(p.rowGroups, rowGroup)
+	p.pending = p.pending[:0]
+	return nil
+}
+
+// footer encodes the FileMetaData.
+func (p *ParquetWriter) footer() []byte {
+	var rows int64
+	for _, rowGroup := range p.rowGroups {
+		rows += rowGroup.rows
+	}
+
+	metadata := thriftWriter{}
+	metadata.i32(1, 1)
+
+	metadata.listBegin(2, thriftStruct, len(p.columns)+1)
+	metadata.elementBegin()
+	metadata.binary(4, "schema")
+	metadata.i32(5, int32(len(p.columns)))
+	metadata.elementEnd()
+	for _, column := range p.columns {
+		metadata.elementBegin()
+		metadata.i32(1, column.physicalType)
+		metadata.i32(3, parquetRepetitionRequired)
+		metadata.binary(4, column.name)
+		if column.convertedType >= 0 {
+			metadata.i32(6, column.convertedType)
+			metadata.structBegin(10)
+			if column.convertedType == parquetConvertedDate {
+				metadata.structBegin(6)
+				metadata.structEnd()
+			} else {
+				metadata.structBegin(8)
+				metadata.bool(1, true)
+				metadata.structBegin(2)
+				metadata.structBegin(2)
+				metadata.structEnd()
+				metadata.structEnd()
+				metadata.structEnd()
+			}
+			metadata.structEnd()
+		}
+		metadata.elementEnd()
+	}
+
+	metadata.i64(3, rows)
+
+	metadata.listBegin(4, thriftStruct, len(p.rowGroups))
+	for _, rowGroup := range p.rowGroups {
+		metadata.elementBegin()
+		metadata.listBegin(1, thriftStruct, len(p.columns))
+		for i, column := range p.columns {
+			chunk := rowGroup.columns[i]
+
+			metadata.elementBegin()
+			metadata.i64(2, chunk.offset)
+			metadata.structBegin(3)
+			metadata.i32(1, column.physicalType)
+			metadata.listBegin(2, thriftI32, 2)
+			metadata.listI32(parquetEncodingPlain)
+			metadata.listI32(parquetEncodingRle)
+			metadata.listBegin(3, thriftBinary, 1)
+			metadata.listBinary(column.name)
+			metadata.i32(4, parquetCodecUncompressed)
+			metadata.i64(5, rowGroup.rows)
+			metadata.i64(6, chunk.size)
+			metadata.i64(7, chunk.size)
+			metadata.i64(9, chunk.offset)
+			metadata.structEnd()
+			metadata.elementEnd()
+		}
+		metadata.i64(2, rowGroup.size)
+		metadata.i64(3, rowGroup.rows)
+		metadata.elementEnd()
+	}
+
+	metadata.binary(6, "austinapi")
+	metadata.stop()
+
+	return metadata.buffer.Bytes()
+}
+
+// thriftWriter writes Thrift's compact protocol. lastField holds the last
+// field id written in each open struct, since field ids are written as a
+// delta from it.
+type thriftWriter struct {
+	buffer    bytes.Buffer
+	lastField []int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := int16(0)
+	if len(t.lastField) > 0 {
+		last = t.lastField[len(t.lastField)-1]
+		t.lastField[len(t.lastField)-1] = id
+	} else {
+		t.lastField = append(t.lastField, id)
+	}
+
+	if delta := id - last; delta > 0 && delta <= 15 {
+		t.buffer.WriteByte(byte(delta)<<4 | fieldType)
+		return
+	}
+
+	t.buffer.WriteByte(fieldType)
+	t.varint(uint64(zigzag(int64(id))))
+}
+
+func (t *thriftWriter) varint(value uint64) {
+	t.buffer.Write(binary.AppendUvarint(nil, value))
+}
+
+func zigzag(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
+
+func (t *thriftWriter) i32(id int16, value int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(value)))
+}
+
+func (t *thriftWriter) i64(id int16, value int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(value))
+}
+
+func (t *thriftWriter) bool(id int16, value bool) {
+	if value {
+		t.fieldHeader(id, thriftBoolTrue)
+	} else {
+		t.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+func (t *thriftWriter) binary(id int16, value string) {
+	t.fieldHeader(id, thriftBinary)
+	t.listBinary(value)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.stop()
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buffer.WriteByte(0)
+}
+
+func (t *thriftWriter) listBegin(id int16, elementType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buffer.WriteByte(byte(size)<<4 | elementType)
+		return
+	}
+	t.buffer.WriteByte(0xF0 | elementType)
+	t.varint(uint64(size))
+}
+
+// elementBegin and elementEnd wrap a struct inside a list, which has no
+// field header of its own.
+func (t *thriftWriter) elementBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftWriter) elementEnd() {
+	t.structEnd()
+}
+
+func (t *thriftWriter) listI32(value int32) {
+	t.varint(zigzag(int64(value)))
+}
+
+func (t *thriftWriter) listBinary(value string) {
+	t.varint(uint64(len(value)))
+	t.buffer.WriteString(value)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+)
+
+// thriftReader decodes Thrift's compact protocol into maps of field id to
+// value, enough to check what thriftWriter produces.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		panic("bad varint")
+	}
+	r.pos += n
+	return value
+}
+
+func (r *thriftReader) zigzag() int64 {
+	value := r.varint()
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (r *thriftReader) value(fieldType byte) any {
+	switch fieldType {
+	case thriftBoolTrue:
+		return true
+	case thriftBoolFalse:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		length := int(r.varint())
+		value := string(r.data[r.pos : r.pos+length])
+		r.pos += length
+		return value
+	case thriftList:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := []any{}
+		for i := 0; i < size; i++ {
+			list = append(list, r.value(header&0x0F))
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unknown thrift type")
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := map[int16]any{}
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+
+		fields[id] = r.value(header & 0x0F)
+	}
+}
+
+func TestParquetWriterFile(t *testing.T) {
+	date := time.Date(2024, time.February, 19, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, time.February, 19, 8, 30, 0, 0, time.UTC)
+	sleeps := []austinapi_db.Sleep{
+		{ID: 1, Date: date, Rating: 84, TotalSleep: 27000, DeepSleep: 5400, LightSleep: 14400, RemSleep: 7200, CreatedTimestamp: updated, UpdatedTimestamp: updated},
+		{ID: 2, Date: date.AddDate(0, 0, 1), Rating: 71, TotalSleep: 24000, CreatedTimestamp: updated, UpdatedTimestamp: updated},
+		{ID: 3, Date: date.AddDate(0, 0, 2), Rating: 90, TotalSleep: 30000, CreatedTimestamp: updated, UpdatedTimestamp: updated},
+	}
+
+	var file bytes.Buffer
+	writer, err := NewParquetWriter(&file, austinapi_db.Sleep{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = writer.Write(sleeps)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := file.Bytes()
+	if string(data[:4]) != ParquetMagic || string(data[len(data)-4:]) != ParquetMagic {
+		t.Fatalf("file does not start and end with %s", ParquetMagic)
+	}
+
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	footerStart := len(data) - 8 - footerSize
+	if footerStart < 4 {
+		t.Fatalf("footer length %d does not fit a %d byte file", footerSize, len(data))
+	}
+
+	footer := thriftReader{data: data[footerStart : len(data)-8]}
+	metadata := footer.readStruct()
+	if footer.pos != footerSize {
+		t.Fatalf("footer decoded %d of %d bytes", footer.pos, footerSize)
+	}
+
+	if metadata[1] != int64(1) || metadata[3] != int64(len(sleeps)) || metadata[6] != "austinapi" {
+		t.Fatalf("unexpected version, rows or created_by in %v", metadata)
+	}
+
+	names := []string{"id", "date", "rating", "total_sleep", "deep_sleep", "light_sleep", "rem_sleep", "created_timestamp", "updated_timestamp"}
+	schema := metadata[2].([]any)
+	if len(schema) != len(names)+1 {
+		t.Fatalf("expected %d schema elements, got %d", len(names)+1, len(schema))
+	}
+	root := schema[0].(map[int16]any)
+	if root[4] != "schema" || root[5] != int64(len(names)) {
+		t.Fatalf("unexpected schema root %v", root)
+	}
+	for i, name := range names {
+		element := schema[i+1].(map[int16]any)
+		if element[4] != name || element[3] != int64(parquetRepetitionRequired) {
+			t.Errorf("schema element %d is %v, expected required %s", i+1, element, name)
+		}
+	}
+	if date := schema[2].(map[int16]any); date[1] != int64(parquetTypeInt32) || date[6] != int64(parquetConvertedDate) {
+		t.Errorf("date column is not an INT32 DATE: %v", date)
+	}
+	if created := schema[8].(map[int16]any); created[1] != int64(parquetTypeInt64) || created[6] != int64(parquetConvertedTimestampMicros) {
+		t.Errorf("created_timestamp column is not an INT64 TIMESTAMP_MICROS: %v", created)
+	}
+
+	rowGroups := metadata[4].([]any)
+	if len(rowGroups) != 1 {
+		t.Fatalf("expected one row group, got %d", len(rowGroups))
+	}
+	rowGroup := rowGroups[0].(map[int16]any)
+	if rowGroup[3] != int64(len(sleeps)) {
+		t.Fatalf("row group has %v rows", rowGroup[3])
+	}
+
+	columns := rowGroup[1].([]any)
+	ratingColumn := columns[2].(map[int16]any)[3].(map[int16]any)
+	if path := ratingColumn[3].([]any); len(path) != 1 || path[0] != "rating" {
+		t.Fatalf("unexpected path %v for the rating column", path)
+	}
+
+	page := thriftReader{data: data[ratingColumn[9].(int64):]}
+	header := page.readStruct()
+	if header[1] != int64(parquetPageData) || header[2] != int64(8*len(sleeps)) || header[5].(map[int16]any)[1] != int64(len(sleeps)) {
+		t.Fatalf("unexpected rating page header %v", header)
+	}
+	if int64(page.pos)+header[3].(int64) != ratingColumn[7].(int64) {
+		t.Errorf("rating column size %v is not its page header and values", ratingColumn[7])
+	}
+
+	for i, sleep := range sleeps {
+		value := int64(binary.LittleEndian.Uint64(page.data[page.pos+i*8:]))
+		if value != sleep.Rating {
+			t.Errorf("rating %d is %d, expected %d", i, value, sleep.Rating)
+		}
+	}
+}
@@ -0,0 +1,1574 @@
+package main
+
+import (
+	"context"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// Queries holds the SQL this API needs beyond what austinapi_db generates.
+// They are written in the same shape sqlc produces so they can be moved into
+// austinapi_db/query.sql without changing any callers.
+type Queries struct {
+	db austinapi_db.DBTX
+}
+
+func NewQueries(db austinapi_db.DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type DateRangeParams struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+const getSleepsByDateRange = `-- name: GetSleepsByDateRange :many
+SELECT id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp
+FROM sleep
+WHERE date BETWEEN $1 AND $2
+ORDER BY date ASC
+`
+
+func (q *Queries) GetSleepsByDateRange(ctx context.Context, arg DateRangeParams) ([]austinapi_db.Sleep, error) {
+	items := []austinapi_db.Sleep{}
+	err := q.EachSleepByDateRange(ctx, arg, func(i austinapi_db.Sleep) error {
+		items = append(items, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachSleepByDateRange calls each with every row of GetSleepsByDateRange as
+// it is scanned, stopping at the first error.
+func (q *Queries) EachSleepByDateRange(ctx context.Context, arg DateRangeParams, each func(austinapi_db.Sleep) error) error {
+	rows, err := q.db.Query(ctx, getSleepsByDateRange, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i austinapi_db.Sleep
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Rating,
+			&i.TotalSleep,
+			&i.DeepSleep,
+			&i.LightSleep,
+			&i.RemSleep,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return err
+		}
+		if err := each(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const getReadyScoresByDateRange = `-- name: GetReadyScoresByDateRange :many
+SELECT id, date, score, created_timestamp, updated_timestamp
+FROM readyscore
+WHERE date BETWEEN $1 AND $2
+ORDER BY date ASC
+`
+
+func (q *Queries) GetReadyScoresByDateRange(ctx context.Context, arg DateRangeParams) ([]austinapi_db.Readyscore, error) {
+	items := []austinapi_db.Readyscore{}
+	err := q.EachReadyscoreByDateRange(ctx, arg, func(i austinapi_db.Readyscore) error {
+		items = append(items, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachReadyscoreByDateRange calls each with every row of GetReadyScoresByDateRange as
+// it is scanned, stopping at the first error.
+func (q *Queries) EachReadyscoreByDateRange(ctx context.Context, arg DateRangeParams, each func(austinapi_db.Readyscore) error) error {
+	rows, err := q.db.Query(ctx, getReadyScoresByDateRange, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i austinapi_db.Readyscore
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Score,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return err
+		}
+		if err := each(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const getHeartRatesByDateRange = `-- name: GetHeartRatesByDateRange :many
+SELECT id, date, high, low, average, created_timestamp, updated_timestamp
+FROM heartrate
+WHERE date BETWEEN $1 AND $2
+ORDER BY date ASC
+`
+
+func (q *Queries) GetHeartRatesByDateRange(ctx context.Context, arg DateRangeParams) ([]austinapi_db.Heartrate, error) {
+	items := []austinapi_db.Heartrate{}
+	err := q.EachHeartrateByDateRange(ctx, arg, func(i austinapi_db.Heartrate) error {
+		items = append(items, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachHeartrateByDateRange calls each with every row of GetHeartRatesByDateRange as
+// it is scanned, stopping at the first error.
+func (q *Queries) EachHeartrateByDateRange(ctx context.Context, arg DateRangeParams, each func(austinapi_db.Heartrate) error) error {
+	rows, err := q.db.Query(ctx, getHeartRatesByDateRange, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i austinapi_db.Heartrate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.High,
+			&i.Low,
+			&i.Average,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return err
+		}
+		if err := each(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const getStressesByDateRange = `-- name: GetStressesByDateRange :many
+SELECT id, date, high_stress_duration, created_timestamp, updated_timestamp
+FROM stress
+WHERE date BETWEEN $1 AND $2
+ORDER BY date ASC
+`
+
+func (q *Queries) GetStressesByDateRange(ctx context.Context, arg DateRangeParams) ([]austinapi_db.Stress, error) {
+	items := []austinapi_db.Stress{}
+	err := q.EachStressByDateRange(ctx, arg, func(i austinapi_db.Stress) error {
+		items = append(items, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachStressByDateRange calls each with every row of GetStressesByDateRange as
+// it is scanned, stopping at the first error.
+func (q *Queries) EachStressByDateRange(ctx context.Context, arg DateRangeParams, each func(austinapi_db.Stress) error) error {
+	rows, err := q.db.Query(ctx, getStressesByDateRange, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i austinapi_db.Stress
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.HighStressDuration,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return err
+		}
+		if err := each(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const getSpo2sByDateRange = `-- name: GetSpo2sByDateRange :many
+SELECT id, date, average_spo2, created_timestamp, updated_timestamp
+FROM spo2
+WHERE date BETWEEN $1 AND $2
+ORDER BY date ASC
+`
+
+func (q *Queries) GetSpo2sByDateRange(ctx context.Context, arg DateRangeParams) ([]austinapi_db.Spo2, error) {
+	items := []austinapi_db.Spo2{}
+	err := q.EachSpo2ByDateRange(ctx, arg, func(i austinapi_db.Spo2) error {
+		items = append(items, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// EachSpo2ByDateRange calls each with every row of GetSpo2sByDateRange as
+// it is scanned, stopping at the first error.
+func (q *Queries) EachSpo2ByDateRange(ctx context.Context, arg DateRangeParams, each func(austinapi_db.Spo2) error) error {
+	rows, err := q.db.Query(ctx, getSpo2sByDateRange, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i austinapi_db.Spo2
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.AverageSpo2,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return err
+		}
+		if err := each(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+type KeysetParams struct {
+	Date     time.Time `json:"date"`
+	ID       int64     `json:"id"`
+	RowLimit int32     `json:"row_limit"`
+}
+
+const getSleepsOlderThanCursor = `-- name: GetSleepsOlderThanCursor :many
+SELECT id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp
+FROM sleep
+WHERE (date, id) < ($1, $2)
+ORDER BY date DESC, id DESC
+LIMIT $3
+`
+
+func (q *Queries) GetSleepsOlderThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Sleep, error) {
+	rows, err := q.db.Query(ctx, getSleepsOlderThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Sleep{}
+	for rows.Next() {
+		var i austinapi_db.Sleep
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Rating,
+			&i.TotalSleep,
+			&i.DeepSleep,
+			&i.LightSleep,
+			&i.RemSleep,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSleepsNewerThanCursor = `-- name: GetSleepsNewerThanCursor :many
+SELECT id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp
+FROM sleep
+WHERE (date, id) > ($1, $2)
+ORDER BY date ASC, id ASC
+LIMIT $3
+`
+
+func (q *Queries) GetSleepsNewerThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Sleep, error) {
+	rows, err := q.db.Query(ctx, getSleepsNewerThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Sleep{}
+	for rows.Next() {
+		var i austinapi_db.Sleep
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Rating,
+			&i.TotalSleep,
+			&i.DeepSleep,
+			&i.LightSleep,
+			&i.RemSleep,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReadyScoresOlderThanCursor = `-- name: GetReadyScoresOlderThanCursor :many
+SELECT id, date, score, created_timestamp, updated_timestamp
+FROM readyscore
+WHERE (date, id) < ($1, $2)
+ORDER BY date DESC, id DESC
+LIMIT $3
+`
+
+func (q *Queries) GetReadyScoresOlderThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Readyscore, error) {
+	rows, err := q.db.Query(ctx, getReadyScoresOlderThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Readyscore{}
+	for rows.Next() {
+		var i austinapi_db.Readyscore
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Score,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReadyScoresNewerThanCursor = `-- name: GetReadyScoresNewerThanCursor :many
+SELECT id, date, score, created_timestamp, updated_timestamp
+FROM readyscore
+WHERE (date, id) > ($1, $2)
+ORDER BY date ASC, id ASC
+LIMIT $3
+`
+
+func (q *Queries) GetReadyScoresNewerThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Readyscore, error) {
+	rows, err := q.db.Query(ctx, getReadyScoresNewerThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Readyscore{}
+	for rows.Next() {
+		var i austinapi_db.Readyscore
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Score,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeartRatesOlderThanCursor = `-- name: GetHeartRatesOlderThanCursor :many
+SELECT id, date, high, low, average, created_timestamp, updated_timestamp
+FROM heartrate
+WHERE (date, id) < ($1, $2)
+ORDER BY date DESC, id DESC
+LIMIT $3
+`
+
+func (q *Queries) GetHeartRatesOlderThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Heartrate, error) {
+	rows, err := q.db.Query(ctx, getHeartRatesOlderThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Heartrate{}
+	for rows.Next() {
+		var i austinapi_db.Heartrate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.High,
+			&i.Low,
+			&i.Average,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeartRatesNewerThanCursor = `-- name: GetHeartRatesNewerThanCursor :many
+SELECT id, date, high, low, average, created_timestamp, updated_timestamp
+FROM heartrate
+WHERE (date, id) > ($1, $2)
+ORDER BY date ASC, id ASC
+LIMIT $3
+`
+
+func (q *Queries) GetHeartRatesNewerThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Heartrate, error) {
+	rows, err := q.db.Query(ctx, getHeartRatesNewerThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Heartrate{}
+	for rows.Next() {
+		var i austinapi_db.Heartrate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.High,
+			&i.Low,
+			&i.Average,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStressesOlderThanCursor = `-- name: GetStressesOlderThanCursor :many
+SELECT id, date, high_stress_duration, created_timestamp, updated_timestamp
+FROM stress
+WHERE (date, id) < ($1, $2)
+ORDER BY date DESC, id DESC
+LIMIT $3
+`
+
+func (q *Queries) GetStressesOlderThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Stress, error) {
+	rows, err := q.db.Query(ctx, getStressesOlderThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Stress{}
+	for rows.Next() {
+		var i austinapi_db.Stress
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.HighStressDuration,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStressesNewerThanCursor = `-- name: GetStressesNewerThanCursor :many
+SELECT id, date, high_stress_duration, created_timestamp, updated_timestamp
+FROM stress
+WHERE (date, id) > ($1, $2)
+ORDER BY date ASC, id ASC
+LIMIT $3
+`
+
+func (q *Queries) GetStressesNewerThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Stress, error) {
+	rows, err := q.db.Query(ctx, getStressesNewerThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Stress{}
+	for rows.Next() {
+		var i austinapi_db.Stress
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.HighStressDuration,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpo2sOlderThanCursor = `-- name: GetSpo2sOlderThanCursor :many
+SELECT id, date, average_spo2, created_timestamp, updated_timestamp
+FROM spo2
+WHERE (date, id) < ($1, $2)
+ORDER BY date DESC, id DESC
+LIMIT $3
+`
+
+func (q *Queries) GetSpo2sOlderThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Spo2, error) {
+	rows, err := q.db.Query(ctx, getSpo2sOlderThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Spo2{}
+	for rows.Next() {
+		var i austinapi_db.Spo2
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.AverageSpo2,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpo2sNewerThanCursor = `-- name: GetSpo2sNewerThanCursor :many
+SELECT id, date, average_spo2, created_timestamp, updated_timestamp
+FROM spo2
+WHERE (date, id) > ($1, $2)
+ORDER BY date ASC, id ASC
+LIMIT $3
+`
+
+func (q *Queries) GetSpo2sNewerThanCursor(ctx context.Context, arg KeysetParams) ([]austinapi_db.Spo2, error) {
+	rows, err := q.db.Query(ctx, getSpo2sNewerThanCursor, arg.Date, arg.ID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Spo2{}
+	for rows.Next() {
+		var i austinapi_db.Spo2
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.AverageSpo2,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSleepsOldestFirst = `-- name: GetSleepsOldestFirst :many
+SELECT id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp
+FROM sleep
+ORDER BY date ASC, id ASC
+LIMIT $1
+`
+
+func (q *Queries) GetSleepsOldestFirst(ctx context.Context, rowLimit int32) ([]austinapi_db.Sleep, error) {
+	rows, err := q.db.Query(ctx, getSleepsOldestFirst, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Sleep{}
+	for rows.Next() {
+		var i austinapi_db.Sleep
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Rating,
+			&i.TotalSleep,
+			&i.DeepSleep,
+			&i.LightSleep,
+			&i.RemSleep,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReadyScoresOldestFirst = `-- name: GetReadyScoresOldestFirst :many
+SELECT id, date, score, created_timestamp, updated_timestamp
+FROM readyscore
+ORDER BY date ASC, id ASC
+LIMIT $1
+`
+
+func (q *Queries) GetReadyScoresOldestFirst(ctx context.Context, rowLimit int32) ([]austinapi_db.Readyscore, error) {
+	rows, err := q.db.Query(ctx, getReadyScoresOldestFirst, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Readyscore{}
+	for rows.Next() {
+		var i austinapi_db.Readyscore
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Score,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeartRatesOldestFirst = `-- name: GetHeartRatesOldestFirst :many
+SELECT id, date, high, low, average, created_timestamp, updated_timestamp
+FROM heartrate
+ORDER BY date ASC, id ASC
+LIMIT $1
+`
+
+func (q *Queries) GetHeartRatesOldestFirst(ctx context.Context, rowLimit int32) ([]austinapi_db.Heartrate, error) {
+	rows, err := q.db.Query(ctx, getHeartRatesOldestFirst, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Heartrate{}
+	for rows.Next() {
+		var i austinapi_db.Heartrate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.High,
+			&i.Low,
+			&i.Average,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStressesOldestFirst = `-- name: GetStressesOldestFirst :many
+SELECT id, date, high_stress_duration, created_timestamp, updated_timestamp
+FROM stress
+ORDER BY date ASC, id ASC
+LIMIT $1
+`
+
+func (q *Queries) GetStressesOldestFirst(ctx context.Context, rowLimit int32) ([]austinapi_db.Stress, error) {
+	rows, err := q.db.Query(ctx, getStressesOldestFirst, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Stress{}
+	for rows.Next() {
+		var i austinapi_db.Stress
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.HighStressDuration,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpo2sOldestFirst = `-- name: GetSpo2sOldestFirst :many
+SELECT id, date, average_spo2, created_timestamp, updated_timestamp
+FROM spo2
+ORDER BY date ASC, id ASC
+LIMIT $1
+`
+
+func (q *Queries) GetSpo2sOldestFirst(ctx context.Context, rowLimit int32) ([]austinapi_db.Spo2, error) {
+	rows, err := q.db.Query(ctx, getSpo2sOldestFirst, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Spo2{}
+	for rows.Next() {
+		var i austinapi_db.Spo2
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.AverageSpo2,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type StatsParams struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Bucket    string    `json:"bucket"`
+}
+
+var SleepStatsFields = []string{"rating", "total_sleep", "deep_sleep", "light_sleep", "rem_sleep"}
+
+const getSleepStats = `-- name: GetSleepStats :many
+SELECT date_trunc($3::text, date::timestamp)::date AS bucket,
+    count(rating), avg(rating)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY rating)::float8, min(rating)::float8, max(rating)::float8, stddev_samp(rating)::float8,
+    count(total_sleep), avg(total_sleep)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY total_sleep)::float8, min(total_sleep)::float8, max(total_sleep)::float8, stddev_samp(total_sleep)::float8,
+    count(deep_sleep), avg(deep_sleep)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY deep_sleep)::float8, min(deep_sleep)::float8, max(deep_sleep)::float8, stddev_samp(deep_sleep)::float8,
+    count(light_sleep), avg(light_sleep)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY light_sleep)::float8, min(light_sleep)::float8, max(light_sleep)::float8, stddev_samp(light_sleep)::float8,
+    count(rem_sleep), avg(rem_sleep)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY rem_sleep)::float8, min(rem_sleep)::float8, max(rem_sleep)::float8, stddev_samp(rem_sleep)::float8
+FROM sleep
+WHERE date BETWEEN $1 AND $2
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+func (q *Queries) GetSleepStats(ctx context.Context, arg StatsParams) ([]BucketStats, error) {
+	rows, err := q.db.Query(ctx, getSleepStats, arg.StartDate, arg.EndDate, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBucketStats(rows, SleepStatsFields)
+}
+
+var ReadyScoreStatsFields = []string{"score"}
+
+const getReadyScoreStats = `-- name: GetReadyScoreStats :many
+SELECT date_trunc($3::text, date::timestamp)::date AS bucket,
+    count(score), avg(score)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY score)::float8, min(score)::float8, max(score)::float8, stddev_samp(score)::float8
+FROM readyscore
+WHERE date BETWEEN $1 AND $2
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+func (q *Queries) GetReadyScoreStats(ctx context.Context, arg StatsParams) ([]BucketStats, error) {
+	rows, err := q.db.Query(ctx, getReadyScoreStats, arg.StartDate, arg.EndDate, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBucketStats(rows, ReadyScoreStatsFields)
+}
+
+var HeartRateStatsFields = []string{"high", "low", "average"}
+
+const getHeartRateStats = `-- name: GetHeartRateStats :many
+SELECT date_trunc($3::text, date::timestamp)::date AS bucket,
+    count(high), avg(high)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY high)::float8, min(high)::float8, max(high)::float8, stddev_samp(high)::float8,
+    count(low), avg(low)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY low)::float8, min(low)::float8, max(low)::float8, stddev_samp(low)::float8,
+    count(average), avg(average)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY average)::float8, min(average)::float8, max(average)::float8, stddev_samp(average)::float8
+FROM heartrate
+WHERE date BETWEEN $1 AND $2
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+func (q *Queries) GetHeartRateStats(ctx context.Context, arg StatsParams) ([]BucketStats, error) {
+	rows, err := q.db.Query(ctx, getHeartRateStats, arg.StartDate, arg.EndDate, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBucketStats(rows, HeartRateStatsFields)
+}
+
+var StressStatsFields = []string{"high_stress_duration"}
+
+const getStressStats = `-- name: GetStressStats :many
+SELECT date_trunc($3::text, date::timestamp)::date AS bucket,
+    count(high_stress_duration), avg(high_stress_duration)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY high_stress_duration)::float8, min(high_stress_duration)::float8, max(high_stress_duration)::float8, stddev_samp(high_stress_duration)::float8
+FROM stress
+WHERE date BETWEEN $1 AND $2
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+func (q *Queries) GetStressStats(ctx context.Context, arg StatsParams) ([]BucketStats, error) {
+	rows, err := q.db.Query(ctx, getStressStats, arg.StartDate, arg.EndDate, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBucketStats(rows, StressStatsFields)
+}
+
+var Spo2StatsFields = []string{"average_spo2"}
+
+const getSpo2Stats = `-- name: GetSpo2Stats :many
+SELECT date_trunc($3::text, date::timestamp)::date AS bucket,
+    count(average_spo2), avg(average_spo2)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY average_spo2)::float8, min(average_spo2)::float8, max(average_spo2)::float8, stddev_samp(average_spo2)::float8
+FROM spo2
+WHERE date BETWEEN $1 AND $2
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+func (q *Queries) GetSpo2Stats(ctx context.Context, arg StatsParams) ([]BucketStats, error) {
+	rows, err := q.db.Query(ctx, getSpo2Stats, arg.StartDate, arg.EndDate, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanBucketStats(rows, Spo2StatsFields)
+}
+
+// scanBucketStats reads rows made of a bucket date followed by count, mean,
+// median, min, max and standard deviation columns for each of fields.
+func scanBucketStats(rows pgx.Rows, fields []string) ([]BucketStats, error) {
+	items := []BucketStats{}
+	for rows.Next() {
+		var bucket time.Time
+		fieldStats := make([]FieldStats, len(fields))
+
+		dest := []any{&bucket}
+		for i := range fieldStats {
+			dest = append(dest,
+				&fieldStats[i].Count,
+				&fieldStats[i].Mean,
+				&fieldStats[i].Median,
+				&fieldStats[i].Min,
+				&fieldStats[i].Max,
+				&fieldStats[i].StdDev,
+			)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		i := BucketStats{
+			Bucket: bucket.Format("2006-01-02"),
+			Fields: map[string]FieldStats{},
+		}
+		for index, field := range fields {
+			i.Fields[field] = fieldStats[index]
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertSleep = `-- name: UpsertSleep :one
+INSERT INTO sleep (date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (date) DO UPDATE SET rating = EXCLUDED.rating, total_sleep = EXCLUDED.total_sleep, deep_sleep = EXCLUDED.deep_sleep, light_sleep = EXCLUDED.light_sleep, rem_sleep = EXCLUDED.rem_sleep, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertSleep saves a sleep row keyed on its date and reports whether a new
+// row was inserted rather than an existing one updated.
+func (q *Queries) UpsertSleep(ctx context.Context, arg austinapi_db.SaveSleepParams) (austinapi_db.Sleep, bool, error) {
+	row := q.db.QueryRow(ctx, upsertSleep,
+		arg.Date,
+		arg.Rating,
+		arg.TotalSleep,
+		arg.DeepSleep,
+		arg.LightSleep,
+		arg.RemSleep,
+	)
+	var i austinapi_db.Sleep
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Rating,
+		&i.TotalSleep,
+		&i.DeepSleep,
+		&i.LightSleep,
+		&i.RemSleep,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const upsertSleepDurations = `-- name: UpsertSleepDurations :one
+INSERT INTO sleep (date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (date) DO UPDATE SET total_sleep = EXCLUDED.total_sleep, deep_sleep = EXCLUDED.deep_sleep, light_sleep = EXCLUDED.light_sleep, rem_sleep = EXCLUDED.rem_sleep, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertSleepDurations is UpsertSleep for sources that record sleep stages
+// but no rating. An existing row keeps its rating, and a new row takes the
+// given one.
+func (q *Queries) UpsertSleepDurations(ctx context.Context, arg austinapi_db.SaveSleepParams) (austinapi_db.Sleep, bool, error) {
+	row := q.db.QueryRow(ctx, upsertSleepDurations,
+		arg.Date,
+		arg.Rating,
+		arg.TotalSleep,
+		arg.DeepSleep,
+		arg.LightSleep,
+		arg.RemSleep,
+	)
+	var i austinapi_db.Sleep
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Rating,
+		&i.TotalSleep,
+		&i.DeepSleep,
+		&i.LightSleep,
+		&i.RemSleep,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const updateSleep = `-- name: UpdateSleep :many
+UPDATE sleep SET date = $2, rating = $3, total_sleep = $4, deep_sleep = $5, light_sleep = $6, rem_sleep = $7, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, date, rating, total_sleep, deep_sleep, light_sleep, rem_sleep, created_timestamp, updated_timestamp
+`
+
+type UpdateSleepParams struct {
+	ID int64 `json:"id"`
+	austinapi_db.SaveSleepParams
+}
+
+func (q *Queries) UpdateSleep(ctx context.Context, arg UpdateSleepParams) ([]austinapi_db.Sleep, error) {
+	rows, err := q.db.Query(ctx, updateSleep,
+		arg.ID,
+		arg.Date,
+		arg.Rating,
+		arg.TotalSleep,
+		arg.DeepSleep,
+		arg.LightSleep,
+		arg.RemSleep,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Sleep{}
+	for rows.Next() {
+		var i austinapi_db.Sleep
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Rating,
+			&i.TotalSleep,
+			&i.DeepSleep,
+			&i.LightSleep,
+			&i.RemSleep,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSleep = `-- name: DeleteSleep :many
+DELETE FROM sleep
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteSleep(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, deleteSleep, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertReadyScore = `-- name: UpsertReadyScore :one
+INSERT INTO readyscore (date, score) VALUES ($1, $2)
+ON CONFLICT (date) DO UPDATE SET score = EXCLUDED.score, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, score, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertReadyScore saves a readyscore row keyed on its date and reports whether a new
+// row was inserted rather than an existing one updated.
+func (q *Queries) UpsertReadyScore(ctx context.Context, arg austinapi_db.SaveReadyScoreParams) (austinapi_db.Readyscore, bool, error) {
+	row := q.db.QueryRow(ctx, upsertReadyScore,
+		arg.Date,
+		arg.Score,
+	)
+	var i austinapi_db.Readyscore
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.Score,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const updateReadyScore = `-- name: UpdateReadyScore :many
+UPDATE readyscore SET date = $2, score = $3, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, date, score, created_timestamp, updated_timestamp
+`
+
+type UpdateReadyScoreParams struct {
+	ID int64 `json:"id"`
+	austinapi_db.SaveReadyScoreParams
+}
+
+func (q *Queries) UpdateReadyScore(ctx context.Context, arg UpdateReadyScoreParams) ([]austinapi_db.Readyscore, error) {
+	rows, err := q.db.Query(ctx, updateReadyScore,
+		arg.ID,
+		arg.Date,
+		arg.Score,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Readyscore{}
+	for rows.Next() {
+		var i austinapi_db.Readyscore
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.Score,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteReadyScore = `-- name: DeleteReadyScore :many
+DELETE FROM readyscore
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteReadyScore(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, deleteReadyScore, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertHeartRate = `-- name: UpsertHeartRate :one
+INSERT INTO heartrate (date, low, high, average) VALUES ($1, $2, $3, $4)
+ON CONFLICT (date) DO UPDATE SET low = EXCLUDED.low, high = EXCLUDED.high, average = EXCLUDED.average, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, high, low, average, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertHeartRate saves a heartrate row keyed on its date and reports whether a new
+// row was inserted rather than an existing one updated.
+func (q *Queries) UpsertHeartRate(ctx context.Context, arg austinapi_db.SaveHeartRateParams) (austinapi_db.Heartrate, bool, error) {
+	row := q.db.QueryRow(ctx, upsertHeartRate,
+		arg.Date,
+		arg.Low,
+		arg.High,
+		arg.Average,
+	)
+	var i austinapi_db.Heartrate
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.High,
+		&i.Low,
+		&i.Average,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const updateHeartRate = `-- name: UpdateHeartRate :many
+UPDATE heartrate SET date = $2, low = $3, high = $4, average = $5, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, date, high, low, average, created_timestamp, updated_timestamp
+`
+
+type UpdateHeartRateParams struct {
+	ID int64 `json:"id"`
+	austinapi_db.SaveHeartRateParams
+}
+
+func (q *Queries) UpdateHeartRate(ctx context.Context, arg UpdateHeartRateParams) ([]austinapi_db.Heartrate, error) {
+	rows, err := q.db.Query(ctx, updateHeartRate,
+		arg.ID,
+		arg.Date,
+		arg.Low,
+		arg.High,
+		arg.Average,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Heartrate{}
+	for rows.Next() {
+		var i austinapi_db.Heartrate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.High,
+			&i.Low,
+			&i.Average,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteHeartRate = `-- name: DeleteHeartRate :many
+DELETE FROM heartrate
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteHeartRate(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, deleteHeartRate, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertStress = `-- name: UpsertStress :one
+INSERT INTO stress (date, high_stress_duration) VALUES ($1, $2)
+ON CONFLICT (date) DO UPDATE SET high_stress_duration = EXCLUDED.high_stress_duration, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, high_stress_duration, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertStress saves a stress row keyed on its date and reports whether a new
+// row was inserted rather than an existing one updated.
+func (q *Queries) UpsertStress(ctx context.Context, arg austinapi_db.SaveStressParams) (austinapi_db.Stress, bool, error) {
+	row := q.db.QueryRow(ctx, upsertStress,
+		arg.Date,
+		arg.HighStressDuration,
+	)
+	var i austinapi_db.Stress
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.HighStressDuration,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const updateStress = `-- name: UpdateStress :many
+UPDATE stress SET date = $2, high_stress_duration = $3, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, date, high_stress_duration, created_timestamp, updated_timestamp
+`
+
+type UpdateStressParams struct {
+	ID int64 `json:"id"`
+	austinapi_db.SaveStressParams
+}
+
+func (q *Queries) UpdateStress(ctx context.Context, arg UpdateStressParams) ([]austinapi_db.Stress, error) {
+	rows, err := q.db.Query(ctx, updateStress,
+		arg.ID,
+		arg.Date,
+		arg.HighStressDuration,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Stress{}
+	for rows.Next() {
+		var i austinapi_db.Stress
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.HighStressDuration,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteStress = `-- name: DeleteStress :many
+DELETE FROM stress
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteStress(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, deleteStress, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertSpo2 = `-- name: UpsertSpo2 :one
+INSERT INTO spo2 (date, average_spo2) VALUES ($1, $2)
+ON CONFLICT (date) DO UPDATE SET average_spo2 = EXCLUDED.average_spo2, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING id, date, average_spo2, created_timestamp, updated_timestamp, (xmax = 0) AS inserted
+`
+
+// UpsertSpo2 saves a spo2 row keyed on its date and reports whether a new
+// row was inserted rather than an existing one updated.
+func (q *Queries) UpsertSpo2(ctx context.Context, arg austinapi_db.SaveSpo2Params) (austinapi_db.Spo2, bool, error) {
+	row := q.db.QueryRow(ctx, upsertSpo2,
+		arg.Date,
+		arg.AverageSpo2,
+	)
+	var i austinapi_db.Spo2
+	var inserted bool
+	err := row.Scan(
+		&i.ID,
+		&i.Date,
+		&i.AverageSpo2,
+		&i.CreatedTimestamp,
+		&i.UpdatedTimestamp,
+		&inserted,
+	)
+	return i, inserted, err
+}
+
+const updateSpo2 = `-- name: UpdateSpo2 :many
+UPDATE spo2 SET date = $2, average_spo2 = $3, updated_timestamp = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, date, average_spo2, created_timestamp, updated_timestamp
+`
+
+type UpdateSpo2Params struct {
+	ID int64 `json:"id"`
+	austinapi_db.SaveSpo2Params
+}
+
+func (q *Queries) UpdateSpo2(ctx context.Context, arg UpdateSpo2Params) ([]austinapi_db.Spo2, error) {
+	rows, err := q.db.Query(ctx, updateSpo2,
+		arg.ID,
+		arg.Date,
+		arg.AverageSpo2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []austinapi_db.Spo2{}
+	for rows.Next() {
+		var i austinapi_db.Spo2
+		if err := rows.Scan(
+			&i.ID,
+			&i.Date,
+			&i.AverageSpo2,
+			&i.CreatedTimestamp,
+			&i.UpdatedTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSpo2 = `-- name: DeleteSpo2 :many
+DELETE FROM spo2
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteSpo2(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, deleteSpo2, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type SyncStateParams struct {
+	Source string `json:"source"`
+	Metric string `json:"metric"`
+}
+
+const getSyncHighWaterMark = `-- name: GetSyncHighWaterMark :one
+SELECT high_water_mark FROM sync_state
+WHERE source = $1 AND metric = $2
+`
+
+// GetSyncHighWaterMark reads a sync's mark from sync_state, which
+// sql/sync_state.sql creates alongside austinapi_db's tables.
+func (q *Queries) GetSyncHighWaterMark(ctx context.Context, arg SyncStateParams) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getSyncHighWaterMark, arg.Source, arg.Metric)
+	var high_water_mark time.Time
+	err := row.Scan(&high_water_mark)
+	return high_water_mark, err
+}
+
+type SaveSyncHighWaterMarkParams struct {
+	Source        string    `json:"source"`
+	Metric        string    `json:"metric"`
+	HighWaterMark time.Time `json:"high_water_mark"`
+}
+
+const saveSyncHighWaterMark = `-- name: SaveSyncHighWaterMark :exec
+INSERT INTO sync_state (source, metric, high_water_mark) VALUES ($1, $2, $3)
+ON CONFLICT (source, metric) DO UPDATE SET high_water_mark = GREATEST(sync_state.high_water_mark, EXCLUDED.high_water_mark), updated_timestamp = CURRENT_TIMESTAMP
+`
+
+// SaveSyncHighWaterMark records the latest date a sync has saved. The mark
+// only ever moves forward.
+func (q *Queries) SaveSyncHighWaterMark(ctx context.Context, arg SaveSyncHighWaterMarkParams) error {
+	_, err := q.db.Exec(ctx, saveSyncHighWaterMark, arg.Source, arg.Metric, arg.HighWaterMark)
+	return err
+}
+
+type SaveRowSourceParams struct {
+	Metric string    `json:"metric"`
+	Date   time.Time `json:"date"`
+	Source string    `json:"source"`
+	Detail string    `json:"detail"`
+}
+
+const saveRowSource = `-- name: SaveRowSource :exec
+INSERT INTO row_source (metric, date, source, detail) VALUES ($1, $2, $3, $4)
+ON CONFLICT (metric, date) DO UPDATE SET source = EXCLUDED.source, detail = EXCLUDED.detail, updated_timestamp = CURRENT_TIMESTAMP
+`
+
+// SaveRowSource records where an imported row came from in row_source,
+// which sql/row_source.sql creates alongside austinapi_db's tables.
+func (q *Queries) SaveRowSource(ctx context.Context, arg SaveRowSourceParams) error {
+	_, err := q.db.Exec(ctx, saveRowSource, arg.Metric, arg.Date, arg.Source, arg.Detail)
+	return err
+}
+
+const getCsvProfile = `-- name: GetCsvProfile :one
+SELECT profile FROM csv_profile
+WHERE name = $1
+`
+
+// GetCsvProfile reads a saved mapping profile from csv_profile, which
+// sql/csv_profile.sql creates alongside austinapi_db's tables.
+func (q *Queries) GetCsvProfile(ctx context.Context, name string) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getCsvProfile, name)
+	var profile []byte
+	err := row.Scan(&profile)
+	return profile, err
+}
+
+const getCsvProfiles = `-- name: GetCsvProfiles :many
+SELECT profile FROM csv_profile
+ORDER BY name ASC
+`
+
+func (q *Queries) GetCsvProfiles(ctx context.Context) ([][]byte, error) {
+	rows, err := q.db.Query(ctx, getCsvProfiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := [][]byte{}
+	for rows.Next() {
+		var profile []byte
+		if err := rows.Scan(&profile); err != nil {
+			return nil, err
+		}
+		items = append(items, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type SaveCsvProfileParams struct {
+	Name    string `json:"name"`
+	Profile []byte `json:"profile"`
+}
+
+const saveCsvProfile = `-- name: SaveCsvProfile :one
+INSERT INTO csv_profile (name, profile) VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET profile = EXCLUDED.profile, updated_timestamp = CURRENT_TIMESTAMP
+RETURNING (xmax = 0) AS inserted
+`
+
+// SaveCsvProfile saves a profile under its name and reports whether it is
+// new rather than replacing one.
+func (q *Queries) SaveCsvProfile(ctx context.Context, arg SaveCsvProfileParams) (bool, error) {
+	row := q.db.QueryRow(ctx, saveCsvProfile, arg.Name, arg.Profile)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
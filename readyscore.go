@@ -1,44 +1,94 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
 	"time"
 )
 
 var (
-	ReadyScoreRgxId   *regexp.Regexp
-	ReadyScoreListRgx *regexp.Regexp
-	ReadyScoreRgxDate *regexp.Regexp
+	ReadyScoreRgxId         *regexp.Regexp
+	ReadyScoreListRgx       *regexp.Regexp
+	ReadyScoreRgxDate       *regexp.Regexp
+	ReadyScoreRgxRange      *regexp.Regexp
+	ReadyScoreRgxStats      *regexp.Regexp
+	ReadyScoreRgxSeries     *regexp.Regexp
+	ReadyScoreRgxChart      *regexp.Regexp
+	ReadyScoreRgxHeatmap    *regexp.Regexp
+	ReadyScoreRgxCollection *regexp.Regexp
 )
 
 type ReadyScoreHandler struct{}
 
 type ReadyScores struct {
 	Data      []austinapi_db.Readyscore `json:"data"`
-	NextToken int32                     `json:"next_token"`
+	NextToken string                    `json:"next_token,omitempty"`
+	PrevToken string                    `json:"prev_token,omitempty"`
+}
+
+type ReadyScoreRange struct {
+	Start string                    `json:"start"`
+	End   string                    `json:"end"`
+	Data  []austinapi_db.Readyscore `json:"data"`
+}
+
+// AnnotatedReadyScore is a ready score with any anomalies found in it on that date
+type AnnotatedReadyScore struct {
+	austinapi_db.Readyscore
+	Anomaly []Anomaly `json:"anomaly"`
+}
+
+// ReadyScoreInput is the JSON body accepted when writing a ready score. PATCH
+// requests may leave out fields to keep their stored values.
+type ReadyScoreInput struct {
+	Date  *string `json:"date"`
+	Score *int    `json:"score"`
 }
 
 func init() {
 	ReadyScoreRgxId = regexp.MustCompile(`^/readyscore/id/([0-9]+)$`)
-	ReadyScoreListRgx = regexp.MustCompile(`^/readyscore/list(?:\?(next_token)=([0-9]+))?$`)
+	ReadyScoreListRgx = regexp.MustCompile(`^/readyscore/list$`)
 	ReadyScoreRgxDate = regexp.MustCompile(`^/readyscore/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	ReadyScoreRgxRange = regexp.MustCompile(`^/readyscore/range$`)
+	ReadyScoreRgxStats = regexp.MustCompile(`^/readyscore/stats$`)
+	ReadyScoreRgxSeries = regexp.MustCompile(`^/readyscore/series$`)
+	ReadyScoreRgxChart = regexp.MustCompile(`^/readyscore/chart\.svg$`)
+	ReadyScoreRgxHeatmap = regexp.MustCompile(`^/readyscore/heatmap\.svg$`)
+	ReadyScoreRgxCollection = regexp.MustCompile(`^/readyscore$`)
 }
 
 func (h *ReadyScoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	switch {
-	case r.Method == http.MethodGet && ReadyScoreListRgx.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && ReadyScoreListRgx.MatchString(r.URL.Path):
 		h.listReadyScore(w, r)
-	case r.Method == http.MethodGet && ReadyScoreRgxId.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && ReadyScoreRgxId.MatchString(r.URL.Path):
 		h.getReadyScore(w, r)
-	case r.Method == http.MethodGet && ReadyScoreRgxDate.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && ReadyScoreRgxDate.MatchString(r.URL.Path):
 		h.getReadyScoreByDate(w, r)
+	case r.Method == http.MethodGet && ReadyScoreRgxRange.MatchString(r.URL.Path):
+		h.getReadyScoreByDateRange(w, r)
+	case r.Method == http.MethodGet && ReadyScoreRgxStats.MatchString(r.URL.Path):
+		h.getReadyScoreStats(w, r)
+	case r.Method == http.MethodGet && ReadyScoreRgxSeries.MatchString(r.URL.Path):
+		h.getReadyScoreSeries(w, r)
+	case r.Method == http.MethodGet && ReadyScoreRgxChart.MatchString(r.URL.Path):
+		h.getReadyScoreChart(w, r)
+	case r.Method == http.MethodGet && ReadyScoreRgxHeatmap.MatchString(r.URL.Path):
+		h.getReadyScoreHeatmap(w, r)
+	case r.Method == http.MethodPost && ReadyScoreRgxCollection.MatchString(r.URL.Path):
+		h.createReadyScore(w, r)
+	case r.Method == http.MethodPut && ReadyScoreRgxId.MatchString(r.URL.Path):
+		h.replaceReadyScore(w, r)
+	case r.Method == http.MethodPatch && ReadyScoreRgxId.MatchString(r.URL.Path):
+		h.patchReadyScore(w, r)
+	case r.Method == http.MethodDelete && ReadyScoreRgxId.MatchString(r.URL.Path):
+		h.deleteReadyScore(w, r)
 	default:
 		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
@@ -49,16 +99,18 @@ func (h *ReadyScoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieves ready score information with specified ID
 // @Tags readyscore
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param id path string true "Ready Score ID"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} austinapi_db.Readyscore
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /readyscore/id/{id} [get]
 func (h *ReadyScoreHandler) getReadyScore(w http.ResponseWriter, r *http.Request) {
-	idMatches := ReadyScoreRgxId.FindStringSubmatch(r.URL.String())
+	idMatches := ReadyScoreRgxId.FindStringSubmatch(r.URL.Path)
 
 	if len(idMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, ReadyScoreRgxId.String())
@@ -89,36 +141,28 @@ func (h *ReadyScoreHandler) getReadyScore(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
-	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
-		handleError(w, http.StatusInternalServerError, "Internal Error")
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
-	}
+	writeFormatted(w, r, result[0], result[0], nil)
 }
 
 // @Summary Get ready score information by date
 // @Security ApiKeyAuth
 // @Description Retrieves ready score information with specified date
+// @Description along with any anomalies found against the recent personal baseline
 // @Tags readyscore
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param date path string true "Date"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
-// @Success 200 {object} austinapi_db.Readyscore
+// @Success 200 {object} AnnotatedReadyScore
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /readyscore/date/{date} [get]
 func (h *ReadyScoreHandler) getReadyScoreByDate(w http.ResponseWriter, r *http.Request) {
 
-	dateMatches := ReadyScoreRgxDate.FindStringSubmatch(r.URL.String())
+	dateMatches := ReadyScoreRgxDate.FindStringSubmatch(r.URL.Path)
 
 	if len(dateMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, ReadyScoreRgxDate.String())
@@ -150,94 +194,495 @@ func (h *ReadyScoreHandler) getReadyScoreByDate(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
+	anomalies, err := annotateAnomalies(ReadyScoreMetric, searchDate)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error detecting ready score anomalies with date '%s': %v", dateString, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	annotated := AnnotatedReadyScore{
+		Readyscore: result[0],
+		Anomaly:    anomalies,
+	}
+
+	writeFormatted(w, r, annotated, annotated, nil)
+}
+
+// @Summary Get ready score information for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves ready score information between start and end (inclusive) in ascending order by date
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description A week or month start begins on the first day of that period and a week or month
+// @Description end finishes on the last day of that period. If end is omitted the range covers
+// @Description only the period given by start. CSV and NDJSON are streamed a row at a time as they are read.
+// @Tags readyscore
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} ReadyScoreRange
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/range [get]
+func (h *ReadyScoreHandler) getReadyScoreByDateRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	err = writeRangeFormatted(w, r, func(each func(austinapi_db.Readyscore) error) error {
+		return ApiQueries.EachReadyscoreByDateRange(DatabaseContext, params, each)
+	}, func(results []austinapi_db.Readyscore) any {
+		return ReadyScoreRange{
+			Start: startDate.Format("2006-01-02"),
+			End:   endDate.Format("2006-01-02"),
+			Data:  results,
+		}
+	})
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
+		ErrorLog.Printf("error retrieving ready score between '%s' and '%s': %v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
 	}
 }
 
+// @Summary Get ready score statistics
+// @Security ApiKeyAuth
+// @Description Retrieves count, mean, median, min, max and standard deviation of each numeric
+// @Description ready score field between start and end (inclusive), grouped by day, week or month.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description Each bucket is identified by its first day. stddev is null for a bucket with one value.
+// @Tags readyscore
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param bucket query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Stats
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/stats [get]
+func (h *ReadyScoreHandler) getReadyScoreStats(w http.ResponseWriter, r *http.Request) {
+	writeStats(w, r, "ready score", ApiQueries.GetReadyScoreStats)
+}
+
+// @Summary Get smoothed ready score time series
+// @Security ApiKeyAuth
+// @Description Retrieves the daily value of one numeric ready score field between start and end (inclusive)
+// @Description alongside a rolling mean for each window of days and an exponentially weighted moving
+// @Description average (EWMA). Rolling means average the days recorded within each window.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags readyscore
+// @Produce json
+// @Param field query string true "Numeric field to return"
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param windows query string false "Comma separated rolling mean windows in days" default(7,30)
+// @Param alpha query number false "EWMA smoothing factor greater than 0 and at most 1" default(0.3)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Series
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/series [get]
+func (h *ReadyScoreHandler) getReadyScoreSeries(w http.ResponseWriter, r *http.Request) {
+	writeSeries(w, r, ReadyScoreMetric)
+}
+
+// @Summary Get a chart of ready score as SVG
+// @Security ApiKeyAuth
+// @Description Draws one or more numeric ready score fields by day between start and end (inclusive) as a
+// @Description standalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars
+// @Description or bars stacked in the order the fields are given, and band=true shades a line chart between
+// @Description its first and last fields. For example field=score&type=bar draws a bar for each day's score.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and
+// @Description default to the last 30 days. Days with no value are left as gaps. Charts can be embedded
+// @Description without an Authorization header using a URL signed by /sign.
+// @Tags readyscore
+// @Produce image/svg+xml
+// @Param field query string true "Comma separated numeric fields to draw"
+// @Param type query string false "Chart type" Enums(line, bar, stacked) default(line)
+// @Param band query bool false "Shade a line chart between its first and last fields"
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param width query int false "Width in pixels" default(640)
+// @Param height query int false "Height in pixels" default(320)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param colors query string false "Comma separated hex series colors, overriding the theme"
+// @Param background query string false "Hex background color, or none for transparent"
+// @Param title query string false "Title, or empty for none"
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/chart.svg [get]
+func (h *ReadyScoreHandler) getReadyScoreChart(w http.ResponseWriter, r *http.Request) {
+	writeChart(w, r, ReadyScoreMetric)
+}
+
+// @Summary Get a year heatmap of ready score as SVG
+// @Security ApiKeyAuth
+// @Description Draws a year of one numeric ready score field as a grid of days, one column per week starting
+// @Description on Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls
+// @Description between the lowest and highest of the year, and hovering a day shows its value. field
+// @Description defaults to score and year to the current year. Heatmaps can be embedded without an
+// @Description Authorization header using a URL signed by /sign.
+// @Tags readyscore
+// @Produce image/svg+xml
+// @Param year query int false "Year"
+// @Param field query string false "Numeric field to shade by" default(score)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/heatmap.svg [get]
+func (h *ReadyScoreHandler) getReadyScoreHeatmap(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, ReadyScoreMetric)
+}
+
 // @Summary Get list of ready score information
 // @Security ApiKeyAuth
-// @Description Retrieves list of ready score information in descending order by date
+// @Description Retrieves list of ready score information ordered by date, newest first unless order=asc
 // @Description Specifying no query parameters pulls list starting with latest
 // @Description Caller can then specify a next_token from previous calls to go
-// @Description forward in the list of items.
+// @Description forward in the list of items, or a prev_token to go back.
+// @Description Tokens are opaque and remain stable while new items are added.
+// @Description Next, previous and first page URLs are also returned in the Link header.
+// @Description Paging past the end of the list returns an empty data array.
 // @Tags readyscore
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param next_token query string false "next list search by next_token" Format(string)
+// @Param prev_token query string false "previous list search by prev_token" Format(string)
+// @Param limit query int false "number of items per page, at most the server list limit"
+// @Param order query string false "order by date" Enums(asc, desc) default(desc)
+// @Param fields query string false "comma separated list of fields to include in each item"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} ReadyScores
+// @Failure 400 {object} GenericMessage
 // @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /readyscore/list [get]
 func (h *ReadyScoreHandler) listReadyScore(w http.ResponseWriter, r *http.Request) {
-	urlMatches := ReadyScoreListRgx.FindStringSubmatch(r.URL.String())
-
-	if len(urlMatches) != 3 {
-		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, ReadyScoreListRgx.String())
-		handleError(w, http.StatusInternalServerError, "Issue parsing URL")
+	listQuery, err := parseListQuery(r.URL.Query(), austinapi_db.Readyscore{})
+	if err != nil {
+		ErrorLog.Printf("error parsing query string '%s': %v", r.URL.RawQuery, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %v", err))
 		return
 	}
 
-	queryType := urlMatches[1]
-	queryToken := urlMatches[2]
+	InfoLog.Printf("URL directive match '%s'\n", listQuery.Direction)
+	InfoLog.Printf("URL token match '%s'\n", listQuery.Token)
 
-	InfoLog.Printf("URL directive match '%s'\n", queryType)
-	InfoLog.Printf("URL token match '%s'\n", queryToken)
+	var results []austinapi_db.Readyscore
 
-	params := austinapi_db.GetReadyScoresParams{
-		RowOffset: 0,
-		RowLimit:  ListRowLimit,
-	}
+	// One row more than a page is requested to tell whether another page follows
+	rowLimit := listQuery.Limit + 1
 
-	if queryType == "next_token" {
-		rowOffset, err := strconv.ParseInt(queryToken, 10, 32)
-		if err != nil {
-			ErrorLog.Printf("error parsing specified query token '%v': %v", queryToken, err)
+	switch listQuery.Direction {
+	case PageNext, PagePrev:
+		cursor, cursorErr := decodeCursor(listQuery.Token)
+		if cursorErr != nil {
+			ErrorLog.Printf("error parsing specified query token '%v': %v", listQuery.Token, cursorErr)
 			handleError(w, http.StatusBadRequest, "Invalid query token")
 			return
 		}
 
-		params.RowOffset = int32(rowOffset)
+		params := KeysetParams{
+			Date:     cursor.Date,
+			ID:       cursor.ID,
+			RowLimit: rowLimit,
+		}
+
+		if listQuery.FetchesNewer() {
+			results, err = ApiQueries.GetReadyScoresNewerThanCursor(DatabaseContext, params)
+		} else {
+			results, err = ApiQueries.GetReadyScoresOlderThanCursor(DatabaseContext, params)
+		}
+	case PageFirst:
+		if listQuery.Order == OrderAsc {
+			results, err = ApiQueries.GetReadyScoresOldestFirst(DatabaseContext, rowLimit)
+		} else {
+			params := austinapi_db.GetReadyScoresParams{
+				RowOffset: 0,
+				RowLimit:  rowLimit,
+			}
+
+			results, err = ApiDatabase.GetReadyScores(DatabaseContext, params)
+		}
+	}
+
+	if err != nil {
+		ErrorLog.Printf("error getting list of ready score: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	keep, hasNext, hasPrev := trimPage(listQuery.Direction, len(results), listQuery.Limit)
+	results = results[:keep]
+	if listQuery.Direction == PagePrev {
+		slices.Reverse(results)
+	}
+
+	readyScores := ReadyScores{
+		Data: results,
+	}
+
+	if len(results) > 0 {
+		if hasNext {
+			last := results[len(results)-1]
+			readyScores.NextToken = encodeCursor(last.Date, last.ID)
+		}
+		if hasPrev {
+			first := results[0]
+			readyScores.PrevToken = encodeCursor(first.Date, first.ID)
+		}
+	}
+
+	setLinkHeader(w, r, readyScores.NextToken, readyScores.PrevToken)
+
+	writeFormatted(w, r, readyScores, results, listQuery.Fields)
+}
+
+func newReadyScoreInput(readyScore austinapi_db.Readyscore) ReadyScoreInput {
+	date := readyScore.Date.Format("2006-01-02")
+	return ReadyScoreInput{
+		Date:  &date,
+		Score: &readyScore.Score,
+	}
+}
+
+func (i ReadyScoreInput) params() (austinapi_db.SaveReadyScoreParams, error) {
+	var params austinapi_db.SaveReadyScoreParams
+	var err error
+
+	if params.Date, err = parseInputDate(i.Date); err != nil {
+		return params, err
+	}
+	if params.Score, err = checkRange("score", i.Score, 0, 100); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// @Summary Save ready score information
+// @Security ApiKeyAuth
+// @Description Saves ready score information for a date. If ready score already exists for the date it is
+// @Description updated and 200 is returned, otherwise it is created and 201 is returned.
+// @Tags readyscore
+// @Accept json
+// @Produce json
+// @Param readyscore body ReadyScoreInput true "Ready Score"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Readyscore
+// @Success 201 {object} austinapi_db.Readyscore
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore [post]
+func (h *ReadyScoreHandler) createReadyScore(w http.ResponseWriter, r *http.Request) {
+	var input ReadyScoreInput
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding ready score request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid ready score request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
 	}
 
-	results, err := ApiDatabase.GetReadyScores(DatabaseContext, params)
+	result, inserted, err := ApiQueries.UpsertReadyScore(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error saving ready score with date '%s': %v", params.Date.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	InfoLog.Printf("saved ready score with id '%d' inserted '%t'\n", result.ID, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/readyscore/id/%d", result.ID))
+	writeJSON(w, statusCode, result)
+}
+
+// @Summary Replace ready score information by ID
+// @Security ApiKeyAuth
+// @Description Replaces all fields of the ready score information with specified ID
+// @Tags readyscore
+// @Accept json
+// @Produce json
+// @Param id path string true "Ready Score ID"
+// @Param readyscore body ReadyScoreInput true "Ready Score"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Readyscore
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/id/{id} [put]
+func (h *ReadyScoreHandler) replaceReadyScore(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(ReadyScoreRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
 
+	var input ReadyScoreInput
+	err = decodeBody(r, &input)
 	if err != nil {
-		ErrorLog.Printf("error getting list of ready scores: %v", err)
+		ErrorLog.Printf("error decoding ready score request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateReadyScore(w, id, input)
+}
+
+// @Summary Update ready score information by ID
+// @Security ApiKeyAuth
+// @Description Updates only the fields given for the ready score information with specified ID
+// @Tags readyscore
+// @Accept json
+// @Produce json
+// @Param id path string true "Ready Score ID"
+// @Param readyscore body ReadyScoreInput true "Ready Score fields to change"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Readyscore
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/id/{id} [patch]
+func (h *ReadyScoreHandler) patchReadyScore(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(ReadyScoreRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	existing, err := ApiDatabase.GetReadyScore(DatabaseContext, id)
+	if err != nil {
+		ErrorLog.Printf("error retrieving ready score with id '%d': %v", id, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	if len(results) < 1 {
-		ErrorLog.Printf("no ready score results from database with '%s' token '%s'", queryType, queryToken)
-		handleError(w, http.StatusNotFound, "no results found")
+	if len(existing) != 1 {
+		InfoLog.Printf("ready score with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Ready Score not found with id %d", id))
 		return
 	}
 
-	readyScores := ReadyScores{
-		Data:      results,
-		NextToken: params.RowLimit + params.RowOffset,
+	// Fields left out of the body keep the values decoded from the stored row
+	input := newReadyScoreInput(existing[0])
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding ready score request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateReadyScore(w, id, input)
+}
+
+func (h *ReadyScoreHandler) updateReadyScore(w http.ResponseWriter, id int64, input ReadyScoreInput) {
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid ready score request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, err := ApiQueries.UpdateReadyScore(DatabaseContext, UpdateReadyScoreParams{ID: id, SaveReadyScoreParams: params})
+	if isUniqueViolation(err) {
+		InfoLog.Printf("ready score with date '%s' already exists", params.Date.Format("2006-01-02"))
+		handleError(w, http.StatusConflict, fmt.Sprintf("Ready Score already exists with date %s", params.Date.Format("2006-01-02")))
+		return
 	}
 
-	jsonBytes, err := json.Marshal(readyScores)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error updating ready score with id '%d': %v", id, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	if len(result) != 1 {
+		InfoLog.Printf("ready score with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Ready Score not found with id %d", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result[0])
+}
+
+// @Summary Delete ready score information by ID
+// @Security ApiKeyAuth
+// @Description Deletes the ready score information with specified ID
+// @Tags readyscore
+// @Param id path string true "Ready Score ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /readyscore/id/{id} [delete]
+func (h *ReadyScoreHandler) deleteReadyScore(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(ReadyScoreRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	result, err := ApiQueries.DeleteReadyScore(DatabaseContext, id)
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v", err)
+		ErrorLog.Printf("error deleting ready score with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("ready score with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Ready Score not found with id %d", id))
+		return
 	}
+
+	InfoLog.Printf("deleted ready score with id '%d'\n", id)
+	w.WriteHeader(http.StatusNoContent)
 }
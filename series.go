@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSeriesWindows = "7,30"
+	DefaultSeriesAlpha   = 0.3
+	MaxSeriesWindow      = 365
+)
+
+type SeriesPoint struct {
+	Date        string             `json:"date"`
+	Value       float64            `json:"value"`
+	RollingMean map[string]float64 `json:"rolling_mean"`
+	Ewma        float64            `json:"ewma"`
+}
+
+type Series struct {
+	Field   string        `json:"field"`
+	Start   string        `json:"start"`
+	End     string        `json:"end"`
+	Windows []int         `json:"windows"`
+	Alpha   float64       `json:"alpha"`
+	Data    []SeriesPoint `json:"data"`
+}
+
+// writeSeries serves a /series request for one metric. Alongside each raw
+// daily value it returns a rolling mean for every requested window of days
+// and an exponentially weighted moving average.
+func writeSeries(w http.ResponseWriter, r *http.Request, metric Metric) {
+	values := r.URL.Query()
+
+	field := values.Get("field")
+	if !metric.HasField(field) {
+		ErrorLog.Printf("invalid %s series field '%s'", metric.Name, field)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid field '%s', expected one of %s", field, strings.Join(metric.Fields, ", ")))
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(values.Get("start"), values.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	windows, err := parseSeriesWindows(values.Get("windows"))
+	if err != nil {
+		ErrorLog.Printf("error parsing series windows from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid windows: %v", err))
+		return
+	}
+
+	alpha := DefaultSeriesAlpha
+	if values.Has("alpha") {
+		alpha, err = strconv.ParseFloat(values.Get("alpha"), 64)
+		if err != nil || alpha <= 0 || alpha > 1 {
+			ErrorLog.Printf("invalid series alpha '%s'", values.Get("alpha"))
+			handleError(w, http.StatusBadRequest, "Invalid alpha, expected a number greater than 0 and at most 1")
+			return
+		}
+	}
+
+	InfoLog.Printf("URL series match '%s' '%s' to '%s'\n", field, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	// Load enough days before start for the first point's widest window to be full
+	params := DateRangeParams{
+		StartDate: startDate.AddDate(0, 0, -(slices.Max(windows) - 1)),
+		EndDate:   endDate,
+	}
+
+	dailyValues, err := metric.Values(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s series between '%s' and '%s': %v", metric.Name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	series := Series{
+		Field:   field,
+		Start:   startDate.Format("2006-01-02"),
+		End:     endDate.Format("2006-01-02"),
+		Windows: windows,
+		Alpha:   alpha,
+		Data:    smoothSeries(dailyValues[field], startDate, windows, alpha),
+	}
+
+	jsonBytes, err := json.Marshal(series)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
+
+func parseSeriesWindows(value string) ([]int, error) {
+	if value == "" {
+		value = DefaultSeriesWindows
+	}
+
+	var windows []int
+	for _, part := range strings.Split(value, ",") {
+		window, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || window < 1 || window > MaxSeriesWindow {
+			return nil, fmt.Errorf("window '%s' must be a whole number of days from 1 to %d", part, MaxSeriesWindow)
+		}
+		if !slices.Contains(windows, window) {
+			windows = append(windows, window)
+		}
+	}
+
+	return windows, nil
+}
+
+// smoothSeries turns daily values in ascending date order into series points
+// from start onward. A rolling mean covers the days recorded in the window
+// ending on the point's date, so missing days do not count as zero. The EWMA
+// is seeded with the first value loaded, including any loaded before start.
+func smoothSeries(dailyValues []DailyValue, start time.Time, windows []int, alpha float64) []SeriesPoint {
+	points := []SeriesPoint{}
+
+	var ewma float64
+	for i, dailyValue := range dailyValues {
+		if i == 0 {
+			ewma = dailyValue.Value
+		} else {
+			ewma = alpha*dailyValue.Value + (1-alpha)*ewma
+		}
+
+		if dailyValue.Date.Before(start) {
+			continue
+		}
+
+		point := SeriesPoint{
+			Date:        dailyValue.Date.Format("2006-01-02"),
+			Value:       dailyValue.Value,
+			RollingMean: map[string]float64{},
+			Ewma:        ewma,
+		}
+
+		for _, window := range windows {
+			windowStart := dailyValue.Date.AddDate(0, 0, -(window - 1))
+
+			var sum float64
+			var count int
+			for j := i; j >= 0 && !dailyValues[j].Date.Before(windowStart); j-- {
+				sum += dailyValues[j].Value
+				count++
+			}
+
+			point.RollingMean[strconv.Itoa(window)] = sum / float64(count)
+		}
+
+		points = append(points, point)
+	}
+
+	return points
+}
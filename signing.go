@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxSignedUrlLifetime is the longest expires_in a signed URL may ask
+	// for. Zero asks for a URL that never expires.
+	MaxSignedUrlLifetime = 5 * 365 * 24 * time.Hour
+)
+
+var (
+	SigningRgx *regexp.Regexp
+
+	// SignableRgx matches the paths that accept a signature in place of a
+	// bearer token. Only images meant for embedding can be signed.
+	SignableRgx *regexp.Regexp
+)
+
+// SigningHandler issues signed URLs. Signatures are the HMAC-SHA256 of the
+// path and sorted query, keyed with Secret.
+type SigningHandler struct {
+	Secret string
+}
+
+type SignUrlInput struct {
+	Url       string `json:"url" example:"/badge/sleep.svg"`
+	ExpiresIn int64  `json:"expires_in" example:"2592000"`
+}
+
+type SignedUrl struct {
+	Url     string `json:"url"`
+	Expires string `json:"expires,omitempty"`
+}
+
+func init() {
+	SigningRgx = regexp.MustCompile(`^/sign$`)
+	SignableRgx = regexp.MustCompile(`^/((sleep|readyscore|heartrate|stress|spo2)/(chart|heatmap)|badge/[a-z0-9]+)\.svg$`)
+}
+
+func (h *SigningHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPost && SigningRgx.MatchString(r.URL.Path):
+		h.signUrl(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Sign an image URL
+// @Security ApiKeyAuth
+// @Description Returns a signed copy of a chart, heatmap or badge URL that can be embedded in pages and
+// @Description status boards without an Authorization header. The signature covers the path and every
+// @Description query parameter, so the URL cannot be changed to show anything else. expires_in is how
+// @Description many seconds the URL stays valid, or 0 for a URL that never expires. Changing
+// @Description SIGNED_URL_SECRET revokes every URL signed before.
+// @Tags signing
+// @Accept json
+// @Produce json
+// @Param input body SignUrlInput true "URL to sign"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} SignedUrl
+// @Failure 400 {object} GenericMessage
+// @Failure 503 {object} GenericMessage
+// @Failure 401
+// @Router /sign [post]
+func (h *SigningHandler) signUrl(w http.ResponseWriter, r *http.Request) {
+	if h.Secret == "" {
+		ErrorLog.Println("url signing requested but SIGNED_URL_SECRET is not set")
+		handleError(w, http.StatusServiceUnavailable, "Signed URLs are not configured")
+		return
+	}
+
+	var input SignUrlInput
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding sign url body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	target, err := url.Parse(input.Url)
+	if err != nil || !SignableRgx.MatchString(target.Path) {
+		ErrorLog.Printf("refused to sign url '%s'", input.Url)
+		handleError(w, http.StatusBadRequest, "Only chart, heatmap and badge URLs can be signed")
+		return
+	}
+
+	lifetime := time.Duration(input.ExpiresIn) * time.Second
+	if input.ExpiresIn < 0 || lifetime > MaxSignedUrlLifetime {
+		ErrorLog.Printf("invalid signed url expires_in %d", input.ExpiresIn)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("expires_in must be from 0 to %d seconds", int64(MaxSignedUrlLifetime.Seconds())))
+		return
+	}
+
+	var expires time.Time
+	if lifetime > 0 {
+		expires = time.Now().Add(lifetime).Truncate(time.Second)
+	}
+
+	query := signQuery(h.Secret, target.Path, target.Query(), expires)
+
+	signed := SignedUrl{Url: fmt.Sprintf("%s%s?%s", requestBaseUrl(r), target.Path, query.Encode())}
+	if !expires.IsZero() {
+		signed.Expires = expires.UTC().Format(time.RFC3339)
+	}
+
+	writeJSON(w, http.StatusOK, signed)
+}
+
+// signQuery returns query with its expires and signature parameters set.
+// A zero expires leaves the signature valid until the secret changes.
+func signQuery(secret string, path string, query url.Values, expires time.Time) url.Values {
+	signed := url.Values{}
+	for key, values := range query {
+		if key != "signature" && key != "expires" {
+			signed[key] = values
+		}
+	}
+	if !expires.IsZero() {
+		signed.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	}
+
+	signed.Set("signature", urlSignature(secret, path, signed))
+	return signed
+}
+
+// verifySignedUrl checks the signature and expiry of a signed URL.
+func verifySignedUrl(secret string, signedUrl *url.URL, now time.Time) error {
+	query := signedUrl.Query()
+
+	given, err := base64.RawURLEncoding.DecodeString(query.Get("signature"))
+	if err != nil {
+		return fmt.Errorf("signature is not base64")
+	}
+
+	expected, _ := base64.RawURLEncoding.DecodeString(urlSignature(secret, signedUrl.Path, query))
+	if !hmac.Equal(given, expected) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	if query.Has("expires") {
+		seconds, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid expires '%s'", query.Get("expires"))
+		}
+		if now.After(time.Unix(seconds, 0)) {
+			return fmt.Errorf("signed url expired at %s", time.Unix(seconds, 0).UTC().Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// urlSignature signs the path and every query parameter but the signature
+// itself, in the sorted order url.Values encodes them.
+func urlSignature(secret string, path string, query url.Values) string {
+	unsigned := url.Values{}
+	for key, values := range query {
+		if key != "signature" {
+			unsigned[key] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path))
+	mac.Write([]byte("?"))
+	mac.Write([]byte(unsigned.Encode()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,46 +1,99 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
 	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
 	"time"
 )
 
 // TODO - create requestId to tie things together in the logs
 
 var (
-	SleepRgxId   *regexp.Regexp
-	SleepListRgx *regexp.Regexp
-	SleepRgxDate *regexp.Regexp
+	SleepRgxId         *regexp.Regexp
+	SleepListRgx       *regexp.Regexp
+	SleepRgxDate       *regexp.Regexp
+	SleepRgxRange      *regexp.Regexp
+	SleepRgxStats      *regexp.Regexp
+	SleepRgxSeries     *regexp.Regexp
+	SleepRgxChart      *regexp.Regexp
+	SleepRgxHeatmap    *regexp.Regexp
+	SleepRgxCollection *regexp.Regexp
 )
 
 type SleepHandler struct{}
 
 type Sleeps struct {
 	Data      []austinapi_db.Sleep `json:"data"`
-	NextToken int32                `json:"next_token"`
+	NextToken string               `json:"next_token,omitempty"`
+	PrevToken string               `json:"prev_token,omitempty"`
+}
+
+type SleepRange struct {
+	Start string               `json:"start"`
+	End   string               `json:"end"`
+	Data  []austinapi_db.Sleep `json:"data"`
+}
+
+// AnnotatedSleep is a sleep with any anomalies found in it on that date
+type AnnotatedSleep struct {
+	austinapi_db.Sleep
+	Anomaly []Anomaly `json:"anomaly"`
+}
+
+// SleepInput is the JSON body accepted when writing sleep. Durations are in
+// seconds. PATCH requests may leave out fields to keep their stored values.
+type SleepInput struct {
+	Date       *string `json:"date"`
+	Rating     *int64  `json:"rating"`
+	TotalSleep *int    `json:"total_sleep"`
+	DeepSleep  *int    `json:"deep_sleep"`
+	LightSleep *int    `json:"light_sleep"`
+	RemSleep   *int    `json:"rem_sleep"`
 }
 
 func init() {
 	SleepRgxId = regexp.MustCompile(`^/sleep/id/([0-9]+)$`)
-	SleepListRgx = regexp.MustCompile(`^/sleep/list(?:\?(next_token)=([0-9]+))?$`)
+	SleepListRgx = regexp.MustCompile(`^/sleep/list$`)
 	SleepRgxDate = regexp.MustCompile(`^/sleep/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	SleepRgxRange = regexp.MustCompile(`^/sleep/range$`)
+	SleepRgxStats = regexp.MustCompile(`^/sleep/stats$`)
+	SleepRgxSeries = regexp.MustCompile(`^/sleep/series$`)
+	SleepRgxChart = regexp.MustCompile(`^/sleep/chart\.svg$`)
+	SleepRgxHeatmap = regexp.MustCompile(`^/sleep/heatmap\.svg$`)
+	SleepRgxCollection = regexp.MustCompile(`^/sleep$`)
 }
 
 func (h *SleepHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	switch {
-	case r.Method == http.MethodGet && SleepListRgx.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && SleepListRgx.MatchString(r.URL.Path):
 		h.listSleep(w, r)
-	case r.Method == http.MethodGet && SleepRgxId.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && SleepRgxId.MatchString(r.URL.Path):
 		h.getSleep(w, r)
-	case r.Method == http.MethodGet && SleepRgxDate.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && SleepRgxDate.MatchString(r.URL.Path):
 		h.getSleepByDate(w, r)
+	case r.Method == http.MethodGet && SleepRgxRange.MatchString(r.URL.Path):
+		h.getSleepByDateRange(w, r)
+	case r.Method == http.MethodGet && SleepRgxStats.MatchString(r.URL.Path):
+		h.getSleepStats(w, r)
+	case r.Method == http.MethodGet && SleepRgxSeries.MatchString(r.URL.Path):
+		h.getSleepSeries(w, r)
+	case r.Method == http.MethodGet && SleepRgxChart.MatchString(r.URL.Path):
+		h.getSleepChart(w, r)
+	case r.Method == http.MethodGet && SleepRgxHeatmap.MatchString(r.URL.Path):
+		h.getSleepHeatmap(w, r)
+	case r.Method == http.MethodPost && SleepRgxCollection.MatchString(r.URL.Path):
+		h.createSleep(w, r)
+	case r.Method == http.MethodPut && SleepRgxId.MatchString(r.URL.Path):
+		h.replaceSleep(w, r)
+	case r.Method == http.MethodPatch && SleepRgxId.MatchString(r.URL.Path):
+		h.patchSleep(w, r)
+	case r.Method == http.MethodDelete && SleepRgxId.MatchString(r.URL.Path):
+		h.deleteSleep(w, r)
 	default:
 		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
@@ -52,12 +105,14 @@ func (h *SleepHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieves sleep information with specified ID
 // @Tags sleep
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param id path string true "Sleep ID"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} austinapi_db.Sleep
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /sleep/id/{id} [get]
 func (h *SleepHandler) getSleep(w http.ResponseWriter, r *http.Request) {
@@ -86,36 +141,28 @@ func (h *SleepHandler) getSleep(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
-	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
-		handleError(w, http.StatusInternalServerError, "Internal Error")
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
-	}
+	writeFormatted(w, r, result[0], result[0], nil)
 
 }
 
 // @Summary Get sleep information by date
 // @Security ApiKeyAuth
 // @Description Retrieves sleep information with specified date
+// @Description along with any anomalies found against the recent personal baseline
 // @Tags sleep
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param date path string true "Date"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
-// @Success 200 {object} austinapi_db.Sleep
+// @Success 200 {object} AnnotatedSleep
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /sleep/date/{date} [get]
 func (h *SleepHandler) getSleepByDate(w http.ResponseWriter, r *http.Request) {
-	sleepDateMatches := SleepRgxDate.FindStringSubmatch(r.URL.String())
+	sleepDateMatches := SleepRgxDate.FindStringSubmatch(r.URL.Path)
 
 	if len(sleepDateMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, SleepRgxDate.String())
@@ -147,94 +194,513 @@ func (h *SleepHandler) getSleepByDate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
+	anomalies, err := annotateAnomalies(SleepMetric, sleepDate)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error detecting sleep anomalies with date '%s': %v", sleepDateString, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	annotated := AnnotatedSleep{
+		Sleep:   result[0],
+		Anomaly: anomalies,
+	}
+
+	writeFormatted(w, r, annotated, annotated, nil)
+
+}
+
+// @Summary Get sleep information for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves sleep information between start and end (inclusive) in ascending order by date
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description A week or month start begins on the first day of that period and a week or month
+// @Description end finishes on the last day of that period. If end is omitted the range covers
+// @Description only the period given by start. CSV and NDJSON are streamed a row at a time as they are read.
+// @Tags sleep
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} SleepRange
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/range [get]
+func (h *SleepHandler) getSleepByDateRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate,
 	}
 
+	err = writeRangeFormatted(w, r, func(each func(austinapi_db.Sleep) error) error {
+		return ApiQueries.EachSleepByDateRange(DatabaseContext, params, each)
+	}, func(results []austinapi_db.Sleep) any {
+		return SleepRange{
+			Start: startDate.Format("2006-01-02"),
+			End:   endDate.Format("2006-01-02"),
+			Data:  results,
+		}
+	})
+	if err != nil {
+		ErrorLog.Printf("error retrieving sleep between '%s' and '%s': %v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+	}
+}
+
+// @Summary Get sleep statistics
+// @Security ApiKeyAuth
+// @Description Retrieves count, mean, median, min, max and standard deviation of each numeric
+// @Description sleep field between start and end (inclusive), grouped by day, week or month.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description Each bucket is identified by its first day. stddev is null for a bucket with one value.
+// @Tags sleep
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param bucket query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Stats
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/stats [get]
+func (h *SleepHandler) getSleepStats(w http.ResponseWriter, r *http.Request) {
+	writeStats(w, r, "sleep", ApiQueries.GetSleepStats)
+}
+
+// @Summary Get smoothed sleep time series
+// @Security ApiKeyAuth
+// @Description Retrieves the daily value of one numeric sleep field between start and end (inclusive)
+// @Description alongside a rolling mean for each window of days and an exponentially weighted moving
+// @Description average (EWMA). Rolling means average the days recorded within each window.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags sleep
+// @Produce json
+// @Param field query string true "Numeric field to return"
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param windows query string false "Comma separated rolling mean windows in days" default(7,30)
+// @Param alpha query number false "EWMA smoothing factor greater than 0 and at most 1" default(0.3)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Series
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/series [get]
+func (h *SleepHandler) getSleepSeries(w http.ResponseWriter, r *http.Request) {
+	writeSeries(w, r, SleepMetric)
+}
+
+// @Summary Get a chart of sleep as SVG
+// @Security ApiKeyAuth
+// @Description Draws one or more numeric sleep fields by day between start and end (inclusive) as a
+// @Description standalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars
+// @Description or bars stacked in the order the fields are given, and band=true shades a line chart between
+// @Description its first and last fields. Sleep durations are drawn in hours. For example field=deep_sleep,rem_sleep,light_sleep&type=stacked
+// @Description stacks each night's sleep stages.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and
+// @Description default to the last 30 days. Days with no value are left as gaps. Charts can be embedded
+// @Description without an Authorization header using a URL signed by /sign.
+// @Tags sleep
+// @Produce image/svg+xml
+// @Param field query string true "Comma separated numeric fields to draw"
+// @Param type query string false "Chart type" Enums(line, bar, stacked) default(line)
+// @Param band query bool false "Shade a line chart between its first and last fields"
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param width query int false "Width in pixels" default(640)
+// @Param height query int false "Height in pixels" default(320)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param colors query string false "Comma separated hex series colors, overriding the theme"
+// @Param background query string false "Hex background color, or none for transparent"
+// @Param title query string false "Title, or empty for none"
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/chart.svg [get]
+func (h *SleepHandler) getSleepChart(w http.ResponseWriter, r *http.Request) {
+	writeChart(w, r, SleepMetric)
+}
+
+// @Summary Get a year heatmap of sleep as SVG
+// @Security ApiKeyAuth
+// @Description Draws a year of one numeric sleep field as a grid of days, one column per week starting
+// @Description on Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls
+// @Description between the lowest and highest of the year, and hovering a day shows its value. field
+// @Description defaults to total_sleep and year to the current year. Heatmaps can be embedded without an
+// @Description Authorization header using a URL signed by /sign.
+// @Tags sleep
+// @Produce image/svg+xml
+// @Param year query int false "Year"
+// @Param field query string false "Numeric field to shade by" default(total_sleep)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/heatmap.svg [get]
+func (h *SleepHandler) getSleepHeatmap(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, SleepMetric)
 }
 
 // @Summary Get list of sleep information
 // @Security ApiKeyAuth
-// @Description Retrieves list of sleep information in descending order by date
+// @Description Retrieves list of sleep information ordered by date, newest first unless order=asc
 // @Description Specifying no query parameters pulls list starting with latest
 // @Description Caller can then specify a next_token from previous calls to go
-// @Description forward in the list of items.
+// @Description forward in the list of items, or a prev_token to go back.
+// @Description Tokens are opaque and remain stable while new items are added.
+// @Description Next, previous and first page URLs are also returned in the Link header.
+// @Description Paging past the end of the list returns an empty data array.
 // @Tags sleep
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param next_token query string false "next list search by next_token" Format(string)
+// @Param prev_token query string false "previous list search by prev_token" Format(string)
+// @Param limit query int false "number of items per page, at most the server list limit"
+// @Param order query string false "order by date" Enums(asc, desc) default(desc)
+// @Param fields query string false "comma separated list of fields to include in each item"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} Sleeps
+// @Failure 400 {object} GenericMessage
 // @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /sleep/list [get]
 func (h *SleepHandler) listSleep(w http.ResponseWriter, r *http.Request) {
-	urlMatches := SleepListRgx.FindStringSubmatch(r.URL.String())
-
-	if len(urlMatches) != 3 {
-		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, SleepListRgx.String())
-		handleError(w, http.StatusInternalServerError, "Issue parsing URL")
+	listQuery, err := parseListQuery(r.URL.Query(), austinapi_db.Sleep{})
+	if err != nil {
+		ErrorLog.Printf("error parsing query string '%s': %v", r.URL.RawQuery, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %v", err))
 		return
 	}
 
-	queryType := urlMatches[1]
-	queryToken := urlMatches[2]
+	InfoLog.Printf("URL directive match '%s'\n", listQuery.Direction)
+	InfoLog.Printf("URL token match '%s'\n", listQuery.Token)
 
-	InfoLog.Printf("URL directive match '%s'\n", queryType)
-	InfoLog.Printf("URL token match '%s'\n", queryToken)
+	var results []austinapi_db.Sleep
 
-	params := austinapi_db.GetSleepsParams{
-		RowOffset: 0,
-		RowLimit:  ListRowLimit,
-	}
+	// One row more than a page is requested to tell whether another page follows
+	rowLimit := listQuery.Limit + 1
 
-	if queryType == "next_token" {
-		rowOffset, err := strconv.ParseInt(queryToken, 10, 32)
-		if err != nil {
-			ErrorLog.Printf("error parsing specified query token '%v': %v", queryToken, err)
+	switch listQuery.Direction {
+	case PageNext, PagePrev:
+		cursor, cursorErr := decodeCursor(listQuery.Token)
+		if cursorErr != nil {
+			ErrorLog.Printf("error parsing specified query token '%v': %v", listQuery.Token, cursorErr)
 			handleError(w, http.StatusBadRequest, "Invalid query token")
 			return
 		}
 
-		params.RowOffset = int32(rowOffset)
+		params := KeysetParams{
+			Date:     cursor.Date,
+			ID:       cursor.ID,
+			RowLimit: rowLimit,
+		}
+
+		if listQuery.FetchesNewer() {
+			results, err = ApiQueries.GetSleepsNewerThanCursor(DatabaseContext, params)
+		} else {
+			results, err = ApiQueries.GetSleepsOlderThanCursor(DatabaseContext, params)
+		}
+	case PageFirst:
+		if listQuery.Order == OrderAsc {
+			results, err = ApiQueries.GetSleepsOldestFirst(DatabaseContext, rowLimit)
+		} else {
+			params := austinapi_db.GetSleepsParams{
+				RowOffset: 0,
+				RowLimit:  rowLimit,
+			}
+
+			results, err = ApiDatabase.GetSleeps(DatabaseContext, params)
+		}
 	}
 
-	results, err := ApiDatabase.GetSleeps(DatabaseContext, params)
 	if err != nil {
 		ErrorLog.Printf("error getting list of sleep: %v", err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	if len(results) < 1 {
-		ErrorLog.Printf("no sleep results from database with '%s' token '%s'", queryType, queryToken)
-		handleError(w, http.StatusNotFound, "no results found")
-		return
+	keep, hasNext, hasPrev := trimPage(listQuery.Direction, len(results), listQuery.Limit)
+	results = results[:keep]
+	if listQuery.Direction == PagePrev {
+		slices.Reverse(results)
 	}
 
 	sleeps := Sleeps{
-		Data:      results,
-		NextToken: params.RowLimit + params.RowOffset,
+		Data: results,
+	}
+
+	if len(results) > 0 {
+		if hasNext {
+			last := results[len(results)-1]
+			sleeps.NextToken = encodeCursor(last.Date, last.ID)
+		}
+		if hasPrev {
+			first := results[0]
+			sleeps.PrevToken = encodeCursor(first.Date, first.ID)
+		}
+	}
+
+	setLinkHeader(w, r, sleeps.NextToken, sleeps.PrevToken)
+
+	writeFormatted(w, r, sleeps, results, listQuery.Fields)
+}
+
+func newSleepInput(sleep austinapi_db.Sleep) SleepInput {
+	date := sleep.Date.Format("2006-01-02")
+	return SleepInput{
+		Date:       &date,
+		Rating:     &sleep.Rating,
+		TotalSleep: &sleep.TotalSleep,
+		DeepSleep:  &sleep.DeepSleep,
+		LightSleep: &sleep.LightSleep,
+		RemSleep:   &sleep.RemSleep,
+	}
+}
+
+func (i SleepInput) params() (austinapi_db.SaveSleepParams, error) {
+	var params austinapi_db.SaveSleepParams
+	var err error
+
+	if params.Date, err = parseInputDate(i.Date); err != nil {
+		return params, err
+	}
+	if params.Rating, err = checkRange("rating", i.Rating, 0, 100); err != nil {
+		return params, err
+	}
+	if params.TotalSleep, err = checkRange("total_sleep", i.TotalSleep, 0, MaxDailySeconds); err != nil {
+		return params, err
+	}
+	if params.DeepSleep, err = checkRange("deep_sleep", i.DeepSleep, 0, MaxDailySeconds); err != nil {
+		return params, err
+	}
+	if params.LightSleep, err = checkRange("light_sleep", i.LightSleep, 0, MaxDailySeconds); err != nil {
+		return params, err
+	}
+	if params.RemSleep, err = checkRange("rem_sleep", i.RemSleep, 0, MaxDailySeconds); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// @Summary Save sleep information
+// @Security ApiKeyAuth
+// @Description Saves sleep information for a date. If sleep already exists for the date it is
+// @Description updated and 200 is returned, otherwise it is created and 201 is returned.
+// @Tags sleep
+// @Accept json
+// @Produce json
+// @Param sleep body SleepInput true "Sleep"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Sleep
+// @Success 201 {object} austinapi_db.Sleep
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep [post]
+func (h *SleepHandler) createSleep(w http.ResponseWriter, r *http.Request) {
+	var input SleepInput
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding sleep request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid sleep request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, inserted, err := ApiQueries.UpsertSleep(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error saving sleep with date '%s': %v", params.Date.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	InfoLog.Printf("saved sleep with id '%d' inserted '%t'\n", result.ID, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/sleep/id/%d", result.ID))
+	writeJSON(w, statusCode, result)
+}
+
+// @Summary Replace sleep information by ID
+// @Security ApiKeyAuth
+// @Description Replaces all fields of the sleep information with specified ID
+// @Tags sleep
+// @Accept json
+// @Produce json
+// @Param id path string true "Sleep ID"
+// @Param sleep body SleepInput true "Sleep"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Sleep
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/id/{id} [put]
+func (h *SleepHandler) replaceSleep(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(SleepRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	var input SleepInput
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding sleep request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateSleep(w, id, input)
+}
+
+// @Summary Update sleep information by ID
+// @Security ApiKeyAuth
+// @Description Updates only the fields given for the sleep information with specified ID
+// @Tags sleep
+// @Accept json
+// @Produce json
+// @Param id path string true "Sleep ID"
+// @Param sleep body SleepInput true "Sleep fields to change"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Sleep
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/id/{id} [patch]
+func (h *SleepHandler) patchSleep(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(SleepRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	existing, err := ApiDatabase.GetSleep(DatabaseContext, id)
+	if err != nil {
+		ErrorLog.Printf("error retrieving sleep with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(existing) != 1 {
+		InfoLog.Printf("sleep with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Sleep not found with id %d", id))
+		return
 	}
 
-	jsonBytes, err := json.Marshal(sleeps)
+	// Fields left out of the body keep the values decoded from the stored row
+	input := newSleepInput(existing[0])
+	err = decodeBody(r, &input)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error decoding sleep request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateSleep(w, id, input)
+}
+
+func (h *SleepHandler) updateSleep(w http.ResponseWriter, id int64, input SleepInput) {
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid sleep request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, err := ApiQueries.UpdateSleep(DatabaseContext, UpdateSleepParams{ID: id, SaveSleepParams: params})
+	if isUniqueViolation(err) {
+		InfoLog.Printf("sleep with date '%s' already exists", params.Date.Format("2006-01-02"))
+		handleError(w, http.StatusConflict, fmt.Sprintf("Sleep already exists with date %s", params.Date.Format("2006-01-02")))
+		return
+	}
+
+	if err != nil {
+		ErrorLog.Printf("error updating sleep with id '%d': %v", id, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	if len(result) != 1 {
+		InfoLog.Printf("sleep with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Sleep not found with id %d", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result[0])
+}
+
+// @Summary Delete sleep information by ID
+// @Security ApiKeyAuth
+// @Description Deletes the sleep information with specified ID
+// @Tags sleep
+// @Param id path string true "Sleep ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /sleep/id/{id} [delete]
+func (h *SleepHandler) deleteSleep(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(SleepRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	result, err := ApiQueries.DeleteSleep(DatabaseContext, id)
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v", err)
+		ErrorLog.Printf("error deleting sleep with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
 	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("sleep with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Sleep not found with id %d", id))
+		return
+	}
+
+	InfoLog.Printf("deleted sleep with id '%d'\n", id)
+	w.WriteHeader(http.StatusNoContent)
 }
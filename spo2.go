@@ -1,44 +1,93 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
 	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
 	"time"
 )
 
 var (
-	Spo2RgxId   *regexp.Regexp
-	Spo2ListRgx *regexp.Regexp
-	Spo2RgxDate *regexp.Regexp
+	Spo2RgxId         *regexp.Regexp
+	Spo2ListRgx       *regexp.Regexp
+	Spo2RgxDate       *regexp.Regexp
+	Spo2RgxRange      *regexp.Regexp
+	Spo2RgxStats      *regexp.Regexp
+	Spo2RgxSeries     *regexp.Regexp
+	Spo2RgxChart      *regexp.Regexp
+	Spo2RgxHeatmap    *regexp.Regexp
+	Spo2RgxCollection *regexp.Regexp
 )
 
 type Spo2Handler struct{}
 
 type Spo2s struct {
 	Data      []austinapi_db.Spo2 `json:"data"`
-	NextToken int32               `json:"next_token"`
+	NextToken string              `json:"next_token,omitempty"`
+	PrevToken string              `json:"prev_token,omitempty"`
+}
+
+type Spo2Range struct {
+	Start string              `json:"start"`
+	End   string              `json:"end"`
+	Data  []austinapi_db.Spo2 `json:"data"`
+}
+
+// AnnotatedSpo2 is a spo2 with any anomalies found in it on that date
+type AnnotatedSpo2 struct {
+	austinapi_db.Spo2
+	Anomaly []Anomaly `json:"anomaly"`
+}
+
+// Spo2Input is the JSON body accepted when writing spo2 as a percentage.
+// PATCH requests may leave out fields to keep their stored values.
+type Spo2Input struct {
+	Date        *string  `json:"date"`
+	AverageSpo2 *float64 `json:"average_spo2"`
 }
 
 func init() {
 	Spo2RgxId = regexp.MustCompile(`^/spo2/id/([0-9]+)$`)
-	Spo2ListRgx = regexp.MustCompile(`^/spo2/list(?:\?(next_token)=([0-9]+))?$`)
+	Spo2ListRgx = regexp.MustCompile(`^/spo2/list$`)
 	Spo2RgxDate = regexp.MustCompile(`^/spo2/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	Spo2RgxRange = regexp.MustCompile(`^/spo2/range$`)
+	Spo2RgxStats = regexp.MustCompile(`^/spo2/stats$`)
+	Spo2RgxSeries = regexp.MustCompile(`^/spo2/series$`)
+	Spo2RgxChart = regexp.MustCompile(`^/spo2/chart\.svg$`)
+	Spo2RgxHeatmap = regexp.MustCompile(`^/spo2/heatmap\.svg$`)
+	Spo2RgxCollection = regexp.MustCompile(`^/spo2$`)
 }
 
 func (h *Spo2Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	switch {
-	case r.Method == http.MethodGet && Spo2ListRgx.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && Spo2ListRgx.MatchString(r.URL.Path):
 		h.listSpo2(w, r)
-	case r.Method == http.MethodGet && Spo2RgxId.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && Spo2RgxId.MatchString(r.URL.Path):
 		h.getSpo2(w, r)
-	case r.Method == http.MethodGet && Spo2RgxDate.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && Spo2RgxDate.MatchString(r.URL.Path):
 		h.getSpo2ByDate(w, r)
+	case r.Method == http.MethodGet && Spo2RgxRange.MatchString(r.URL.Path):
+		h.getSpo2ByDateRange(w, r)
+	case r.Method == http.MethodGet && Spo2RgxStats.MatchString(r.URL.Path):
+		h.getSpo2Stats(w, r)
+	case r.Method == http.MethodGet && Spo2RgxSeries.MatchString(r.URL.Path):
+		h.getSpo2Series(w, r)
+	case r.Method == http.MethodGet && Spo2RgxChart.MatchString(r.URL.Path):
+		h.getSpo2Chart(w, r)
+	case r.Method == http.MethodGet && Spo2RgxHeatmap.MatchString(r.URL.Path):
+		h.getSpo2Heatmap(w, r)
+	case r.Method == http.MethodPost && Spo2RgxCollection.MatchString(r.URL.Path):
+		h.createSpo2(w, r)
+	case r.Method == http.MethodPut && Spo2RgxId.MatchString(r.URL.Path):
+		h.replaceSpo2(w, r)
+	case r.Method == http.MethodPatch && Spo2RgxId.MatchString(r.URL.Path):
+		h.patchSpo2(w, r)
+	case r.Method == http.MethodDelete && Spo2RgxId.MatchString(r.URL.Path):
+		h.deleteSpo2(w, r)
 	default:
 		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
@@ -49,12 +98,14 @@ func (h *Spo2Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieves Spo2 information with specified ID
 // @Tags spo2
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param id path string true "Spo2 ID"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} austinapi_db.Spo2
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /spo2/id/{id} [get]
 func (h *Spo2Handler) getSpo2(w http.ResponseWriter, r *http.Request) {
@@ -82,36 +133,28 @@ func (h *Spo2Handler) getSpo2(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
-	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
-		handleError(w, http.StatusInternalServerError, "Internal Error")
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
-	}
+	writeFormatted(w, r, result[0], result[0], nil)
 
 }
 
 // @Summary Get spo2 information by date
 // @Security ApiKeyAuth
 // @Description Retrieves spo2 information with specified date
+// @Description along with any anomalies found against the recent personal baseline
 // @Tags spo2
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param date path string true "Date"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
-// @Success 200 {object} austinapi_db.Spo2
+// @Success 200 {object} AnnotatedSpo2
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /spo2/date/{date} [get]
 func (h *Spo2Handler) getSpo2ByDate(w http.ResponseWriter, r *http.Request) {
-	dateMatches := Spo2RgxDate.FindStringSubmatch(r.URL.String())
+	dateMatches := Spo2RgxDate.FindStringSubmatch(r.URL.Path)
 
 	if len(dateMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, Spo2RgxDate.String())
@@ -143,95 +186,497 @@ func (h *Spo2Handler) getSpo2ByDate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
+	anomalies, err := annotateAnomalies(Spo2Metric, date)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error detecting spo2 anomalies with date '%s': %v", dateString, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	annotated := AnnotatedSpo2{
+		Spo2:    result[0],
+		Anomaly: anomalies,
+	}
+
+	writeFormatted(w, r, annotated, annotated, nil)
+
+}
+
+// @Summary Get spo2 information for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves spo2 information between start and end (inclusive) in ascending order by date
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description A week or month start begins on the first day of that period and a week or month
+// @Description end finishes on the last day of that period. If end is omitted the range covers
+// @Description only the period given by start. CSV and NDJSON are streamed a row at a time as they are read.
+// @Tags spo2
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Spo2Range
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/range [get]
+func (h *Spo2Handler) getSpo2ByDateRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	err = writeRangeFormatted(w, r, func(each func(austinapi_db.Spo2) error) error {
+		return ApiQueries.EachSpo2ByDateRange(DatabaseContext, params, each)
+	}, func(results []austinapi_db.Spo2) any {
+		return Spo2Range{
+			Start: startDate.Format("2006-01-02"),
+			End:   endDate.Format("2006-01-02"),
+			Data:  results,
+		}
+	})
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
+		ErrorLog.Printf("error retrieving spo2 between '%s' and '%s': %v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
 	}
+}
+
+// @Summary Get spo2 statistics
+// @Security ApiKeyAuth
+// @Description Retrieves count, mean, median, min, max and standard deviation of each numeric
+// @Description spo2 field between start and end (inclusive), grouped by day, week or month.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description Each bucket is identified by its first day. stddev is null for a bucket with one value.
+// @Tags spo2
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param bucket query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Stats
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/stats [get]
+func (h *Spo2Handler) getSpo2Stats(w http.ResponseWriter, r *http.Request) {
+	writeStats(w, r, "spo2", ApiQueries.GetSpo2Stats)
+}
+
+// @Summary Get smoothed spo2 time series
+// @Security ApiKeyAuth
+// @Description Retrieves the daily value of one numeric spo2 field between start and end (inclusive)
+// @Description alongside a rolling mean for each window of days and an exponentially weighted moving
+// @Description average (EWMA). Rolling means average the days recorded within each window.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags spo2
+// @Produce json
+// @Param field query string true "Numeric field to return"
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param windows query string false "Comma separated rolling mean windows in days" default(7,30)
+// @Param alpha query number false "EWMA smoothing factor greater than 0 and at most 1" default(0.3)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Series
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/series [get]
+func (h *Spo2Handler) getSpo2Series(w http.ResponseWriter, r *http.Request) {
+	writeSeries(w, r, Spo2Metric)
+}
 
+// @Summary Get a chart of spo2 as SVG
+// @Security ApiKeyAuth
+// @Description Draws one or more numeric spo2 fields by day between start and end (inclusive) as a
+// @Description standalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars
+// @Description or bars stacked in the order the fields are given, and band=true shades a line chart between
+// @Description its first and last fields. For example field=average_spo2 draws a line of the daily average.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and
+// @Description default to the last 30 days. Days with no value are left as gaps. Charts can be embedded
+// @Description without an Authorization header using a URL signed by /sign.
+// @Tags spo2
+// @Produce image/svg+xml
+// @Param field query string true "Comma separated numeric fields to draw"
+// @Param type query string false "Chart type" Enums(line, bar, stacked) default(line)
+// @Param band query bool false "Shade a line chart between its first and last fields"
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param width query int false "Width in pixels" default(640)
+// @Param height query int false "Height in pixels" default(320)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param colors query string false "Comma separated hex series colors, overriding the theme"
+// @Param background query string false "Hex background color, or none for transparent"
+// @Param title query string false "Title, or empty for none"
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/chart.svg [get]
+func (h *Spo2Handler) getSpo2Chart(w http.ResponseWriter, r *http.Request) {
+	writeChart(w, r, Spo2Metric)
+}
+
+// @Summary Get a year heatmap of spo2 as SVG
+// @Security ApiKeyAuth
+// @Description Draws a year of one numeric spo2 field as a grid of days, one column per week starting
+// @Description on Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls
+// @Description between the lowest and highest of the year, and hovering a day shows its value. field
+// @Description defaults to average_spo2 and year to the current year. Heatmaps can be embedded without an
+// @Description Authorization header using a URL signed by /sign.
+// @Tags spo2
+// @Produce image/svg+xml
+// @Param year query int false "Year"
+// @Param field query string false "Numeric field to shade by" default(average_spo2)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/heatmap.svg [get]
+func (h *Spo2Handler) getSpo2Heatmap(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, Spo2Metric)
 }
 
 // @Summary Get list of spo2 information
 // @Security ApiKeyAuth
-// @Description Retrieves list of spo2 information in descending order by date
+// @Description Retrieves list of spo2 information ordered by date, newest first unless order=asc
 // @Description Specifying no query parameters pulls list starting with latest
 // @Description Caller can then specify a next_token from previous calls to go
-// @Description forward in the list of items.
+// @Description forward in the list of items, or a prev_token to go back.
+// @Description Tokens are opaque and remain stable while new items are added.
+// @Description Next, previous and first page URLs are also returned in the Link header.
+// @Description Paging past the end of the list returns an empty data array.
 // @Tags spo2
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param next_token query string false "next list search by next_token" Format(string)
+// @Param prev_token query string false "previous list search by prev_token" Format(string)
+// @Param limit query int false "number of items per page, at most the server list limit"
+// @Param order query string false "order by date" Enums(asc, desc) default(desc)
+// @Param fields query string false "comma separated list of fields to include in each item"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} Spo2s
+// @Failure 400 {object} GenericMessage
 // @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /spo2/list [get]
 func (h *Spo2Handler) listSpo2(w http.ResponseWriter, r *http.Request) {
-	urlMatches := Spo2ListRgx.FindStringSubmatch(r.URL.String())
-
-	if len(urlMatches) != 3 {
-		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, Spo2ListRgx.String())
-		handleError(w, http.StatusInternalServerError, "Issue parsing URL")
+	listQuery, err := parseListQuery(r.URL.Query(), austinapi_db.Spo2{})
+	if err != nil {
+		ErrorLog.Printf("error parsing query string '%s': %v", r.URL.RawQuery, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %v", err))
 		return
 	}
 
-	queryType := urlMatches[1]
-	queryToken := urlMatches[2]
+	InfoLog.Printf("URL directive match '%s'\n", listQuery.Direction)
+	InfoLog.Printf("URL token match '%s'\n", listQuery.Token)
 
-	InfoLog.Printf("URL directive match '%s'\n", queryType)
-	InfoLog.Printf("URL token match '%s'\n", queryToken)
+	var results []austinapi_db.Spo2
 
-	params := austinapi_db.GetSpo2sParams{
-		RowOffset: 0,
-		RowLimit:  ListRowLimit,
-	}
+	// One row more than a page is requested to tell whether another page follows
+	rowLimit := listQuery.Limit + 1
 
-	if queryType == "next_token" {
-		rowOffset, err := strconv.ParseInt(queryToken, 10, 32)
-		if err != nil {
-			ErrorLog.Printf("error parsing specified query token '%v': %v", queryToken, err)
+	switch listQuery.Direction {
+	case PageNext, PagePrev:
+		cursor, cursorErr := decodeCursor(listQuery.Token)
+		if cursorErr != nil {
+			ErrorLog.Printf("error parsing specified query token '%v': %v", listQuery.Token, cursorErr)
 			handleError(w, http.StatusBadRequest, "Invalid query token")
 			return
 		}
 
-		params.RowOffset = int32(rowOffset)
+		params := KeysetParams{
+			Date:     cursor.Date,
+			ID:       cursor.ID,
+			RowLimit: rowLimit,
+		}
+
+		if listQuery.FetchesNewer() {
+			results, err = ApiQueries.GetSpo2sNewerThanCursor(DatabaseContext, params)
+		} else {
+			results, err = ApiQueries.GetSpo2sOlderThanCursor(DatabaseContext, params)
+		}
+	case PageFirst:
+		if listQuery.Order == OrderAsc {
+			results, err = ApiQueries.GetSpo2sOldestFirst(DatabaseContext, rowLimit)
+		} else {
+			params := austinapi_db.GetSpo2sParams{
+				RowOffset: 0,
+				RowLimit:  rowLimit,
+			}
+
+			results, err = ApiDatabase.GetSpo2s(DatabaseContext, params)
+		}
 	}
 
-	results, err := ApiDatabase.GetSpo2s(DatabaseContext, params)
 	if err != nil {
 		ErrorLog.Printf("error getting list of spo2: %v", err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	if len(results) < 1 {
-		ErrorLog.Printf("no spo2 results from database with '%s' token '%s'", queryType, queryToken)
-		handleError(w, http.StatusNotFound, "no results found")
-		return
+	keep, hasNext, hasPrev := trimPage(listQuery.Direction, len(results), listQuery.Limit)
+	results = results[:keep]
+	if listQuery.Direction == PagePrev {
+		slices.Reverse(results)
 	}
 
 	spo2s := Spo2s{
-		Data:      results,
-		NextToken: params.RowLimit + params.RowOffset,
+		Data: results,
+	}
+
+	if len(results) > 0 {
+		if hasNext {
+			last := results[len(results)-1]
+			spo2s.NextToken = encodeCursor(last.Date, last.ID)
+		}
+		if hasPrev {
+			first := results[0]
+			spo2s.PrevToken = encodeCursor(first.Date, first.ID)
+		}
+	}
+
+	setLinkHeader(w, r, spo2s.NextToken, spo2s.PrevToken)
+
+	writeFormatted(w, r, spo2s, results, listQuery.Fields)
+
+}
+
+func newSpo2Input(spo2 austinapi_db.Spo2) Spo2Input {
+	date := spo2.Date.Format("2006-01-02")
+	return Spo2Input{
+		Date:        &date,
+		AverageSpo2: &spo2.AverageSpo2,
+	}
+}
+
+func (i Spo2Input) params() (austinapi_db.SaveSpo2Params, error) {
+	var params austinapi_db.SaveSpo2Params
+	var err error
+
+	if params.Date, err = parseInputDate(i.Date); err != nil {
+		return params, err
+	}
+	if params.AverageSpo2, err = checkRange("average_spo2", i.AverageSpo2, 0, 100); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// @Summary Save spo2 information
+// @Security ApiKeyAuth
+// @Description Saves spo2 information for a date. If spo2 already exists for the date it is
+// @Description updated and 200 is returned, otherwise it is created and 201 is returned.
+// @Tags spo2
+// @Accept json
+// @Produce json
+// @Param spo2 body Spo2Input true "Spo2"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Spo2
+// @Success 201 {object} austinapi_db.Spo2
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2 [post]
+func (h *Spo2Handler) createSpo2(w http.ResponseWriter, r *http.Request) {
+	var input Spo2Input
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding spo2 request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid spo2 request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
 	}
 
-	jsonBytes, err := json.Marshal(spo2s)
+	result, inserted, err := ApiQueries.UpsertSpo2(DatabaseContext, params)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error saving spo2 with date '%s': %v", params.Date.Format("2006-01-02"), err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	InfoLog.Printf("saved spo2 with id '%d' inserted '%t'\n", result.ID, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/spo2/id/%d", result.ID))
+	writeJSON(w, statusCode, result)
+}
+
+// @Summary Replace spo2 information by ID
+// @Security ApiKeyAuth
+// @Description Replaces all fields of the spo2 information with specified ID
+// @Tags spo2
+// @Accept json
+// @Produce json
+// @Param id path string true "Spo2 ID"
+// @Param spo2 body Spo2Input true "Spo2"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Spo2
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/id/{id} [put]
+func (h *Spo2Handler) replaceSpo2(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(Spo2RgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	var input Spo2Input
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding spo2 request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateSpo2(w, id, input)
+}
+
+// @Summary Update spo2 information by ID
+// @Security ApiKeyAuth
+// @Description Updates only the fields given for the spo2 information with specified ID
+// @Tags spo2
+// @Accept json
+// @Produce json
+// @Param id path string true "Spo2 ID"
+// @Param spo2 body Spo2Input true "Spo2 fields to change"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Spo2
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/id/{id} [patch]
+func (h *Spo2Handler) patchSpo2(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(Spo2RgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	existing, err := ApiDatabase.GetSpo2(DatabaseContext, id)
+	if err != nil {
+		ErrorLog.Printf("error retrieving spo2 with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(existing) != 1 {
+		InfoLog.Printf("spo2 with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Spo2 not found with id %d", id))
+		return
+	}
+
+	// Fields left out of the body keep the values decoded from the stored row
+	input := newSpo2Input(existing[0])
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding spo2 request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateSpo2(w, id, input)
+}
+
+func (h *Spo2Handler) updateSpo2(w http.ResponseWriter, id int64, input Spo2Input) {
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid spo2 request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, err := ApiQueries.UpdateSpo2(DatabaseContext, UpdateSpo2Params{ID: id, SaveSpo2Params: params})
+	if isUniqueViolation(err) {
+		InfoLog.Printf("spo2 with date '%s' already exists", params.Date.Format("2006-01-02"))
+		handleError(w, http.StatusConflict, fmt.Sprintf("Spo2 already exists with date %s", params.Date.Format("2006-01-02")))
+		return
+	}
+
+	if err != nil {
+		ErrorLog.Printf("error updating spo2 with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("spo2 with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Spo2 not found with id %d", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result[0])
+}
+
+// @Summary Delete spo2 information by ID
+// @Security ApiKeyAuth
+// @Description Deletes the spo2 information with specified ID
+// @Tags spo2
+// @Param id path string true "Spo2 ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /spo2/id/{id} [delete]
+func (h *Spo2Handler) deleteSpo2(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(Spo2RgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	result, err := ApiQueries.DeleteSpo2(DatabaseContext, id)
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v", err)
+		ErrorLog.Printf("error deleting spo2 with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("spo2 with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Spo2 not found with id %d", id))
+		return
 	}
 
+	InfoLog.Printf("deleted spo2 with id '%d'\n", id)
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,19 @@
+CREATE TABLE csv_profile (
+    name TEXT NOT NULL,
+    profile JSONB NOT NULL,
+    created_timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (name)
+);
+
+CREATE OR REPLACE FUNCTION update_csv_profile_updated_timestamp()
+    RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_timestamp = CURRENT_TIMESTAMP;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER csv_profile_updated_timestamp_trigger
+    BEFORE UPDATE ON csv_profile
+    FOR EACH ROW EXECUTE FUNCTION update_csv_profile_updated_timestamp();
@@ -0,0 +1,20 @@
+CREATE TABLE row_source (
+    metric TEXT NOT NULL,
+    date DATE NOT NULL,
+    source TEXT NOT NULL,
+    detail TEXT NOT NULL,
+    updated_timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (metric, date)
+);
+
+CREATE OR REPLACE FUNCTION update_row_source_updated_timestamp()
+    RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_timestamp = CURRENT_TIMESTAMP;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER row_source_updated_timestamp_trigger
+    BEFORE UPDATE ON row_source
+    FOR EACH ROW EXECUTE FUNCTION update_row_source_updated_timestamp();
@@ -0,0 +1,19 @@
+CREATE TABLE sync_state (
+    source TEXT NOT NULL,
+    metric TEXT NOT NULL,
+    high_water_mark DATE NOT NULL,
+    updated_timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (source, metric)
+);
+
+CREATE OR REPLACE FUNCTION update_sync_state_updated_timestamp()
+    RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_timestamp = CURRENT_TIMESTAMP;
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER sync_state_updated_timestamp_trigger
+    BEFORE UPDATE ON sync_state
+    FOR EACH ROW EXECUTE FUNCTION update_sync_state_updated_timestamp();
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+var StatsBuckets = []string{"day", "week", "month"}
+
+type FieldStats struct {
+	Count  int64    `json:"count"`
+	Mean   float64  `json:"mean"`
+	Median float64  `json:"median"`
+	Min    float64  `json:"min"`
+	Max    float64  `json:"max"`
+	StdDev *float64 `json:"stddev"`
+}
+
+// BucketStats holds the statistics for each numeric field over one day,
+// week or month. Bucket is the first day of the period.
+type BucketStats struct {
+	Bucket string                `json:"bucket"`
+	Fields map[string]FieldStats `json:"fields"`
+}
+
+type Stats struct {
+	Start  string        `json:"start"`
+	End    string        `json:"end"`
+	Bucket string        `json:"bucket"`
+	Data   []BucketStats `json:"data"`
+}
+
+// writeStats serves a /stats request for one metric using the given query.
+// The query string is the same start and end accepted by /range plus an
+// optional bucket of day, week or month.
+func writeStats(w http.ResponseWriter, r *http.Request, label string, query func(context.Context, StatsParams) ([]BucketStats, error)) {
+	values := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(values.Get("start"), values.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	bucket := values.Get("bucket")
+	if bucket == "" {
+		bucket = "day"
+	}
+
+	if !slices.Contains(StatsBuckets, bucket) {
+		ErrorLog.Printf("invalid stats bucket '%s'", bucket)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid bucket '%s', expected day, week or month", bucket))
+		return
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s' by '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), bucket)
+
+	params := StatsParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Bucket:    bucket,
+	}
+
+	results, err := query(DatabaseContext, params)
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s stats between '%s' and '%s': %v", label, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	stats := Stats{
+		Start:  startDate.Format("2006-01-02"),
+		End:    endDate.Format("2006-01-02"),
+		Bucket: bucket,
+		Data:   results,
+	}
+
+	jsonBytes, err := json.Marshal(stats)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
@@ -1,44 +1,93 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/austinmoody/austinapi_db/austinapi_db"
 	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
 	"time"
 )
 
 var (
-	StressRgxId   *regexp.Regexp
-	StressListRgx *regexp.Regexp
-	StressRgxDate *regexp.Regexp
+	StressRgxId         *regexp.Regexp
+	StressListRgx       *regexp.Regexp
+	StressRgxDate       *regexp.Regexp
+	StressRgxRange      *regexp.Regexp
+	StressRgxStats      *regexp.Regexp
+	StressRgxSeries     *regexp.Regexp
+	StressRgxChart      *regexp.Regexp
+	StressRgxHeatmap    *regexp.Regexp
+	StressRgxCollection *regexp.Regexp
 )
 
 type StressHandler struct{}
 
 type Stresses struct {
 	Data      []austinapi_db.Stress `json:"data"`
-	NextToken int32                 `json:"next_token"`
+	NextToken string                `json:"next_token,omitempty"`
+	PrevToken string                `json:"prev_token,omitempty"`
+}
+
+type StressRange struct {
+	Start string                `json:"start"`
+	End   string                `json:"end"`
+	Data  []austinapi_db.Stress `json:"data"`
+}
+
+// AnnotatedStress is a stress with any anomalies found in it on that date
+type AnnotatedStress struct {
+	austinapi_db.Stress
+	Anomaly []Anomaly `json:"anomaly"`
+}
+
+// StressInput is the JSON body accepted when writing stress. The duration is
+// in seconds. PATCH requests may leave out fields to keep their stored values.
+type StressInput struct {
+	Date               *string `json:"date"`
+	HighStressDuration *int64  `json:"high_stress_duration"`
 }
 
 func init() {
 	StressRgxId = regexp.MustCompile(`^/stress/id/([0-9]+)$`)
-	StressListRgx = regexp.MustCompile(`^/stress/list(?:\?(next_token)=([0-9]+))?$`)
+	StressListRgx = regexp.MustCompile(`^/stress/list$`)
 	StressRgxDate = regexp.MustCompile(`^/stress/date/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	StressRgxRange = regexp.MustCompile(`^/stress/range$`)
+	StressRgxStats = regexp.MustCompile(`^/stress/stats$`)
+	StressRgxSeries = regexp.MustCompile(`^/stress/series$`)
+	StressRgxChart = regexp.MustCompile(`^/stress/chart\.svg$`)
+	StressRgxHeatmap = regexp.MustCompile(`^/stress/heatmap\.svg$`)
+	StressRgxCollection = regexp.MustCompile(`^/stress$`)
 }
 
 func (h *StressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	switch {
-	case r.Method == http.MethodGet && StressListRgx.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && StressListRgx.MatchString(r.URL.Path):
 		h.listStress(w, r)
-	case r.Method == http.MethodGet && StressRgxId.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && StressRgxId.MatchString(r.URL.Path):
 		h.getStress(w, r)
-	case r.Method == http.MethodGet && StressRgxDate.MatchString(r.URL.String()):
+	case r.Method == http.MethodGet && StressRgxDate.MatchString(r.URL.Path):
 		h.getStressByDate(w, r)
+	case r.Method == http.MethodGet && StressRgxRange.MatchString(r.URL.Path):
+		h.getStressByDateRange(w, r)
+	case r.Method == http.MethodGet && StressRgxStats.MatchString(r.URL.Path):
+		h.getStressStats(w, r)
+	case r.Method == http.MethodGet && StressRgxSeries.MatchString(r.URL.Path):
+		h.getStressSeries(w, r)
+	case r.Method == http.MethodGet && StressRgxChart.MatchString(r.URL.Path):
+		h.getStressChart(w, r)
+	case r.Method == http.MethodGet && StressRgxHeatmap.MatchString(r.URL.Path):
+		h.getStressHeatmap(w, r)
+	case r.Method == http.MethodPost && StressRgxCollection.MatchString(r.URL.Path):
+		h.createStress(w, r)
+	case r.Method == http.MethodPut && StressRgxId.MatchString(r.URL.Path):
+		h.replaceStress(w, r)
+	case r.Method == http.MethodPatch && StressRgxId.MatchString(r.URL.Path):
+		h.patchStress(w, r)
+	case r.Method == http.MethodDelete && StressRgxId.MatchString(r.URL.Path):
+		h.deleteStress(w, r)
 	default:
 		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
@@ -49,12 +98,14 @@ func (h *StressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieves stress information with specified ID
 // @Tags stress
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param id path string true "Stress ID"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} austinapi_db.Stress
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /stress/id/{id} [get]
 func (h *StressHandler) getStress(w http.ResponseWriter, r *http.Request) {
@@ -83,37 +134,29 @@ func (h *StressHandler) getStress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
-	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
-		handleError(w, http.StatusInternalServerError, "Internal Error")
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
-	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
-	}
+	writeFormatted(w, r, result[0], result[0], nil)
 
 }
 
 // @Summary Get stress information by date
 // @Security ApiKeyAuth
 // @Description Retrieves stress information with specified date
+// @Description along with any anomalies found against the recent personal baseline
 // @Tags stress
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param date path string true "Date"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
-// @Success 200 {object} austinapi_db.Stress
+// @Success 200 {object} AnnotatedStress
 // @Failure 500 {object} GenericMessage
 // @Failure 404 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /stress/date/{date} [get]
 func (h *StressHandler) getStressByDate(w http.ResponseWriter, r *http.Request) {
 
-	dateMatches := StressRgxDate.FindStringSubmatch(r.URL.String())
+	dateMatches := StressRgxDate.FindStringSubmatch(r.URL.Path)
 
 	if len(dateMatches) < 2 {
 		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, StressRgxDate.String())
@@ -145,95 +188,497 @@ func (h *StressHandler) getStressByDate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	jsonBytes, err := json.Marshal(result[0])
+	anomalies, err := annotateAnomalies(StressMetric, date)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error detecting stress anomalies with date '%s': %v", dateString, err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	annotated := AnnotatedStress{
+		Stress:  result[0],
+		Anomaly: anomalies,
+	}
+
+	writeFormatted(w, r, annotated, annotated, nil)
+
+}
+
+// @Summary Get stress information for a date range
+// @Security ApiKeyAuth
+// @Description Retrieves stress information between start and end (inclusive) in ascending order by date
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description A week or month start begins on the first day of that period and a week or month
+// @Description end finishes on the last day of that period. If end is omitted the range covers
+// @Description only the period given by start. CSV and NDJSON are streamed a row at a time as they are read.
+// @Tags stress
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} StressRange
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
+// @Failure 401
+// @Router /stress/range [get]
+func (h *StressHandler) getStressByDateRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startDate, endDate, err := parseDateRange(query.Get("start"), query.Get("end"))
+	if err != nil {
+		ErrorLog.Printf("error parsing date range from url '%s': %v", r.URL.String(), err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid date range: %v", err))
+		return
+	}
+
+	InfoLog.Printf("URL range match '%s' to '%s'\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	params := DateRangeParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	err = writeRangeFormatted(w, r, func(each func(austinapi_db.Stress) error) error {
+		return ApiQueries.EachStressByDateRange(DatabaseContext, params, each)
+	}, func(results []austinapi_db.Stress) any {
+		return StressRange{
+			Start: startDate.Format("2006-01-02"),
+			End:   endDate.Format("2006-01-02"),
+			Data:  results,
+		}
+	})
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v\n", err)
+		ErrorLog.Printf("error retrieving stress between '%s' and '%s': %v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), err)
 	}
+}
+
+// @Summary Get stress statistics
+// @Security ApiKeyAuth
+// @Description Retrieves count, mean, median, min, max and standard deviation of each numeric
+// @Description stress field between start and end (inclusive), grouped by day, week or month.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Description Each bucket is identified by its first day. stddev is null for a bucket with one value.
+// @Tags stress
+// @Produce json
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param bucket query string false "Period to group by" Enums(day, week, month) default(day)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Stats
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/stats [get]
+func (h *StressHandler) getStressStats(w http.ResponseWriter, r *http.Request) {
+	writeStats(w, r, "stress", ApiQueries.GetStressStats)
+}
+
+// @Summary Get smoothed stress time series
+// @Security ApiKeyAuth
+// @Description Retrieves the daily value of one numeric stress field between start and end (inclusive)
+// @Description alongside a rolling mean for each window of days and an exponentially weighted moving
+// @Description average (EWMA). Rolling means average the days recorded within each window.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02).
+// @Tags stress
+// @Produce json
+// @Param field query string true "Numeric field to return"
+// @Param start query string true "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param windows query string false "Comma separated rolling mean windows in days" default(7,30)
+// @Param alpha query number false "EWMA smoothing factor greater than 0 and at most 1" default(0.3)
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Series
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/series [get]
+func (h *StressHandler) getStressSeries(w http.ResponseWriter, r *http.Request) {
+	writeSeries(w, r, StressMetric)
+}
 
+// @Summary Get a chart of stress as SVG
+// @Security ApiKeyAuth
+// @Description Draws one or more numeric stress fields by day between start and end (inclusive) as a
+// @Description standalone SVG image for embedding in wikis, READMEs and emails. type is a line, grouped bars
+// @Description or bars stacked in the order the fields are given, and band=true shades a line chart between
+// @Description its first and last fields. High stress time is drawn in hours. For example field=high_stress_duration&type=bar draws a bar for each day.
+// @Description start and end accept a date (2024-02-19), an ISO week (2024-W08) or a month (2024-02) and
+// @Description default to the last 30 days. Days with no value are left as gaps. Charts can be embedded
+// @Description without an Authorization header using a URL signed by /sign.
+// @Tags stress
+// @Produce image/svg+xml
+// @Param field query string true "Comma separated numeric fields to draw"
+// @Param type query string false "Chart type" Enums(line, bar, stacked) default(line)
+// @Param band query bool false "Shade a line chart between its first and last fields"
+// @Param start query string false "Start date, ISO week or month"
+// @Param end query string false "End date, ISO week or month"
+// @Param width query int false "Width in pixels" default(640)
+// @Param height query int false "Height in pixels" default(320)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param colors query string false "Comma separated hex series colors, overriding the theme"
+// @Param background query string false "Hex background color, or none for transparent"
+// @Param title query string false "Title, or empty for none"
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/chart.svg [get]
+func (h *StressHandler) getStressChart(w http.ResponseWriter, r *http.Request) {
+	writeChart(w, r, StressMetric)
+}
+
+// @Summary Get a year heatmap of stress as SVG
+// @Security ApiKeyAuth
+// @Description Draws a year of one numeric stress field as a grid of days, one column per week starting
+// @Description on Sunday, like a GitHub contribution graph. Each day is shaded by where its value falls
+// @Description between the lowest and highest of the year, and hovering a day shows its value. field
+// @Description defaults to high_stress_duration and year to the current year. Heatmaps can be embedded without an
+// @Description Authorization header using a URL signed by /sign.
+// @Tags stress
+// @Produce image/svg+xml
+// @Param year query int false "Year"
+// @Param field query string false "Numeric field to shade by" default(high_stress_duration)
+// @Param theme query string false "Color theme" Enums(light, dark) default(light)
+// @Param expires query int false "Unix time a signed URL expires"
+// @Param signature query string false "Signature from /sign, instead of the Authorization header"
+// @Param Authorization header string false "Bearer Token"
+// @Success 200 {string} string
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/heatmap.svg [get]
+func (h *StressHandler) getStressHeatmap(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, StressMetric)
 }
 
 // @Summary Get list of stress information
 // @Security ApiKeyAuth
-// @Description Retrieves list of stress information in descending order by date
+// @Description Retrieves list of stress information ordered by date, newest first unless order=asc
 // @Description Specifying no query parameters pulls list starting with latest
 // @Description Caller can then specify a next_token from previous calls to go
-// @Description forward in the list of items.
+// @Description forward in the list of items, or a prev_token to go back.
+// @Description Tokens are opaque and remain stable while new items are added.
+// @Description Next, previous and first page URLs are also returned in the Link header.
+// @Description Paging past the end of the list returns an empty data array.
 // @Tags stress
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson,application/msgpack,application/vnd.openmhealth+json
 // @Param next_token query string false "next list search by next_token" Format(string)
+// @Param prev_token query string false "previous list search by prev_token" Format(string)
+// @Param limit query int false "number of items per page, at most the server list limit"
+// @Param order query string false "order by date" Enums(asc, desc) default(desc)
+// @Param fields query string false "comma separated list of fields to include in each item"
+// @Param format query string false "Response format, overriding the Accept header" Enums(json, csv, ndjson, msgpack, omh)
 // @Param Authorization header string true "Bearer Token"
 // @Success 200 {object} Stresses
+// @Failure 400 {object} GenericMessage
 // @Failure 500 {object} GenericMessage
+// @Failure 406 {object} GenericMessage
 // @Failure 401
 // @Router /stress/list [get]
 func (h *StressHandler) listStress(w http.ResponseWriter, r *http.Request) {
-	urlMatches := StressListRgx.FindStringSubmatch(r.URL.String())
-
-	if len(urlMatches) != 3 {
-		ErrorLog.Printf("error regex parsing url '%s' with regex '%s'", r.URL.Path, StressListRgx.String())
-		handleError(w, http.StatusInternalServerError, "Issue parsing URL")
+	listQuery, err := parseListQuery(r.URL.Query(), austinapi_db.Stress{})
+	if err != nil {
+		ErrorLog.Printf("error parsing query string '%s': %v", r.URL.RawQuery, err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query string: %v", err))
 		return
 	}
 
-	queryType := urlMatches[1]
-	queryToken := urlMatches[2]
+	InfoLog.Printf("URL directive match '%s'\n", listQuery.Direction)
+	InfoLog.Printf("URL token match '%s'\n", listQuery.Token)
 
-	InfoLog.Printf("URL directive match '%s'\n", queryType)
-	InfoLog.Printf("URL token match '%s'\n", queryToken)
+	var results []austinapi_db.Stress
 
-	params := austinapi_db.GetStressesParams{
-		RowOffset: 0,
-		RowLimit:  ListRowLimit,
-	}
+	// One row more than a page is requested to tell whether another page follows
+	rowLimit := listQuery.Limit + 1
 
-	if queryType == "next_token" {
-		rowOffset, err := strconv.ParseInt(queryToken, 10, 32)
-		if err != nil {
-			ErrorLog.Printf("error parsing specified query token '%v': %v", queryToken, err)
+	switch listQuery.Direction {
+	case PageNext, PagePrev:
+		cursor, cursorErr := decodeCursor(listQuery.Token)
+		if cursorErr != nil {
+			ErrorLog.Printf("error parsing specified query token '%v': %v", listQuery.Token, cursorErr)
 			handleError(w, http.StatusBadRequest, "Invalid query token")
 			return
 		}
 
-		params.RowOffset = int32(rowOffset)
+		params := KeysetParams{
+			Date:     cursor.Date,
+			ID:       cursor.ID,
+			RowLimit: rowLimit,
+		}
+
+		if listQuery.FetchesNewer() {
+			results, err = ApiQueries.GetStressesNewerThanCursor(DatabaseContext, params)
+		} else {
+			results, err = ApiQueries.GetStressesOlderThanCursor(DatabaseContext, params)
+		}
+	case PageFirst:
+		if listQuery.Order == OrderAsc {
+			results, err = ApiQueries.GetStressesOldestFirst(DatabaseContext, rowLimit)
+		} else {
+			params := austinapi_db.GetStressesParams{
+				RowOffset: 0,
+				RowLimit:  rowLimit,
+			}
+
+			results, err = ApiDatabase.GetStresses(DatabaseContext, params)
+		}
 	}
 
-	results, err := ApiDatabase.GetStresses(DatabaseContext, params)
 	if err != nil {
 		ErrorLog.Printf("error getting list of stress: %v", err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	if len(results) < 1 {
-		ErrorLog.Printf("no stress results from database with '%s' token '%s'", queryType, queryToken)
-		handleError(w, http.StatusNotFound, "no results found")
-		return
+	keep, hasNext, hasPrev := trimPage(listQuery.Direction, len(results), listQuery.Limit)
+	results = results[:keep]
+	if listQuery.Direction == PagePrev {
+		slices.Reverse(results)
 	}
 
 	stresses := Stresses{
-		Data:      results,
-		NextToken: params.RowLimit + params.RowOffset,
+		Data: results,
+	}
+
+	if len(results) > 0 {
+		if hasNext {
+			last := results[len(results)-1]
+			stresses.NextToken = encodeCursor(last.Date, last.ID)
+		}
+		if hasPrev {
+			first := results[0]
+			stresses.PrevToken = encodeCursor(first.Date, first.ID)
+		}
+	}
+
+	setLinkHeader(w, r, stresses.NextToken, stresses.PrevToken)
+
+	writeFormatted(w, r, stresses, results, listQuery.Fields)
+
+}
+
+func newStressInput(stress austinapi_db.Stress) StressInput {
+	date := stress.Date.Format("2006-01-02")
+	return StressInput{
+		Date:               &date,
+		HighStressDuration: &stress.HighStressDuration,
+	}
+}
+
+func (i StressInput) params() (austinapi_db.SaveStressParams, error) {
+	var params austinapi_db.SaveStressParams
+	var err error
+
+	if params.Date, err = parseInputDate(i.Date); err != nil {
+		return params, err
+	}
+	if params.HighStressDuration, err = checkRange("high_stress_duration", i.HighStressDuration, 0, MaxDailySeconds); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// @Summary Save stress information
+// @Security ApiKeyAuth
+// @Description Saves stress information for a date. If stress already exists for the date it is
+// @Description updated and 200 is returned, otherwise it is created and 201 is returned.
+// @Tags stress
+// @Accept json
+// @Produce json
+// @Param stress body StressInput true "Stress"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Stress
+// @Success 201 {object} austinapi_db.Stress
+// @Failure 400 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress [post]
+func (h *StressHandler) createStress(w http.ResponseWriter, r *http.Request) {
+	var input StressInput
+	err := decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding stress request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid stress request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
 	}
 
-	jsonBytes, err := json.Marshal(stresses)
+	result, inserted, err := ApiQueries.UpsertStress(DatabaseContext, params)
 	if err != nil {
-		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		ErrorLog.Printf("error saving stress with date '%s': %v", params.Date.Format("2006-01-02"), err)
 		handleError(w, http.StatusInternalServerError, "Internal Error")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	InfoLog.Printf("saved stress with id '%d' inserted '%t'\n", result.ID, inserted)
+
+	statusCode := http.StatusOK
+	if inserted {
+		statusCode = http.StatusCreated
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/stress/id/%d", result.ID))
+	writeJSON(w, statusCode, result)
+}
+
+// @Summary Replace stress information by ID
+// @Security ApiKeyAuth
+// @Description Replaces all fields of the stress information with specified ID
+// @Tags stress
+// @Accept json
+// @Produce json
+// @Param id path string true "Stress ID"
+// @Param stress body StressInput true "Stress"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Stress
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/id/{id} [put]
+func (h *StressHandler) replaceStress(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(StressRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	var input StressInput
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding stress request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateStress(w, id, input)
+}
+
+// @Summary Update stress information by ID
+// @Security ApiKeyAuth
+// @Description Updates only the fields given for the stress information with specified ID
+// @Tags stress
+// @Accept json
+// @Produce json
+// @Param id path string true "Stress ID"
+// @Param stress body StressInput true "Stress fields to change"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} austinapi_db.Stress
+// @Failure 400 {object} GenericMessage
+// @Failure 404 {object} GenericMessage
+// @Failure 409 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/id/{id} [patch]
+func (h *StressHandler) patchStress(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(StressRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	existing, err := ApiDatabase.GetStress(DatabaseContext, id)
+	if err != nil {
+		ErrorLog.Printf("error retrieving stress with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(existing) != 1 {
+		InfoLog.Printf("stress with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Stress not found with id %d", id))
+		return
+	}
+
+	// Fields left out of the body keep the values decoded from the stored row
+	input := newStressInput(existing[0])
+	err = decodeBody(r, &input)
+	if err != nil {
+		ErrorLog.Printf("error decoding stress request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	h.updateStress(w, id, input)
+}
+
+func (h *StressHandler) updateStress(w http.ResponseWriter, id int64, input StressInput) {
+	params, err := input.params()
+	if err != nil {
+		ErrorLog.Printf("invalid stress request body: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	result, err := ApiQueries.UpdateStress(DatabaseContext, UpdateStressParams{ID: id, SaveStressParams: params})
+	if isUniqueViolation(err) {
+		InfoLog.Printf("stress with date '%s' already exists", params.Date.Format("2006-01-02"))
+		handleError(w, http.StatusConflict, fmt.Sprintf("Stress already exists with date %s", params.Date.Format("2006-01-02")))
+		return
+	}
+
+	if err != nil {
+		ErrorLog.Printf("error updating stress with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("stress with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Stress not found with id %d", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result[0])
+}
+
+// @Summary Delete stress information by ID
+// @Security ApiKeyAuth
+// @Description Deletes the stress information with specified ID
+// @Tags stress
+// @Param id path string true "Stress ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204
+// @Failure 404 {object} GenericMessage
+// @Failure 500 {object} GenericMessage
+// @Failure 401
+// @Router /stress/id/{id} [delete]
+func (h *StressHandler) deleteStress(w http.ResponseWriter, r *http.Request) {
+	id, err := getIdFromUrl(StressRgxId, r.URL)
+	if err != nil {
+		ErrorLog.Println(err)
+		handleError(w, http.StatusInternalServerError, "Issue parsing id from URL")
+		return
+	}
+
+	result, err := ApiQueries.DeleteStress(DatabaseContext, id)
 	if err != nil {
-		ErrorLog.Printf("error writing http response: %v", err)
+		ErrorLog.Printf("error deleting stress with id '%d': %v", id, err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	if len(result) != 1 {
+		InfoLog.Printf("stress with id '%d' was not found in database", id)
+		handleError(w, http.StatusNotFound, fmt.Sprintf("Stress not found with id %d", id))
+		return
 	}
 
+	InfoLog.Printf("deleted stress with id '%d'\n", id)
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"github.com/jackc/pgx/v5"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const (
+	OuraSyncSource = "oura"
+
+	DefaultOuraSyncInterval     = time.Hour
+	DefaultOuraSyncLookbackDays = 30
+
+	// OuraSyncOverlapDays is how many days before the high-water mark are
+	// fetched again, since Oura keeps revising the latest day until it ends
+	OuraSyncOverlapDays = 1
+
+	OuraSyncQueueSize = 100
+)
+
+// OuraSource pulls one metric from Oura for the days from start to end
+// inclusive, saves each day and returns how many days were saved along with
+// the latest of them.
+type OuraSource struct {
+	Metric string
+	Sync   func(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error)
+}
+
+// OuraDocument identifies a single Oura document, such as one day's
+// daily_sleep, that a webhook event reported as changed.
+type OuraDocument struct {
+	DataType string
+	ObjectId string
+}
+
+var (
+	OuraSources = []OuraSource{
+		{Metric: SleepMetric.Name, Sync: syncOuraSleep},
+		{Metric: ReadyScoreMetric.Name, Sync: syncOuraReadyScore},
+		{Metric: HeartRateMetric.Name, Sync: syncOuraHeartRate},
+		{Metric: StressMetric.Name, Sync: syncOuraStress},
+		{Metric: Spo2Metric.Name, Sync: syncOuraSpo2},
+	}
+
+	// OuraDataTypes maps each webhook data type to the metric it feeds
+	OuraDataTypes = map[string]string{
+		"daily_sleep":     SleepMetric.Name,
+		"sleep":           SleepMetric.Name,
+		"daily_readiness": ReadyScoreMetric.Name,
+		"daily_stress":    StressMetric.Name,
+		"daily_spo2":      Spo2Metric.Name,
+	}
+)
+
+// SyncWorker keeps the metric tables up to date with Oura. Every Interval it
+// fetches each metric from just before that metric's high-water mark up to
+// today. A metric that has never synced starts LookbackDays ago.
+//
+// Documents passed to Enqueue are fetched between runs, so a webhook event
+// only re-syncs the day it is about.
+type SyncWorker struct {
+	Client       *OuraClient
+	Interval     time.Duration
+	LookbackDays int
+	documents    chan OuraDocument
+}
+
+func NewSyncWorker(client *OuraClient, interval time.Duration, lookbackDays int) *SyncWorker {
+	return &SyncWorker{
+		Client:       client,
+		Interval:     interval,
+		LookbackDays: lookbackDays,
+		documents:    make(chan OuraDocument, OuraSyncQueueSize),
+	}
+}
+
+// Enqueue queues a document to be re-fetched, returning false when the queue
+// is full.
+func (s *SyncWorker) Enqueue(document OuraDocument) bool {
+	select {
+	case s.documents <- document:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run syncs straight away and then every Interval until ctx is done, fetching
+// any enqueued documents in between.
+func (s *SyncWorker) Run(ctx context.Context) {
+	InfoLog.Printf("oura sync from '%s' every %s\n", s.Client.BaseUrl, s.Interval)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	s.SyncAll(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.SyncAll(ctx)
+		case document := <-s.documents:
+			err := s.SyncDocument(ctx, document)
+			if err != nil {
+				ErrorLog.Printf("error syncing oura %s '%s': %v", document.DataType, document.ObjectId, err)
+			}
+		}
+	}
+}
+
+// SyncAll syncs every metric. A metric that fails is logged and left for the
+// next run without holding up the others.
+func (s *SyncWorker) SyncAll(ctx context.Context) {
+	for _, source := range OuraSources {
+		err := s.Sync(ctx, source)
+		if err != nil {
+			ErrorLog.Printf("error syncing oura %s: %v", source.Metric, err)
+		}
+	}
+}
+
+// SyncDocument looks up the day an Oura document belongs to and re-syncs its
+// metric for just that day. The high-water mark is left where it is, so days
+// between it and the document are still picked up by the next Sync.
+func (s *SyncWorker) SyncDocument(ctx context.Context, document OuraDocument) error {
+	metric, found := OuraDataTypes[document.DataType]
+	if !found {
+		return fmt.Errorf("unsupported data type '%s'", document.DataType)
+	}
+
+	var day struct {
+		Day string `json:"day"`
+	}
+	err := s.Client.get(ctx, fmt.Sprintf("/v2/usercollection/%s/%s", document.DataType, url.PathEscape(document.ObjectId)), url.Values{}, &day)
+	if err != nil {
+		return err
+	}
+
+	date, err := parseOuraDay(day.Day)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range OuraSources {
+		if source.Metric == metric {
+			_, _, err := s.syncRange(ctx, source, date, date)
+			return err
+		}
+	}
+
+	return fmt.Errorf("no oura source for metric '%s'", metric)
+}
+
+func (s *SyncWorker) Sync(ctx context.Context, source OuraSource) error {
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -s.LookbackDays)
+
+	state := SyncStateParams{Source: OuraSyncSource, Metric: source.Metric}
+
+	highWaterMark, err := ApiQueries.GetSyncHighWaterMark(ctx, state)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("error retrieving high-water mark: %w", err)
+	default:
+		start = highWaterMark.AddDate(0, 0, -OuraSyncOverlapDays)
+	}
+
+	saved, latest, err := s.syncRange(ctx, source, start, end)
+	if err != nil || saved == 0 {
+		return err
+	}
+
+	err = ApiQueries.SaveSyncHighWaterMark(ctx, SaveSyncHighWaterMarkParams{
+		Source:        OuraSyncSource,
+		Metric:        source.Metric,
+		HighWaterMark: latest,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving high-water mark '%s': %w", latest.Format("2006-01-02"), err)
+	}
+
+	return nil
+}
+
+// syncRange fetches one metric between start and end. It leaves the
+// high-water mark alone, since only Sync covers every day up to the mark.
+func (s *SyncWorker) syncRange(ctx context.Context, source OuraSource, start time.Time, end time.Time) (int, time.Time, error) {
+	saved, latest, err := source.Sync(ctx, s.Client, start, end)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	InfoLog.Printf("synced %d day(s) of oura %s between '%s' and '%s'\n", saved, source.Metric, start.Format("2006-01-02"), end.Format("2006-01-02"))
+
+	return saved, latest, nil
+}
+
+// ouraZones lists when each sleep period ended, oldest first, in the offset
+// Oura reported it in.
+func ouraZones(periods []OuraSleep) []time.Time {
+	var zones []time.Time
+	for _, period := range periods {
+		if !period.BedtimeEnd.IsZero() {
+			zones = append(zones, period.BedtimeEnd)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Before(zones[j]) })
+	return zones
+}
+
+// ouraLocalDay is the local date of a timestamp, in the offset of the last
+// sleep to end before it. That follows the user across time zones from the
+// first night after they move. Timestamps before any sleep use the first
+// sleep's offset, and with no sleeps at all the date is in UTC.
+func ouraLocalDay(zones []time.Time, timestamp time.Time) string {
+	location := time.UTC
+	for i, zone := range zones {
+		if i > 0 && zone.After(timestamp) {
+			break
+		}
+		location = zone.Location()
+	}
+	return timestamp.In(location).Format("2006-01-02")
+}
+
+func parseOuraDay(day string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid oura day '%s': %w", day, err)
+	}
+	return date, nil
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// syncOuraSleep takes the rating from daily_sleep and the durations from the
+// sleep periods on the same day. Naps and rest periods are left out so the
+// durations match what the rating scores.
+func syncOuraSleep(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error) {
+	dailySleeps, err := ouraList[OuraDailySleep](ctx, client, "/v2/usercollection/daily_sleep", ouraDateQuery(start, end))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	periods, err := ouraList[OuraSleep](ctx, client, "/v2/usercollection/sleep", ouraDateQuery(start, end))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	durations := map[string]austinapi_db.SaveSleepParams{}
+	for _, period := range periods {
+		if period.Type != "long_sleep" && period.Type != "sleep" {
+			continue
+		}
+
+		params := durations[period.Day]
+		params.TotalSleep += valueOrZero(period.TotalSleepDuration)
+		params.DeepSleep += valueOrZero(period.DeepSleepDuration)
+		params.LightSleep += valueOrZero(period.LightSleepDuration)
+		params.RemSleep += valueOrZero(period.RemSleepDuration)
+		durations[period.Day] = params
+	}
+
+	var saved int
+	var latest time.Time
+	for _, dailySleep := range dailySleeps {
+		if dailySleep.Score == nil {
+			continue
+		}
+
+		date, err := parseOuraDay(dailySleep.Day)
+		if err != nil {
+			return saved, latest, err
+		}
+
+		params := durations[dailySleep.Day]
+		params.Date = date
+		params.Rating = *dailySleep.Score
+
+		_, _, err = ApiQueries.UpsertSleep(ctx, params)
+		if err != nil {
+			return saved, latest, fmt.Errorf("error saving sleep with date '%s': %w", dailySleep.Day, err)
+		}
+
+		saved++
+		latest = later(latest, date)
+	}
+
+	return saved, latest, nil
+}
+
+func syncOuraReadyScore(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error) {
+	readinesses, err := ouraList[OuraDailyReadiness](ctx, client, "/v2/usercollection/daily_readiness", ouraDateQuery(start, end))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var saved int
+	var latest time.Time
+	for _, readiness := range readinesses {
+		if readiness.Score == nil {
+			continue
+		}
+
+		date, err := parseOuraDay(readiness.Day)
+		if err != nil {
+			return saved, latest, err
+		}
+
+		_, _, err = ApiQueries.UpsertReadyScore(ctx, austinapi_db.SaveReadyScoreParams{
+			Date:  date,
+			Score: *readiness.Score,
+		})
+		if err != nil {
+			return saved, latest, fmt.Errorf("error saving ready score with date '%s': %w", readiness.Day, err)
+		}
+
+		saved++
+		latest = later(latest, date)
+	}
+
+	return saved, latest, nil
+}
+
+// syncOuraHeartRate rolls the individual heart rate samples up into a daily
+// low, high and average. Oura sends the samples in UTC, so each is grouped by
+// its local date, taking the offset from the sleep periods as ouraLocalDay
+// does. The heartrate endpoint is read in windows of at most
+// OuraHeartRateMaxDays.
+func syncOuraHeartRate(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error) {
+	type dailyHeartRate struct {
+		params austinapi_db.SaveHeartRateParams
+		sum    int
+		count  int
+	}
+
+	periods, err := ouraList[OuraSleep](ctx, client, "/v2/usercollection/sleep", ouraDateQuery(start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	zones := ouraZones(periods)
+
+	days := map[string]*dailyHeartRate{}
+	var order []string
+
+	// A local day can start or end up to 14 hours either side of the UTC
+	// one, so a day more is fetched on each side to have whole days
+	fetchStart, fetchEnd := start.AddDate(0, 0, -1), end.AddDate(0, 0, 2)
+
+	for windowStart := fetchStart; windowStart.Before(fetchEnd); windowStart = windowStart.AddDate(0, 0, OuraHeartRateMaxDays) {
+		windowEnd := windowStart.AddDate(0, 0, OuraHeartRateMaxDays)
+		if windowEnd.After(fetchEnd) {
+			windowEnd = fetchEnd
+		}
+
+		query := url.Values{}
+		query.Set("start_datetime", windowStart.Format(time.RFC3339))
+		query.Set("end_datetime", windowEnd.Format(time.RFC3339))
+
+		samples, err := ouraList[OuraHeartRate](ctx, client, "/v2/usercollection/heartrate", query)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+
+		for _, sample := range samples {
+			day := ouraLocalDay(zones, sample.Timestamp)
+
+			daily, found := days[day]
+			if !found {
+				daily = &dailyHeartRate{params: austinapi_db.SaveHeartRateParams{Low: sample.Bpm, High: sample.Bpm}}
+				days[day] = daily
+				order = append(order, day)
+			}
+
+			daily.params.Low = min(daily.params.Low, sample.Bpm)
+			daily.params.High = max(daily.params.High, sample.Bpm)
+			daily.sum += sample.Bpm
+			daily.count++
+		}
+	}
+
+	var saved int
+	var latest time.Time
+	for _, day := range order {
+		date, err := parseOuraDay(day)
+		if err != nil {
+			return saved, latest, err
+		}
+
+		// The extra days fetched either side are only there to complete the range's days
+		if date.Before(start) || date.After(end) {
+			continue
+		}
+
+		daily := days[day]
+		daily.params.Date = date
+		daily.params.Average = int(math.Round(float64(daily.sum) / float64(daily.count)))
+
+		_, _, err = ApiQueries.UpsertHeartRate(ctx, daily.params)
+		if err != nil {
+			return saved, latest, fmt.Errorf("error saving heart rate with date '%s': %w", day, err)
+		}
+
+		saved++
+		latest = later(latest, date)
+	}
+
+	return saved, latest, nil
+}
+
+func syncOuraStress(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error) {
+	stresses, err := ouraList[OuraDailyStress](ctx, client, "/v2/usercollection/daily_stress", ouraDateQuery(start, end))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var saved int
+	var latest time.Time
+	for _, stress := range stresses {
+		if stress.StressHigh == nil {
+			continue
+		}
+
+		date, err := parseOuraDay(stress.Day)
+		if err != nil {
+			return saved, latest, err
+		}
+
+		_, _, err = ApiQueries.UpsertStress(ctx, austinapi_db.SaveStressParams{
+			Date:               date,
+			HighStressDuration: *stress.StressHigh,
+		})
+		if err != nil {
+			return saved, latest, fmt.Errorf("error saving stress with date '%s': %w", stress.Day, err)
+		}
+
+		saved++
+		latest = later(latest, date)
+	}
+
+	return saved, latest, nil
+}
+
+func syncOuraSpo2(ctx context.Context, client *OuraClient, start time.Time, end time.Time) (int, time.Time, error) {
+	spo2s, err := ouraList[OuraDailySpo2](ctx, client, "/v2/usercollection/daily_spo2", ouraDateQuery(start, end))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var saved int
+	var latest time.Time
+	for _, spo2 := range spo2s {
+		if spo2.Spo2Percentage == nil || spo2.Spo2Percentage.Average == nil {
+			continue
+		}
+
+		date, err := parseOuraDay(spo2.Day)
+		if err != nil {
+			return saved, latest, err
+		}
+
+		_, _, err = ApiQueries.UpsertSpo2(ctx, austinapi_db.SaveSpo2Params{
+			Date:        date,
+			AverageSpo2: *spo2.Spo2Percentage.Average,
+		})
+		if err != nil {
+			return saved, latest, fmt.Errorf("error saving spo2 with date '%s': %w", spo2.Day, err)
+		}
+
+		saved++
+		latest = later(latest, date)
+	}
+
+	return saved, latest, nil
+}
+
+func valueOrZero[T any](value *T) T {
+	var zero T
+	if value == nil {
+		return zero
+	}
+	return *value
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/austinmoody/austinapi_db/austinapi_db"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TakeoutSource = "fitbit_takeout"
+
+	// TakeoutHeartRateTimeFormat is the local time used in heart_rate files
+	TakeoutHeartRateTimeFormat = "01/02/06 15:04:05"
+
+	TakeoutActionCreate    = "create"
+	TakeoutActionUpdate    = "update"
+	TakeoutActionUnchanged = "unchanged"
+)
+
+var (
+	TakeoutHeartRateRgx       *regexp.Regexp
+	TakeoutSleepRgx           *regexp.Regexp
+	TakeoutSleepScoreRgx      *regexp.Regexp
+	TakeoutDailySpo2Rgx       *regexp.Regexp
+	TakeoutOxygenVariationRgx *regexp.Regexp
+	TakeoutReadinessRgx       *regexp.Regexp
+	TakeoutStressScoreRgx     *regexp.Regexp
+)
+
+// FieldChange is one field of a row before and after an import. Before is
+// null when the row does not exist yet.
+type FieldChange struct {
+	Before *float64 `json:"before"`
+	After  float64  `json:"after"`
+}
+
+// RowChange is what an import does, or in a dry run would do, to one daily
+// row. Fields lists every field for a create and only the changed ones for
+// an update.
+type RowChange struct {
+	Metric string                 `json:"metric"`
+	Date   string                 `json:"date"`
+	Action string                 `json:"action"`
+	Source string                 `json:"source"`
+	Fields map[string]FieldChange `json:"fields,omitempty"`
+}
+
+type TakeoutImport struct {
+	Source  string       `json:"source"`
+	DryRun  bool         `json:"dry_run"`
+	Files   int          `json:"files"`
+	Changes []RowChange  `json:"changes"`
+	Skipped []SkippedRow `json:"skipped"`
+}
+
+// takeoutRow is the values proposed for one daily row and the archive file
+// they were read from
+type takeoutRow struct {
+	source string
+	fields map[string]float64
+}
+
+// takeoutDays collects proposed rows from every file in an archive. Values
+// are keyed by metric name, then date.
+type takeoutDays struct {
+	heartRates  map[string]*heartRateRollup
+	heartSource map[string]string
+	rows        map[string]map[string]*takeoutRow
+	sleepScores map[string]float64
+}
+
+func init() {
+	TakeoutHeartRateRgx = regexp.MustCompile(`^heart_rate-[0-9]{4}-[0-9]{2}-[0-9]{2}\.json$`)
+	TakeoutSleepRgx = regexp.MustCompile(`^sleep-[0-9]{4}-[0-9]{2}-[0-9]{2}\.json$`)
+	TakeoutSleepScoreRgx = regexp.MustCompile(`^sleep_score\.csv$`)
+	TakeoutDailySpo2Rgx = regexp.MustCompile(`^Daily SpO2 - .*\.csv$`)
+	TakeoutOxygenVariationRgx = regexp.MustCompile(`^estimated_oxygen_variation-.*\.csv$`)
+	TakeoutReadinessRgx = regexp.MustCompile(`^Daily Readiness Score - .*\.csv$`)
+	TakeoutStressScoreRgx = regexp.MustCompile(`^Stress Score\.csv$`)
+}
+
+// ImportTakeout reads a Google Takeout Fitbit archive and works out the daily
+// rows it holds. Unless dryRun is set the rows are upserted, and their
+// source recorded, in a single transaction.
+func ImportTakeout(ctx context.Context, archive *zip.Reader, dryRun bool) (TakeoutImport, error) {
+	takeoutImport := TakeoutImport{
+		Source:  TakeoutSource,
+		DryRun:  dryRun,
+		Changes: []RowChange{},
+		Skipped: []SkippedRow{},
+	}
+
+	days := takeoutDays{
+		heartRates:  map[string]*heartRateRollup{},
+		heartSource: map[string]string{},
+		rows:        map[string]map[string]*takeoutRow{},
+		sleepScores: map[string]float64{},
+	}
+
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+
+		var read func(io.Reader, string) ([]SkippedRow, error)
+		switch {
+		case TakeoutHeartRateRgx.MatchString(name):
+			read = days.readHeartRate
+		case TakeoutSleepRgx.MatchString(name):
+			read = days.readSleep
+		case TakeoutSleepScoreRgx.MatchString(name):
+			read = days.readSleepScore
+		case TakeoutDailySpo2Rgx.MatchString(name):
+			read = days.readDailySpo2
+		case TakeoutReadinessRgx.MatchString(name):
+			read = days.readReadiness
+		case TakeoutOxygenVariationRgx.MatchString(name):
+			read = skipTakeoutFile("estimated oxygen variation is a red to infrared signal ratio, not a saturation percentage; spo2 comes from the Daily SpO2 files")
+		case TakeoutStressScoreRgx.MatchString(name):
+			read = skipTakeoutFile("Fitbit stress scores have no high stress duration to map to stress")
+		default:
+			continue
+		}
+
+		takeoutImport.Files++
+
+		reader, err := file.Open()
+		if err != nil {
+			return takeoutImport, fmt.Errorf("%w: error opening '%s': %v", ErrImportFormat, file.Name, err)
+		}
+
+		skipped, err := read(reader, file.Name)
+		reader.Close()
+		if err != nil {
+			return takeoutImport, fmt.Errorf("%w: error reading '%s': %v", ErrImportFormat, file.Name, err)
+		}
+
+		takeoutImport.Skipped = append(takeoutImport.Skipped, skipped...)
+	}
+
+	days.finish()
+
+	changes, err := days.diff(ctx)
+	if err != nil {
+		return takeoutImport, err
+	}
+	takeoutImport.Changes = changes
+
+	if dryRun {
+		return takeoutImport, nil
+	}
+
+	return takeoutImport, days.save(ctx, changes)
+}
+
+func skipTakeoutFile(reason string) func(io.Reader, string) ([]SkippedRow, error) {
+	return func(reader io.Reader, name string) ([]SkippedRow, error) {
+		return []SkippedRow{{Reason: fmt.Sprintf("'%s': %s", name, reason)}}, nil
+	}
+}
+
+func (d takeoutDays) propose(metric string, date string, source string, fields map[string]float64) {
+	if d.rows[metric] == nil {
+		d.rows[metric] = map[string]*takeoutRow{}
+	}
+	d.rows[metric][date] = &takeoutRow{source: source, fields: fields}
+}
+
+// readHeartRate streams a heart_rate file one sample at a time, since each
+// holds a reading every few seconds for a whole day.
+func (d takeoutDays) readHeartRate(reader io.Reader, name string) ([]SkippedRow, error) {
+	decoder := json.NewDecoder(reader)
+
+	_, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped int
+	for decoder.More() {
+		var sample struct {
+			DateTime string `json:"dateTime"`
+			Value    struct {
+				Bpm float64 `json:"bpm"`
+			} `json:"value"`
+		}
+
+		err = decoder.Decode(&sample)
+		if err != nil {
+			return nil, err
+		}
+
+		timestamp, err := time.Parse(TakeoutHeartRateTimeFormat, sample.DateTime)
+		if err != nil || sample.Value.Bpm <= 0 {
+			skipped++
+			continue
+		}
+
+		day := timestamp.Format("2006-01-02")
+		heartRate, found := d.heartRates[day]
+		if !found {
+			heartRate = &heartRateRollup{low: sample.Value.Bpm, high: sample.Value.Bpm}
+			d.heartRates[day] = heartRate
+		}
+
+		heartRate.low = math.Min(heartRate.low, sample.Value.Bpm)
+		heartRate.high = math.Max(heartRate.high, sample.Value.Bpm)
+		heartRate.sum += sample.Value.Bpm
+		heartRate.count++
+		d.heartSource[day] = name
+	}
+
+	if skipped > 0 {
+		return []SkippedRow{{Metric: HeartRateMetric.Name, Reason: fmt.Sprintf("'%s': %d sample(s) without a valid time or bpm", name, skipped)}}, nil
+	}
+
+	return nil, nil
+}
+
+// readSleep takes the main sleep of each day. Durations come from the stage
+// summary when Fitbit recorded stages, otherwise only the total is known.
+func (d takeoutDays) readSleep(reader io.Reader, name string) ([]SkippedRow, error) {
+	var logs []struct {
+		DateOfSleep   string `json:"dateOfSleep"`
+		MinutesAsleep int    `json:"minutesAsleep"`
+		MainSleep     bool   `json:"mainSleep"`
+		Levels        struct {
+			Summary map[string]struct {
+				Minutes int `json:"minutes"`
+			} `json:"summary"`
+		} `json:"levels"`
+	}
+
+	err := json.NewDecoder(reader).Decode(&logs)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []SkippedRow
+	for _, sleepLog := range logs {
+		if !sleepLog.MainSleep {
+			skipped = append(skipped, SkippedRow{Metric: SleepMetric.Name, Date: sleepLog.DateOfSleep, Reason: fmt.Sprintf("'%s': naps are not imported", name)})
+			continue
+		}
+
+		_, err := time.Parse("2006-01-02", sleepLog.DateOfSleep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateOfSleep '%s'", sleepLog.DateOfSleep)
+		}
+
+		d.propose(SleepMetric.Name, sleepLog.DateOfSleep, name, map[string]float64{
+			"total_sleep": float64(sleepLog.MinutesAsleep * 60),
+			"deep_sleep":  float64(sleepLog.Levels.Summary["deep"].Minutes * 60),
+			"light_sleep": float64(sleepLog.Levels.Summary["light"].Minutes * 60),
+			"rem_sleep":   float64(sleepLog.Levels.Summary["rem"].Minutes * 60),
+		})
+	}
+
+	return skipped, nil
+}
+
+func (d takeoutDays) readSleepScore(reader io.Reader, name string) ([]SkippedRow, error) {
+	return readTakeoutCsv(reader, name, SleepMetric.Name, "timestamp", "overall_score", func(date string, value float64) {
+		d.sleepScores[date] = value
+	})
+}
+
+func (d takeoutDays) readDailySpo2(reader io.Reader, name string) ([]SkippedRow, error) {
+	return readTakeoutCsv(reader, name, Spo2Metric.Name, "timestamp", "average_value", func(date string, value float64) {
+		d.propose(Spo2Metric.Name, date, name, map[string]float64{"average_spo2": value})
+	})
+}
+
+func (d takeoutDays) readReadiness(reader io.Reader, name string) ([]SkippedRow, error) {
+	return readTakeoutCsv(reader, name, ReadyScoreMetric.Name, "date", "readiness_score_value", func(date string, value float64) {
+		d.propose(ReadyScoreMetric.Name, date, name, map[string]float64{"score": value})
+	})
+}
+
+// readTakeoutCsv calls add with the date and value of each row of a CSV,
+// reading them from the named columns. Dates may be followed by a time.
+func readTakeoutCsv(reader io.Reader, name string, metric string, dateColumn string, valueColumn string, add func(string, float64)) ([]SkippedRow, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	dateIndex, valueIndex := -1, -1
+	for i, column := range header {
+		switch strings.TrimSpace(column) {
+		case dateColumn:
+			dateIndex = i
+		case valueColumn:
+			valueIndex = i
+		}
+	}
+	if dateIndex < 0 || valueIndex < 0 {
+		return nil, fmt.Errorf("missing '%s' or '%s' column", dateColumn, valueColumn)
+	}
+
+	var skipped int
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) <= dateIndex || len(record) <= valueIndex || len(record[dateIndex]) < 10 {
+			skipped++
+			continue
+		}
+
+		date := record[dateIndex][:10]
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[valueIndex]), 64)
+		if _, dateErr := time.Parse("2006-01-02", date); err != nil || dateErr != nil {
+			skipped++
+			continue
+		}
+
+		add(date, value)
+	}
+
+	if skipped > 0 {
+		return []SkippedRow{{Metric: metric, Reason: fmt.Sprintf("'%s': %d row(s) without a valid date or value", name, skipped)}}, nil
+	}
+
+	return nil, nil
+}
+
+// finish turns the heart rate rollups into rows and adds sleep scores to the
+// sleep rows they belong to.
+func (d takeoutDays) finish() {
+	for day, heartRate := range d.heartRates {
+		d.propose(HeartRateMetric.Name, day, d.heartSource[day], map[string]float64{
+			"low":     math.Round(heartRate.low),
+			"high":    math.Round(heartRate.high),
+			"average": math.Round(heartRate.sum / float64(heartRate.count)),
+		})
+	}
+
+	for day, row := range d.rows[SleepMetric.Name] {
+		if score, found := d.sleepScores[day]; found {
+			row.fields["rating"] = score
+		}
+	}
+}
+
+// diff compares the proposed rows with the rows already saved.
+func (d takeoutDays) diff(ctx context.Context) ([]RowChange, error) {
+	changes := []RowChange{}
+
+	for _, metric := range Metrics {
+		rows := d.rows[metric.Name]
+		if len(rows) == 0 {
+			continue
+		}
+
+		dates := sortedKeys(rows)
+		start, _ := time.Parse("2006-01-02", dates[0])
+		end, _ := time.Parse("2006-01-02", dates[len(dates)-1])
+
+		values, err := metric.Values(ctx, DateRangeParams{StartDate: start, EndDate: end})
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving existing %s rows: %w", metric.Name, err)
+		}
+
+		existing := map[string]map[string]float64{}
+		for field, dailyValues := range values {
+			for _, dailyValue := range dailyValues {
+				day := dailyValue.Date.Format("2006-01-02")
+				if existing[day] == nil {
+					existing[day] = map[string]float64{}
+				}
+				existing[day][field] = dailyValue.Value
+			}
+		}
+
+		for _, day := range dates {
+			row := rows[day]
+			before, found := existing[day]
+
+			// Fitbit has no score for some nights, so keep any rating already saved
+			if metric.Name == SleepMetric.Name {
+				if _, scored := row.fields["rating"]; !scored {
+					row.fields["rating"] = before["rating"]
+				}
+			}
+
+			change := RowChange{
+				Metric: metric.Name,
+				Date:   day,
+				Action: TakeoutActionCreate,
+				Source: row.source,
+				Fields: map[string]FieldChange{},
+			}
+			if found {
+				change.Action = TakeoutActionUpdate
+			}
+
+			for _, field := range metric.Fields {
+				after := row.fields[field]
+				if !found {
+					change.Fields[field] = FieldChange{After: after}
+					continue
+				}
+
+				if value := before[field]; value != after {
+					change.Fields[field] = FieldChange{Before: &value, After: after}
+				}
+			}
+
+			if found && len(change.Fields) == 0 {
+				change.Action = TakeoutActionUnchanged
+				change.Fields = nil
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// save applies every create and update in one transaction, recording the
+// archive file each row came from.
+func (d takeoutDays) save(ctx context.Context, changes []RowChange) error {
+	tx, err := DatabaseConnection.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := NewQueries(tx)
+
+	for _, change := range changes {
+		if change.Action == TakeoutActionUnchanged {
+			continue
+		}
+
+		date, _ := time.Parse("2006-01-02", change.Date)
+		fields := d.rows[change.Metric][change.Date].fields
+
+		switch change.Metric {
+		case SleepMetric.Name:
+			_, _, err = queries.UpsertSleep(ctx, austinapi_db.SaveSleepParams{
+				Date:       date,
+				Rating:     int64(fields["rating"]),
+				TotalSleep: int(fields["total_sleep"]),
+				DeepSleep:  int(fields["deep_sleep"]),
+				LightSleep: int(fields["light_sleep"]),
+				RemSleep:   int(fields["rem_sleep"]),
+			})
+		case ReadyScoreMetric.Name:
+			_, _, err = queries.UpsertReadyScore(ctx, austinapi_db.SaveReadyScoreParams{
+				Date:  date,
+				Score: int(fields["score"]),
+			})
+		case HeartRateMetric.Name:
+			_, _, err = queries.UpsertHeartRate(ctx, austinapi_db.SaveHeartRateParams{
+				Date:    date,
+				Low:     int(fields["low"]),
+				High:    int(fields["high"]),
+				Average: int(fields["average"]),
+			})
+		case Spo2Metric.Name:
+			_, _, err = queries.UpsertSpo2(ctx, austinapi_db.SaveSpo2Params{
+				Date:        date,
+				AverageSpo2: fields["average_spo2"],
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("error saving %s with date '%s': %w", change.Metric, change.Date, err)
+		}
+
+		err = queries.SaveRowSource(ctx, SaveRowSourceParams{
+			Metric: change.Metric,
+			Date:   date,
+			Source: TakeoutSource,
+			Detail: change.Source,
+		})
+		if err != nil {
+			return fmt.Errorf("error saving source of %s with date '%s': %w", change.Metric, change.Date, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
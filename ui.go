@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The dashboard is plain server-rendered HTML for people rather than API
+// clients, so its routes are not part of the Swagger documentation.
+
+const (
+	UiSessionCookie = "austinapi_session"
+	UiLoginPath     = "/ui/login"
+
+	UiTodayDays        = 30
+	UiCardChartWidth   = 320
+	UiCardChartHeight  = 140
+	UiTrendChartWidth  = 960
+	UiTrendChartHeight = 360
+
+	// UiContentSecurityPolicy allows the dashboard's own stylesheet and
+	// nothing else. Charts are inline SVG so need no image sources.
+	UiContentSecurityPolicy = "default-src 'none'; style-src 'self'; form-action 'self'; frame-ancestors 'none'; base-uri 'none'"
+)
+
+var (
+	//go:embed ui/templates ui/static
+	uiFiles embed.FS
+
+	uiTemplates = map[string]*template.Template{}
+
+	UiRgxToday  *regexp.Regexp
+	UiRgxDay    *regexp.Regexp
+	UiRgxDate   *regexp.Regexp
+	UiRgxTrend  *regexp.Regexp
+	UiRgxLogin  *regexp.Regexp
+	UiRgxLogout *regexp.Regexp
+
+	// UiMetrics are the metrics in the order the dashboard shows them, with
+	// the fields and chart type their trend page opens with
+	UiMetrics = []UiMetric{
+		{Metric: SleepMetric, Title: "Sleep", TrendFields: []string{"deep_sleep", "rem_sleep", "light_sleep"}, TrendType: ChartTypeStacked},
+		{Metric: ReadyScoreMetric, Title: "Readiness", TrendFields: []string{"score"}, TrendType: ChartTypeLine},
+		{Metric: HeartRateMetric, Title: "Heart rate", TrendFields: []string{"low", "average", "high"}, TrendType: ChartTypeLine, TrendBand: true},
+		{Metric: StressMetric, Title: "Stress", TrendFields: []string{"high_stress_duration"}, TrendType: ChartTypeBar},
+		{Metric: Spo2Metric, Title: "SpO2", TrendFields: []string{"average_spo2"}, TrendType: ChartTypeLine},
+	}
+)
+
+type UiHandler struct{}
+
+// UiSessionHandler signs browsers in and out of the dashboard.
+type UiSessionHandler struct{}
+
+type UiMetric struct {
+	Metric
+	Title       string
+	TrendFields []string
+	TrendType   string
+	TrendBand   bool
+}
+
+// UiPage holds what the layout needs on every page.
+type UiPage struct {
+	Title    string
+	Active   string
+	SignedIn bool
+	Today    string
+	Metrics  []UiMetric
+	Error    string
+}
+
+type UiLoginPage struct {
+	UiPage
+	Next string
+}
+
+type UiCard struct {
+	Metric   string
+	Title    string
+	Label    string
+	Recorded bool
+	Value    string
+	Status   string
+	Chart    template.HTML
+}
+
+type UiTodayPage struct {
+	UiPage
+	Date  string
+	Days  int
+	Cards []UiCard
+}
+
+type UiField struct {
+	Name     string
+	Value    string
+	Selected bool
+}
+
+type UiTrendRow struct {
+	Date   string
+	Values []string
+}
+
+type UiTrendPage struct {
+	UiPage
+	Metric  UiMetric
+	Fields  []UiField
+	Types   []string
+	Type    string
+	Band    bool
+	Start   string
+	End     string
+	Chart   template.HTML
+	Columns []string
+	Rows    []UiTrendRow
+}
+
+type UiResource struct {
+	Metric string
+	Title  string
+	Fields []UiField
+}
+
+type UiDayPage struct {
+	UiPage
+	Date      string
+	Previous  string
+	Next      string
+	Resources []UiResource
+}
+
+func init() {
+	UiRgxToday = regexp.MustCompile(`^/ui/$`)
+	UiRgxDay = regexp.MustCompile(`^/ui/day$`)
+	UiRgxDate = regexp.MustCompile(`^/ui/day/([0-9]{4}-[0-9]{2}-[0-9]{2})$`)
+	UiRgxTrend = regexp.MustCompile(`^/ui/trend/([a-z0-9]+)$`)
+	UiRgxLogin = regexp.MustCompile(`^/ui/login$`)
+	UiRgxLogout = regexp.MustCompile(`^/ui/logout$`)
+
+	for _, page := range []string{"login", "today", "trend", "day", "error"} {
+		uiTemplates[page] = template.Must(template.ParseFS(uiFiles, "ui/templates/layout.html", "ui/templates/"+page+".html"))
+	}
+}
+
+// uiStaticHandler serves the dashboard's embedded stylesheet.
+func uiStaticHandler() http.Handler {
+	static, err := fs.Sub(uiFiles, "ui/static")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/ui/static/", http.FileServer(http.FS(static)))
+}
+
+func (h *UiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && UiRgxToday.MatchString(r.URL.Path):
+		h.getToday(w, r)
+	case r.Method == http.MethodGet && UiRgxDay.MatchString(r.URL.Path):
+		http.Redirect(w, r, "/ui/day/"+r.URL.Query().Get("date"), http.StatusSeeOther)
+	case r.Method == http.MethodGet && UiRgxDate.MatchString(r.URL.Path):
+		h.getDay(w, r)
+	case r.Method == http.MethodGet && UiRgxTrend.MatchString(r.URL.Path):
+		h.getTrend(w, r)
+	default:
+		renderUi(w, http.StatusNotFound, "error", uiPage("Not found", "", "Page not found"))
+	}
+}
+
+func (h *UiHandler) getToday(w http.ResponseWriter, r *http.Request) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	params := DateRangeParams{StartDate: today.AddDate(0, 0, 1-UiTodayDays), EndDate: today}
+
+	page := UiTodayPage{
+		UiPage: uiPage("Today", "today", ""),
+		Date:   today.Format("2006-01-02"),
+		Days:   UiTodayDays,
+	}
+
+	for _, metric := range UiMetrics {
+		headline := BadgeHeadlines[metric.Name]
+
+		values, err := metric.Values(DatabaseContext, params)
+		if err != nil {
+			ErrorLog.Printf("error retrieving %s for dashboard: %v", metric.Name, err)
+			renderUi(w, http.StatusInternalServerError, "error", uiPage("Error", "", "Something went wrong loading today's data"))
+			return
+		}
+
+		card := UiCard{
+			Metric: metric.Name,
+			Title:  metric.Title,
+			Label:  headline.Label,
+			Chart: uiChart(ChartOptions{
+				Type:   ChartTypeLine,
+				Fields: []string{headline.Field},
+				Start:  params.StartDate,
+				End:    params.EndDate,
+				Width:  UiCardChartWidth,
+				Height: UiCardChartHeight,
+				Theme:  ChartThemes[DefaultChartTheme],
+			}, values),
+		}
+
+		daily := values[headline.Field]
+		if len(daily) > 0 && daily[len(daily)-1].Date.Equal(today) {
+			latest := daily[len(daily)-1].Value
+			card.Recorded, card.Value, card.Status = true, headline.Format(latest), headline.status(latest)
+		}
+
+		page.Cards = append(page.Cards, card)
+	}
+
+	renderUi(w, http.StatusOK, "today", page)
+}
+
+func (h *UiHandler) getTrend(w http.ResponseWriter, r *http.Request) {
+	name := UiRgxTrend.FindStringSubmatch(r.URL.Path)[1]
+
+	index := slices.IndexFunc(UiMetrics, func(metric UiMetric) bool { return metric.Name == name })
+	if index < 0 {
+		renderUi(w, http.StatusNotFound, "error", uiPage("Not found", "", fmt.Sprintf("There is no metric called %s", name)))
+		return
+	}
+	metric := UiMetrics[index]
+
+	// The form sends each checked field separately, charts take them joined.
+	// With no fields at all the page opens on the metric's usual chart.
+	query := r.URL.Query()
+	if query.Has("field") {
+		query.Set("field", strings.Join(query["field"], ","))
+	} else {
+		query.Set("field", strings.Join(metric.TrendFields, ","))
+		query.Set("type", metric.TrendType)
+		if metric.TrendBand {
+			query.Set("band", "true")
+		}
+	}
+	query.Set("width", fmt.Sprint(UiTrendChartWidth))
+	query.Set("height", fmt.Sprint(UiTrendChartHeight))
+	query.Set("title", "")
+
+	page := UiTrendPage{
+		UiPage: uiPage(metric.Title, metric.Name, ""),
+		Metric: metric,
+		Types:  ChartTypes,
+		Type:   query.Get("type"),
+		Band:   query.Get("band") == "true",
+		Start:  query.Get("start"),
+		End:    query.Get("end"),
+	}
+	for _, field := range metric.Fields {
+		page.Fields = append(page.Fields, UiField{Name: field, Selected: slices.Contains(strings.Split(query.Get("field"), ","), field)})
+	}
+
+	options, err := parseChartOptions(query, metric.Metric)
+	if err != nil {
+		page.Error = fmt.Sprintf("Can't draw that chart: %v", err)
+		renderUi(w, http.StatusBadRequest, "trend", page)
+		return
+	}
+	page.Type, page.Band = options.Type, options.Band
+	page.Start, page.End = options.Start.Format("2006-01-02"), options.End.Format("2006-01-02")
+	page.Columns = options.Fields
+
+	values, err := metric.Values(DatabaseContext, DateRangeParams{StartDate: options.Start, EndDate: options.End})
+	if err != nil {
+		ErrorLog.Printf("error retrieving %s for dashboard trend: %v", metric.Name, err)
+		renderUi(w, http.StatusInternalServerError, "error", uiPage("Error", "", "Something went wrong loading this trend"))
+		return
+	}
+
+	page.Chart = uiChart(options, values)
+
+	rows := map[string][]string{}
+	for i, field := range options.Fields {
+		for _, value := range values[field] {
+			date := value.Date.Format("2006-01-02")
+			if rows[date] == nil {
+				rows[date] = make([]string, len(options.Fields))
+			}
+			rows[date][i] = uiValue(field, value.Value)
+		}
+	}
+	dates := sortedKeys(rows)
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	for _, date := range dates {
+		page.Rows = append(page.Rows, UiTrendRow{Date: date, Values: rows[date]})
+	}
+
+	renderUi(w, http.StatusOK, "trend", page)
+}
+
+func (h *UiHandler) getDay(w http.ResponseWriter, r *http.Request) {
+	dateString := UiRgxDate.FindStringSubmatch(r.URL.Path)[1]
+
+	date, err := time.Parse("2006-01-02", dateString)
+	if err != nil {
+		renderUi(w, http.StatusBadRequest, "error", uiPage("Not found", "day", fmt.Sprintf("%s is not a date", dateString)))
+		return
+	}
+
+	day, err := loadDay(date)
+	if err != nil {
+		ErrorLog.Printf("error retrieving day with date '%s' for dashboard: %v", dateString, err)
+		renderUi(w, http.StatusInternalServerError, "error", uiPage("Error", "", "Something went wrong loading this day"))
+		return
+	}
+
+	rows := map[string]any{
+		SleepMetric.Name:      day.Sleep,
+		ReadyScoreMetric.Name: day.ReadyScore,
+		HeartRateMetric.Name:  day.HeartRate,
+		StressMetric.Name:     day.Stress,
+		Spo2Metric.Name:       day.Spo2,
+	}
+
+	page := UiDayPage{
+		UiPage:   uiPage(dateString, "day", ""),
+		Date:     dateString,
+		Previous: date.AddDate(0, 0, -1).Format("2006-01-02"),
+		Next:     date.AddDate(0, 0, 1).Format("2006-01-02"),
+	}
+	for _, metric := range UiMetrics {
+		page.Resources = append(page.Resources, UiResource{Metric: metric.Name, Title: metric.Title, Fields: uiFields(rows[metric.Name])})
+	}
+
+	renderUi(w, http.StatusOK, "day", page)
+}
+
+func (h *UiSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && UiRgxLogin.MatchString(r.URL.Path):
+		renderUi(w, http.StatusOK, "login", UiLoginPage{UiPage: UiPage{Title: "Sign in"}, Next: uiNext(r.URL.Query().Get("next"))})
+	case r.Method == http.MethodPost && UiRgxLogin.MatchString(r.URL.Path):
+		h.login(w, r)
+	case r.Method == http.MethodPost && UiRgxLogout.MatchString(r.URL.Path):
+		h.logout(w, r)
+	default:
+		renderUi(w, http.StatusMethodNotAllowed, "error", UiPage{Title: "Not allowed", Error: "Method not allowed"})
+	}
+}
+
+// login takes the same JWT the API accepts as a bearer token and keeps it in
+// a cookie that lasts as long as the token does.
+func (h *UiSessionHandler) login(w http.ResponseWriter, r *http.Request) {
+	next := uiNext(r.PostFormValue("next"))
+	token := strings.TrimPrefix(strings.TrimSpace(r.PostFormValue("token")), "Bearer ")
+
+	claims, err := VerifyToken(token)
+	if err != nil {
+		InfoLog.Printf("dashboard sign in refused: %v", err)
+		page := UiLoginPage{UiPage: UiPage{Title: "Sign in", Error: "That token is not valid"}, Next: next}
+		renderUi(w, http.StatusUnauthorized, "login", page)
+		return
+	}
+
+	cookie := &http.Cookie{
+		Name:     UiSessionCookie,
+		Value:    token,
+		Path:     "/ui/",
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(requestBaseUrl(r), "https:"),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if claims.ExpiresAt != nil {
+		cookie.Expires = claims.ExpiresAt.Time
+	}
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+func (h *UiSessionHandler) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     UiSessionCookie,
+		Value:    "",
+		Path:     "/ui/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, UiLoginPath, http.StatusSeeOther)
+}
+
+// uiNext keeps the page to return to after signing in within the
+// dashboard, so the login form cannot be used to redirect elsewhere.
+func uiNext(next string) string {
+	if !strings.HasPrefix(next, "/ui/") || strings.Contains(next, `\`) {
+		return "/ui/"
+	}
+	return next
+}
+
+func uiPage(title string, active string, message string) UiPage {
+	return UiPage{
+		Title:    title,
+		Active:   active,
+		SignedIn: true,
+		Today:    time.Now().UTC().Format("2006-01-02"),
+		Metrics:  UiMetrics,
+		Error:    message,
+	}
+}
+
+// uiChart embeds a chart in a page. renderChart escapes all text it draws,
+// so its SVG is safe to include as is.
+func uiChart(options ChartOptions, values map[string][]DailyValue) template.HTML {
+	return template.HTML(renderChart(options, values))
+}
+
+// uiFields lists a row's values for display, leaving out its id, date and
+// creation time. A nil row has no fields.
+func uiFields(row any) []UiField {
+	value := reflect.ValueOf(row)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil
+	}
+	value = value.Elem()
+
+	var fields []UiField
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		field := value.Field(i)
+
+		switch {
+		case name == "id" || name == "date" || name == "created_timestamp":
+			continue
+		case name == "updated_timestamp":
+			fields = append(fields, UiField{Name: "updated", Value: field.Interface().(time.Time).UTC().Format("2006-01-02 15:04 UTC")})
+		case field.CanInt():
+			fields = append(fields, UiField{Name: strings.ReplaceAll(name, "_", " "), Value: uiValue(name, float64(field.Int()))})
+		case field.CanFloat():
+			fields = append(fields, UiField{Name: strings.ReplaceAll(name, "_", " "), Value: uiValue(name, field.Float())})
+		}
+	}
+
+	return fields
+}
+
+// uiValue writes durations as hours and minutes and other values as numbers.
+func uiValue(field string, value float64) string {
+	if slices.Contains(ChartHourFields, field) {
+		return formatSeconds(int64(value))
+	}
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// renderUi writes a page in the layout. Pages are rendered in full before
+// anything is sent, so a template error can still become a 500.
+func renderUi(w http.ResponseWriter, statusCode int, page string, data any) {
+	var body bytes.Buffer
+	err := uiTemplates[page].ExecuteTemplate(&body, "layout", data)
+	if err != nil {
+		ErrorLog.Printf("error rendering %s page: %v", page, err)
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", UiContentSecurityPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// OuraWebhookMaxAge is how far an event's timestamp may be from now before
+	// it is treated as a replay
+	OuraWebhookMaxAge = 5 * time.Minute
+)
+
+var (
+	OuraWebhookRgx *regexp.Regexp
+)
+
+// OuraWebhookHandler receives Oura's webhook subscription events. It sits
+// outside the authenticator since Oura cannot send a JWT. Subscription
+// challenges are checked against VerificationToken and events against an
+// HMAC of their body keyed with Secret, the Oura client secret.
+type OuraWebhookHandler struct {
+	Secret            string
+	VerificationToken string
+}
+
+// OuraWebhookEvent is the notification Oura posts when a document changes
+type OuraWebhookEvent struct {
+	EventType string `json:"event_type"`
+	DataType  string `json:"data_type"`
+	ObjectId  string `json:"object_id"`
+	EventTime string `json:"event_time"`
+	UserId    string `json:"user_id"`
+}
+
+type OuraWebhookChallenge struct {
+	Challenge string `json:"challenge"`
+}
+
+func init() {
+	OuraWebhookRgx = regexp.MustCompile(`^/webhooks/oura$`)
+}
+
+func (h *OuraWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodGet && OuraWebhookRgx.MatchString(r.URL.Path):
+		h.verifySubscription(w, r)
+	case r.Method == http.MethodPost && OuraWebhookRgx.MatchString(r.URL.Path):
+		h.receiveEvent(w, r)
+	default:
+		handleError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// @Summary Verify the Oura webhook subscription
+// @Description Answers the challenge Oura sends when a webhook subscription is created. The
+// @Description verification_token must match OURA_WEBHOOK_VERIFICATION_TOKEN.
+// @Tags webhooks
+// @Produce json
+// @Param verification_token query string true "Token given when creating the subscription"
+// @Param challenge query string true "Value to echo back"
+// @Success 200 {object} OuraWebhookChallenge
+// @Failure 401 {object} GenericMessage
+// @Failure 503 {object} GenericMessage
+// @Router /webhooks/oura [get]
+func (h *OuraWebhookHandler) verifySubscription(w http.ResponseWriter, r *http.Request) {
+	if h.VerificationToken == "" {
+		ErrorLog.Println("oura webhook verification received but OURA_WEBHOOK_VERIFICATION_TOKEN is not set")
+		handleError(w, http.StatusServiceUnavailable, "Oura webhook is not configured")
+		return
+	}
+
+	values := r.URL.Query()
+
+	token := values.Get("verification_token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.VerificationToken)) != 1 {
+		ErrorLog.Println("oura webhook verification token does not match")
+		handleError(w, http.StatusUnauthorized, "Invalid verification token")
+		return
+	}
+
+	InfoLog.Println("oura webhook subscription verified")
+	writeJSON(w, http.StatusOK, OuraWebhookChallenge{Challenge: values.Get("challenge")})
+}
+
+// @Summary Receive an Oura webhook event
+// @Description Accepts an event Oura sends when a document is created or updated and queues the
+// @Description day it belongs to for a re-sync of its metric. The x-oura-signature header must be
+// @Description the hex HMAC-SHA256 of the x-oura-timestamp header followed by the raw body, keyed
+// @Description with the Oura client secret. Events for data types that are not synced are
+// @Description acknowledged and ignored.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param x-oura-signature header string true "HMAC-SHA256 signature"
+// @Param x-oura-timestamp header string true "Unix time the event was signed"
+// @Param event body OuraWebhookEvent true "Event"
+// @Success 202 {object} GenericMessage
+// @Failure 400 {object} GenericMessage
+// @Failure 401 {object} GenericMessage
+// @Failure 503 {object} GenericMessage
+// @Router /webhooks/oura [post]
+func (h *OuraWebhookHandler) receiveEvent(w http.ResponseWriter, r *http.Request) {
+	if h.Secret == "" || OuraSync == nil {
+		ErrorLog.Println("oura webhook event received but OURA_WEBHOOK_SECRET or OURA_ACCESS_TOKEN is not set")
+		handleError(w, http.StatusServiceUnavailable, "Oura webhook is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodyBytes))
+	if err != nil {
+		ErrorLog.Printf("error reading oura webhook body: %v", err)
+		handleError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.verifySignature(r.Header.Get("x-oura-timestamp"), r.Header.Get("x-oura-signature"), body, time.Now())
+	if err != nil {
+		ErrorLog.Printf("rejected oura webhook event: %v", err)
+		handleError(w, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	var event OuraWebhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		ErrorLog.Printf("error decoding oura webhook event: %v", err)
+		handleError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	InfoLog.Printf("oura webhook %s %s '%s'\n", event.EventType, event.DataType, event.ObjectId)
+
+	// A deleted document can no longer be fetched to find its day
+	_, synced := OuraDataTypes[event.DataType]
+	if !synced || event.EventType == "delete" || event.ObjectId == "" {
+		writeJSON(w, http.StatusAccepted, GenericMessage{Message: "Ignored"})
+		return
+	}
+
+	if !OuraSync.Enqueue(OuraDocument{DataType: event.DataType, ObjectId: event.ObjectId}) {
+		ErrorLog.Printf("oura sync queue is full, dropping %s '%s'", event.DataType, event.ObjectId)
+		handleError(w, http.StatusServiceUnavailable, "Sync queue is full")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, GenericMessage{Message: "Queued"})
+}
+
+func (h *OuraWebhookHandler) verifySignature(timestamp string, signature string, body []byte, now time.Time) error {
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing x-oura-timestamp or x-oura-signature header")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid x-oura-timestamp '%s'", timestamp)
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > OuraWebhookMaxAge || age < -OuraWebhookMaxAge {
+		return fmt.Errorf("x-oura-timestamp '%s' is more than %s from now", timestamp, OuraWebhookMaxAge)
+	}
+
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("x-oura-signature is not hex")
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return fmt.Errorf("x-oura-signature does not match")
+	}
+
+	return nil
+}
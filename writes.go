@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	MaxRequestBodyBytes = 1 << 20
+	MaxDailySeconds     = 24 * 60 * 60
+
+	// UniqueViolation is the Postgres error code raised when a row would
+	// duplicate the date of another row
+	UniqueViolation = "23505"
+)
+
+// decodeBody decodes a JSON request body into input. Fields input does not
+// have, and anything after the JSON value, are rejected.
+func decodeBody(r *http.Request, input any) error {
+	return decodeJSON(io.LimitReader(r.Body, MaxRequestBodyBytes), input)
+}
+
+func decodeJSON(reader io.Reader, input any) error {
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(input)
+	if err != nil {
+		return err
+	}
+
+	if decoder.More() {
+		return fmt.Errorf("body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+// parseInputDate parses a required date given either as YYYY-MM-DD or as the
+// RFC 3339 timestamp the API returns dates in.
+func parseInputDate(value *string) (time.Time, error) {
+	if value == nil {
+		return time.Time{}, fmt.Errorf("'date' is required")
+	}
+
+	date, err := time.Parse("2006-01-02", *value)
+	if err == nil {
+		return date, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'date' must be YYYY-MM-DD, got '%s'", *value)
+	}
+
+	return time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// checkRange returns the value of a required numeric field once it is known
+// to be present and between min and max inclusive.
+func checkRange[T int | int64 | float64](name string, value *T, min T, max T) (T, error) {
+	if value == nil {
+		return 0, fmt.Errorf("'%s' is required", name)
+	}
+
+	if *value < min || *value > max {
+		return 0, fmt.Errorf("'%s' must be between %v and %v, got %v", name, min, max, *value)
+	}
+
+	return *value, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == UniqueViolation
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	jsonBytes, err := json.Marshal(body)
+	if err != nil {
+		ErrorLog.Printf("error marshaling JSON response: %v", err)
+		handleError(w, http.StatusInternalServerError, "Internal Error")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	_, err = w.Write(jsonBytes)
+	if err != nil {
+		ErrorLog.Printf("error writing http response: %v", err)
+	}
+}